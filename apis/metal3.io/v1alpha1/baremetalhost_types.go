@@ -21,6 +21,7 @@ import (
 
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 )
 
@@ -48,6 +49,17 @@ const (
 	// annotation is present and status is empty, BMO will reconstruct BMH Status
 	// from the status annotation.
 	StatusAnnotation = "baremetalhost.metal3.io/status"
+
+	// ProvisioningPriorityAnnotation is the annotation holding an
+	// integer priority of the host when waiting for a free
+	// provisioning slot. Hosts with a higher priority get the slot
+	// first. The default is 0.
+	ProvisioningPriorityAnnotation = "baremetalhost.metal3.io/provisioning-priority"
+
+	// IronicBackendLabel is the label naming the Ironic backend that
+	// manages the host when the operator is configured with several of
+	// them. Hosts without it use the default backend.
+	IronicBackendLabel = "baremetalhost.metal3.io/ironic-backend"
 )
 
 // RootDeviceHints holds the hints for specifying the storage location
@@ -57,6 +69,11 @@ type RootDeviceHints struct {
 	// actual value exactly.
 	DeviceName string `json:"deviceName,omitempty"`
 
+	// A path to the device under /dev/disk/by-path, like
+	// "/dev/disk/by-path/pci-0000:00:1f.2-ata-1". The hint must match
+	// the actual value exactly.
+	ByPath string `json:"byPath,omitempty"`
+
 	// A SCSI bus address like 0:0:0:0. The hint must match the actual
 	// value exactly.
 	HCTL string `json:"hctl,omitempty"`
@@ -77,6 +94,10 @@ type RootDeviceHints struct {
 	// +kubebuilder:validation:Minimum=0
 	MinSizeGigabytes int `json:"minSizeGigabytes,omitempty"`
 
+	// The maximum size of the device in Gigabytes.
+	// +kubebuilder:validation:Minimum=0
+	MaxSizeGigabytes int `json:"maxSizeGigabytes,omitempty"`
+
 	// Unique storage identifier. The hint must match the actual value
 	// exactly.
 	WWN string `json:"wwn,omitempty"`
@@ -91,6 +112,74 @@ type RootDeviceHints struct {
 
 	// True if the device should use spinning media, false otherwise.
 	Rotational *bool `json:"rotational,omitempty"`
+
+	// Expressions compare fields of the device in the ways the other
+	// hints do not, such as a model among several. A field can only
+	// be used by one expression, and not by another hint.
+	// +optional
+	Expressions []RootDeviceHintExpression `json:"expressions,omitempty"`
+}
+
+// RootDeviceHintField is a field of a device compared by a root device
+// hint expression.
+// +kubebuilder:validation:Enum=deviceName;byPath;hctl;model;vendor;serialNumber;sizeGigabytes;wwn;wwnWithExtension;wwnVendorExtension
+type RootDeviceHintField string
+
+// Fields compared by root device hint expressions
+const (
+	RootDeviceHintFieldDeviceName         RootDeviceHintField = "deviceName"
+	RootDeviceHintFieldByPath             RootDeviceHintField = "byPath"
+	RootDeviceHintFieldHCTL               RootDeviceHintField = "hctl"
+	RootDeviceHintFieldModel              RootDeviceHintField = "model"
+	RootDeviceHintFieldVendor             RootDeviceHintField = "vendor"
+	RootDeviceHintFieldSerialNumber       RootDeviceHintField = "serialNumber"
+	RootDeviceHintFieldSizeGigabytes      RootDeviceHintField = "sizeGigabytes"
+	RootDeviceHintFieldWWN                RootDeviceHintField = "wwn"
+	RootDeviceHintFieldWWNWithExtension   RootDeviceHintField = "wwnWithExtension"
+	RootDeviceHintFieldWWNVendorExtension RootDeviceHintField = "wwnVendorExtension"
+)
+
+// RootDeviceHintOperator is the comparison made by a root device hint
+// expression.
+// +kubebuilder:validation:Enum=eq;ne;lt;le;gt;ge;contains;in
+type RootDeviceHintOperator string
+
+// Operators of root device hint expressions
+const (
+	// RootDeviceHintEqual matches a field equal to the value
+	RootDeviceHintEqual RootDeviceHintOperator = "eq"
+	// RootDeviceHintNotEqual matches a field different from the value
+	RootDeviceHintNotEqual RootDeviceHintOperator = "ne"
+	// RootDeviceHintLess matches a field lower than the value
+	RootDeviceHintLess RootDeviceHintOperator = "lt"
+	// RootDeviceHintLessOrEqual matches a field lower than or equal
+	// to the value
+	RootDeviceHintLessOrEqual RootDeviceHintOperator = "le"
+	// RootDeviceHintGreater matches a field greater than the value
+	RootDeviceHintGreater RootDeviceHintOperator = "gt"
+	// RootDeviceHintGreaterOrEqual matches a field greater than or
+	// equal to the value
+	RootDeviceHintGreaterOrEqual RootDeviceHintOperator = "ge"
+	// RootDeviceHintContains matches a field containing the value
+	RootDeviceHintContains RootDeviceHintOperator = "contains"
+	// RootDeviceHintIn matches a field equal to one of the values
+	RootDeviceHintIn RootDeviceHintOperator = "in"
+)
+
+// RootDeviceHintExpression compares a field of a device with values.
+// Sizes are compared as numbers, other fields as strings.
+type RootDeviceHintExpression struct {
+	// Field of the device compared.
+	Field RootDeviceHintField `json:"field"`
+
+	// Op is the comparison made. "contains" and "in" can not be used
+	// with sizeGigabytes.
+	Op RootDeviceHintOperator `json:"op"`
+
+	// Values compared with the field. Only "in" takes more than one.
+	// Sizes are positive integers.
+	// +kubebuilder:validation:MinItems=1
+	Values []string `json:"values"`
 }
 
 // BootMode is the boot mode of the system
@@ -231,6 +320,48 @@ type BMCDetails struct {
 	// insecure because it allows a man-in-the-middle to intercept the
 	// connection.
 	DisableCertificateVerification bool `json:"disableCertificateVerification,omitempty"`
+
+	// PasswordRotation enables periodic rotation of the password of
+	// the BMC account referenced by CredentialsName. Only supported
+	// for Redfish based BMCs.
+	// +optional
+	PasswordRotation *BMCPasswordRotation `json:"passwordRotation,omitempty"`
+}
+
+// BMCPasswordRotation describes how the operator rotates the BMC
+// password for a host.
+type BMCPasswordRotation struct {
+	// Interval is the time between two password rotations.
+	Interval metav1.Duration `json:"interval"`
+
+	// PasswordLength is the length of the generated passwords.
+	// +kubebuilder:validation:Minimum=8
+	// +kubebuilder:validation:Maximum=64
+	// +optional
+	PasswordLength int `json:"passwordLength,omitempty"`
+
+	// PasswordCharacters is the set of characters generated passwords
+	// are drawn from. Defaults to upper and lower case letters and
+	// digits.
+	// +optional
+	PasswordCharacters string `json:"passwordCharacters,omitempty"`
+
+	// SecretTemplate holds metadata to apply to the credentials secret
+	// each time the password is rotated.
+	// +optional
+	SecretTemplate *BMCSecretTemplate `json:"secretTemplate,omitempty"`
+}
+
+// BMCSecretTemplate holds the metadata written to a credentials
+// secret when its password is rotated.
+type BMCSecretTemplate struct {
+	// Labels to set on the secret.
+	// +optional
+	Labels map[string]string `json:"labels,omitempty"`
+
+	// Annotations to set on the secret.
+	// +optional
+	Annotations map[string]string `json:"annotations,omitempty"`
 }
 
 // HardwareRAIDVolume defines the desired configuration of volume in hardware RAID
@@ -311,6 +442,80 @@ type FirmwareConfig struct {
 	SriovEnabled *bool `json:"sriovEnabled,omitempty"`
 }
 
+// BondMode is the bonding mode of a port group, named as in the Linux
+// bonding driver.
+// +kubebuilder:validation:Enum="balance-rr";"active-backup";"balance-xor";"broadcast";"802.3ad";"balance-tlb";"balance-alb"
+type BondMode string
+
+const (
+	// BondModeActiveBackup uses one member at a time
+	BondModeActiveBackup BondMode = "active-backup"
+	// BondMode8023AD uses LACP to aggregate the members
+	BondMode8023AD BondMode = "802.3ad"
+)
+
+// NICConfig describes a NIC of the host to register with the
+// provisioner, besides the one with the BootMACAddress.
+type NICConfig struct {
+	// The MAC address of the NIC.
+	// +kubebuilder:validation:Pattern=`[0-9a-fA-F]{2}(:[0-9a-fA-F]{2}){5}`
+	MACAddress string `json:"macAddress"`
+
+	// The name of the physical network the NIC is connected to.
+	// +optional
+	PhysicalNetwork string `json:"physicalNetwork,omitempty"`
+}
+
+// BondConfig describes NICs of the host aggregated in a bond, which is
+// registered as a port group in the provisioner.
+type BondConfig struct {
+	// The name of the bond, unique within the host.
+	// +kubebuilder:validation:MaxLength=63
+	// +kubebuilder:validation:Pattern=`^[a-zA-Z0-9_.-]+$`
+	Name string `json:"name"`
+
+	// The bonding mode. Defaults to 802.3ad.
+	// +kubebuilder:default:="802.3ad"
+	// +optional
+	Mode BondMode `json:"mode,omitempty"`
+
+	// The MAC addresses of the NICs in the bond. The BootMACAddress may
+	// be one of them.
+	// +kubebuilder:validation:MinItems=1
+	Members []string `json:"members"`
+
+	// The MAC address of the bond. Defaults to the one of the first
+	// member.
+	// +kubebuilder:validation:Pattern=`[0-9a-fA-F]{2}(:[0-9a-fA-F]{2}){5}`
+	// +optional
+	MACAddress string `json:"macAddress,omitempty"`
+
+	// The name of the physical network the members are connected to.
+	// +optional
+	PhysicalNetwork string `json:"physicalNetwork,omitempty"`
+}
+
+// NetworkingConfig describes the NICs of the host and how they are
+// bonded.
+type NetworkingConfig struct {
+	// NICs lists the NICs to register in addition to the one with the
+	// BootMACAddress.
+	// +optional
+	NICs []NICConfig `json:"nics,omitempty"`
+
+	// Bonds lists the bonds to register as port groups.
+	// +optional
+	Bonds []BondConfig `json:"bonds,omitempty"`
+}
+
+// BondMACAddress returns the MAC address of the bond.
+func (b BondConfig) BondMACAddress() string {
+	if b.MACAddress != "" || len(b.Members) == 0 {
+		return b.MACAddress
+	}
+	return b.Members[0]
+}
+
 // BareMetalHostSpec defines the desired state of BareMetalHost
 type BareMetalHostSpec struct {
 	// Important: Run "make generate manifests" to regenerate code
@@ -350,6 +555,19 @@ type BareMetalHostSpec struct {
 	// +kubebuilder:validation:Pattern=`[0-9a-fA-F]{2}(:[0-9a-fA-F]{2}){5}`
 	BootMACAddress string `json:"bootMACAddress,omitempty"`
 
+	// ConductorGroup is the Ironic conductor group managing the
+	// host, for deployments where only some conductors can reach its
+	// BMC. Hosts without one use the conductors of the default group.
+	// +kubebuilder:validation:MaxLength=255
+	// +kubebuilder:validation:Pattern=`^[a-zA-Z0-9_.-]*$`
+	// +optional
+	ConductorGroup string `json:"conductorGroup,omitempty"`
+
+	// Networking describes the NICs of the host other than the one
+	// with the BootMACAddress and the bonds they are part of.
+	// +optional
+	Networking *NetworkingConfig `json:"networking,omitempty"`
+
 	// Should the server be online?
 	Online bool `json:"online"`
 
@@ -393,8 +611,51 @@ type BareMetalHostSpec struct {
 	// A custom deploy procedure.
 	// +optional
 	CustomDeploy *CustomDeploy `json:"customDeploy,omitempty"`
+
+	// DeploySteps are run while the image is deployed, in addition to
+	// the default deploy steps. Changes are only used the next time
+	// the host is provisioned.
+	// +optional
+	DeploySteps []DeployStep `json:"deploySteps,omitempty"`
+
+	// Traits are requested from the host when it is provisioned. They
+	// select the matching deploy templates configured in Ironic.
+	// Changes are only used the next time the host is provisioned.
+	// +optional
+	// +listType=set
+	Traits []Trait `json:"traits,omitempty"`
+
+	// InspectionMode selects how the hardware details are collected.
+	// In-band inspection boots an agent on the host, while out-of-band
+	// inspection only asks the BMC, which is faster but reports less.
+	// Out-of-band inspection requires a Redfish BMC.
+	// +optional
+	InspectionMode InspectionMode `json:"inspectionMode,omitempty"`
+
+	// Reinspection enables periodic inspection of the host while it is
+	// available, to notice hardware changes.
+	// +optional
+	Reinspection *ReinspectionPolicy `json:"reinspection,omitempty"`
+}
+
+// ReinspectionPolicy describes how often an available host is
+// inspected again.
+type ReinspectionPolicy struct {
+	// Interval is the time between the end of an inspection and the
+	// start of the next one.
+	Interval metav1.Duration `json:"interval"`
 }
 
+// InspectionMode is the way the hardware of a host is inspected
+// +kubebuilder:validation:Enum:=inband;outofband
+type InspectionMode string
+
+// Allowed inspection modes
+const (
+	InspectionModeInBand    InspectionMode = "inband"
+	InspectionModeOutOfBand InspectionMode = "outofband"
+)
+
 // AutomatedCleaningMode is the interface to enable/disable automated cleaning
 // +kubebuilder:validation:Enum:=metadata;disabled
 type AutomatedCleaningMode string
@@ -454,6 +715,59 @@ type CustomDeploy struct {
 	Method string `json:"method"`
 }
 
+// DeployStepInterface is the driver interface implementing a deploy
+// step.
+// +kubebuilder:validation:Enum=deploy;bios;raid;management;power
+type DeployStepInterface string
+
+// Allowed deploy step interfaces
+const (
+	DeployStepInterfaceDeploy     DeployStepInterface = "deploy"
+	DeployStepInterfaceBIOS       DeployStepInterface = "bios"
+	DeployStepInterfaceRAID       DeployStepInterface = "raid"
+	DeployStepInterfaceManagement DeployStepInterface = "management"
+	DeployStepInterfacePower      DeployStepInterface = "power"
+)
+
+// DefaultDeployStepPriority is the priority of a deploy step that does
+// not set one, which runs it together with the image deployment.
+const DefaultDeployStepPriority = 80
+
+// DeployStep is a step run while the image is deployed.
+type DeployStep struct {
+	// Interface is the driver interface implementing the step.
+	Interface DeployStepInterface `json:"interface"`
+
+	// Step is the name of the step, as documented by the driver.
+	// +kubebuilder:validation:MinLength=1
+	Step string `json:"step"`
+
+	// Args are the arguments of the step, as a JSON object.
+	// +optional
+	// +kubebuilder:pruning:PreserveUnknownFields
+	Args *runtime.RawExtension `json:"args,omitempty"`
+
+	// Priority orders the steps, highest first. The image is written
+	// at priority 80, which is also the default.
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	Priority *int `json:"priority,omitempty"`
+}
+
+// StepPriority returns the priority of the step, or the default one.
+func (s DeployStep) StepPriority() int {
+	if s.Priority == nil {
+		return DefaultDeployStepPriority
+	}
+	return *s.Priority
+}
+
+// Trait is a trait of a host, either a standard one such as
+// HW_CPU_X86_AVX2 or a custom one starting with CUSTOM_.
+// +kubebuilder:validation:MaxLength=255
+// +kubebuilder:validation:Pattern=`^[A-Z][A-Z0-9_]*$`
+type Trait string
+
 // FIXME(dhellmann): We probably want some other module to own these
 // data structures.
 
@@ -500,6 +814,59 @@ type CPU struct {
 	ClockMegahertz ClockSpeed `json:"clockMegahertz,omitempty"`
 	Flags          []string   `json:"flags,omitempty"`
 	Count          int        `json:"count,omitempty"`
+
+	// The physical processors, when reported by the agent
+	Sockets []CPUSocket `json:"sockets,omitempty"`
+}
+
+// CPUSocket describes one physical processor on the host.
+type CPUSocket struct {
+	// The index of the socket
+	ID int `json:"id"`
+
+	// The model string
+	Model string `json:"model,omitempty"`
+
+	// The number of cores of the processor
+	Cores int `json:"cores,omitempty"`
+
+	// The number of hardware threads of the processor
+	Threads int `json:"threads,omitempty"`
+}
+
+// NUMANode describes one NUMA node of the host and the resources
+// attached to it.
+type NUMANode struct {
+	// The index of the NUMA node
+	ID int `json:"id"`
+
+	// The logical CPUs of the node
+	CPUs []int `json:"cpus,omitempty"`
+
+	// The memory of the node in Mebibytes
+	MemoryMebibytes int `json:"memoryMebibytes,omitempty"`
+
+	// The names of the network interfaces attached to the node
+	NICs []string `json:"nics,omitempty"`
+
+	// The names of the disks attached to the node. Only the disks
+	// whose PCI controller is reported with its NUMA node are listed.
+	Disks []string `json:"disks,omitempty"`
+}
+
+// DIMM describes one populated memory slot of the host.
+type DIMM struct {
+	// The name of the slot, e.g. "DIMM_A1"
+	Slot string `json:"slot,omitempty"`
+
+	// The size of the module in Mebibytes
+	SizeMebibytes int `json:"sizeMebibytes,omitempty"`
+
+	// The clock speed of the module
+	SpeedMegahertz ClockSpeed `json:"speedMegahertz,omitempty"`
+
+	// The memory type, e.g. "DDR4"
+	Type string `json:"type,omitempty"`
 }
 
 // Storage describes one storage device (disk, SSD, etc.) on the host.
@@ -542,6 +909,53 @@ type Storage struct {
 
 	// The SCSI location of the device
 	HCTL string `json:"hctl,omitempty"`
+
+	// The path of the device under /dev/disk/by-path
+	ByPath string `json:"byPath,omitempty"`
+
+	// The health indicators reported by the device, if any
+	Health *StorageHealth `json:"health,omitempty"`
+
+	// The NVMe controller and namespace of the device, for NVMe devices
+	NVMe *NVMeNamespace `json:"nvme,omitempty"`
+}
+
+// StorageHealthStatus is the overall health of a storage device, as
+// assessed by the device itself.
+// +kubebuilder:validation:Enum=OK;Failing
+type StorageHealthStatus string
+
+const (
+	// StorageHealthOK means the device passed its health self-assessment
+	StorageHealthOK StorageHealthStatus = "OK"
+
+	// StorageHealthFailing means the device reports it is failing
+	StorageHealthFailing StorageHealthStatus = "Failing"
+)
+
+// StorageHealth holds the SMART or NVMe health indicators of a storage
+// device.
+type StorageHealth struct {
+	// The overall health of the device
+	Status StorageHealthStatus `json:"status,omitempty"`
+
+	// The number of reallocated sectors
+	ReallocatedSectors *int64 `json:"reallocatedSectors,omitempty"`
+
+	// The percentage of the rated endurance of the device already used
+	WearLevelPercent *int `json:"wearLevelPercent,omitempty"`
+
+	// The number of unrecovered media errors
+	MediaErrors *int64 `json:"mediaErrors,omitempty"`
+}
+
+// NVMeNamespace identifies an NVMe namespace.
+type NVMeNamespace struct {
+	// The name of the controller, e.g. "nvme0"
+	Controller string `json:"controller,omitempty"`
+
+	// The ID of the namespace on the controller
+	NamespaceID int `json:"namespaceId,omitempty"`
 }
 
 // VLANID is a 12-bit 802.1Q VLAN identifier
@@ -585,6 +999,45 @@ type NIC struct {
 
 	// Whether the NIC is PXE Bootable
 	PXE bool `json:"pxe,omitempty"`
+
+	// The switch port the NIC is cabled to, as reported by LLDP
+	LLDP *LLDP `json:"lldp,omitempty"`
+}
+
+// LLDP describes the switch port at the other end of a NIC, as
+// advertised by the switch.
+type LLDP struct {
+	// The chassis ID of the switch
+	SwitchID string `json:"switchId,omitempty"`
+
+	// The port ID on the switch
+	PortID string `json:"portId,omitempty"`
+
+	// The system name of the switch
+	SwitchSystemName string `json:"switchSystemName,omitempty"`
+}
+
+// PCIDevice describes one PCI device on the host, such as a GPU or
+// another accelerator.
+type PCIDevice struct {
+	// The PCI address of the device, e.g. "0000:3b:00.0"
+	Address string `json:"address,omitempty"`
+
+	// The vendor ID of the device, e.g. "10de"
+	VendorID string `json:"vendorId,omitempty"`
+
+	// The device ID, e.g. "20b0"
+	DeviceID string `json:"deviceId,omitempty"`
+
+	// The class and subclass of the device, e.g. "0302" for a 3D
+	// controller
+	Class string `json:"class,omitempty"`
+
+	// The vendor and product names of the device
+	Model string `json:"model,omitempty"`
+
+	// The NUMA node the device is attached to
+	NUMANode *int `json:"numaNode,omitempty"`
 }
 
 // Firmware describes the firmware on the host.
@@ -615,6 +1068,86 @@ type HardwareDetails struct {
 	Storage      []Storage            `json:"storage,omitempty"`
 	CPU          CPU                  `json:"cpu,omitempty"`
 	Hostname     string               `json:"hostname,omitempty"`
+	PCIDevices   []PCIDevice          `json:"pciDevices,omitempty"`
+	NUMANodes    []NUMANode           `json:"numaNodes,omitempty"`
+	DIMMs        []DIMM               `json:"dimms,omitempty"`
+
+	// StorageFailing is set when a storage device reports it is
+	// failing. Hosts whose root device is failing are not provisioned.
+	StorageFailing bool `json:"storageFailing,omitempty"`
+}
+
+// FailingStorage returns the names of the storage devices reporting
+// they are failing.
+func (details *HardwareDetails) FailingStorage() (names []string) {
+	if details == nil {
+		return nil
+	}
+	for _, disk := range details.Storage {
+		if disk.Health != nil && disk.Health.Status == StorageHealthFailing {
+			names = append(names, disk.Name)
+		}
+	}
+	return
+}
+
+// HardwareComponent is a part of the hardware a change was found in.
+type HardwareComponent string
+
+// Hardware components compared between inspections
+const (
+	HardwareComponentNIC      HardwareComponent = "nic"
+	HardwareComponentStorage  HardwareComponent = "storage"
+	HardwareComponentRAM      HardwareComponent = "ram"
+	HardwareComponentCPU      HardwareComponent = "cpu"
+	HardwareComponentFirmware HardwareComponent = "firmware"
+)
+
+// HardwareChangeType is the way a hardware component changed.
+type HardwareChangeType string
+
+// Hardware change types
+const (
+	HardwareAdded   HardwareChangeType = "added"
+	HardwareRemoved HardwareChangeType = "removed"
+	HardwareChanged HardwareChangeType = "changed"
+)
+
+// HardwareChange describes one difference between the hardware details
+// of two inspections.
+type HardwareChange struct {
+	// Component is the kind of hardware that changed.
+	Component HardwareComponent `json:"component"`
+
+	// Type of the change.
+	Type HardwareChangeType `json:"type"`
+
+	// Name identifies the changed device, such as the MAC of a NIC or
+	// the serial number of a disk.
+	// +optional
+	Name string `json:"name,omitempty"`
+
+	// Field is the detail that changed, for changed devices.
+	// +optional
+	Field string `json:"field,omitempty"`
+
+	// Old is the value before the change.
+	// +optional
+	Old string `json:"old,omitempty"`
+
+	// New is the value after the change.
+	// +optional
+	New string `json:"new,omitempty"`
+}
+
+// HardwareDrift records the hardware changes found by an inspection.
+type HardwareDrift struct {
+	// DetectedAt is the time the inspection finding the changes
+	// completed.
+	DetectedAt metav1.Time `json:"detectedAt"`
+
+	// Changes found by the inspection.
+	Changes []HardwareChange `json:"changes"`
 }
 
 // HardwareSystemVendor stores details about the whole hardware system.
@@ -631,6 +1164,23 @@ type CredentialsStatus struct {
 	Version   string                  `json:"credentialsVersion,omitempty"`
 }
 
+// PasswordRotationStatus records the outcome of BMC password rotations.
+type PasswordRotationStatus struct {
+	// LastRotated is the time the password was last rotated
+	// successfully.
+	// +optional
+	LastRotated *metav1.Time `json:"lastRotated,omitempty"`
+
+	// LastAttempt is the time of the last rotation attempt.
+	// +optional
+	LastAttempt *metav1.Time `json:"lastAttempt,omitempty"`
+
+	// ErrorMessage is the error reported by the last failed rotation
+	// attempt.
+	// +optional
+	ErrorMessage string `json:"errorMessage,omitempty"`
+}
+
 // RebootMode defines known variations of reboot modes
 type RebootMode string
 
@@ -689,6 +1239,32 @@ type OperationHistory struct {
 	Deprovision OperationMetric `json:"deprovision,omitempty"`
 }
 
+// MaxProvisionerHistory is the number of entries kept in the
+// ProvisionerHistory of a host.
+const MaxProvisionerHistory = 10
+
+// ProvisionerStateRecord records a state of the host seen in the
+// provisioner, such as an Ironic provision state.
+type ProvisionerStateRecord struct {
+	// The state of the host in the provisioner.
+	State string `json:"state"`
+
+	// The state the provisioner is moving the host to, if any.
+	// +optional
+	Target string `json:"target,omitempty"`
+
+	// The clean or deploy step running, as "<interface>.<step>".
+	// +optional
+	Step string `json:"step,omitempty"`
+
+	// The last error reported by the provisioner.
+	// +optional
+	LastError string `json:"lastError,omitempty"`
+
+	// When the state was first seen.
+	Time metav1.Time `json:"time"`
+}
+
 // BareMetalHostStatus defines the observed state of BareMetalHost
 type BareMetalHostStatus struct {
 	// Important: Run "make generate manifests" to regenerate code
@@ -713,6 +1289,11 @@ type BareMetalHostStatus struct {
 	// The hardware discovered to exist on the host.
 	HardwareDetails *HardwareDetails `json:"hardware,omitempty"`
 
+	// The hardware changes found the last time an inspection reported
+	// different hardware than the previous one.
+	// +optional
+	HardwareDrift *HardwareDrift `json:"hardwareDrift,omitempty"`
+
 	// Information tracked by the provisioner.
 	Provisioning ProvisionStatus `json:"provisioning"`
 
@@ -722,6 +1303,10 @@ type BareMetalHostStatus struct {
 	// the last credentials we sent to the provisioning backend
 	TriedCredentials CredentialsStatus `json:"triedCredentials,omitempty"`
 
+	// the outcome of BMC password rotations, when enabled
+	// +optional
+	PasswordRotation *PasswordRotationStatus `json:"passwordRotation,omitempty"`
+
 	// the last error message reported by the provisioning subsystem
 	ErrorMessage string `json:"errorMessage"`
 
@@ -732,6 +1317,12 @@ type BareMetalHostStatus struct {
 	// on this host.
 	OperationHistory OperationHistory `json:"operationHistory,omitempty"`
 
+	// ProvisionerHistory holds the most recent states of the host seen
+	// in the provisioner, newest first.
+	// +kubebuilder:validation:MaxItems=10
+	// +optional
+	ProvisionerHistory []ProvisionerStateRecord `json:"provisionerHistory,omitempty"`
+
 	// ErrorCount records how many times the host has encoutered an error since the last successful operation
 	// +kubebuilder:default:=0
 	ErrorCount int `json:"errorCount"`
@@ -752,6 +1343,9 @@ type ProvisionStatus struct {
 	// The RootDevicehints set by the user
 	RootDeviceHints *RootDeviceHints `json:"rootDeviceHints,omitempty"`
 
+	// The disk found by inspection that the root device hints select
+	RootDevice *Storage `json:"rootDevice,omitempty"`
+
 	// BootMode indicates the boot mode used to provision the node
 	BootMode BootMode `json:"bootMode,omitempty"`
 
@@ -761,8 +1355,23 @@ type ProvisionStatus struct {
 	// The Bios set by the user
 	Firmware *FirmwareConfig `json:"firmware,omitempty"`
 
+	// The NICs and bonds set by the user
+	Networking *NetworkingConfig `json:"networking,omitempty"`
+
+	// The networks the host was last moved to by the network
+	// switcher: provisioning, provisioned or deprovisioning.
+	NetworkPhase string `json:"networkPhase,omitempty"`
+
 	// Custom deploy procedure applied to the host.
 	CustomDeploy *CustomDeploy `json:"customDeploy,omitempty"`
+
+	// Deploy steps run when the image was deployed.
+	DeploySteps []DeployStep `json:"deploySteps,omitempty"`
+
+	// Traits requested when the host was last provisioned. The
+	// provisioner added them to the host, and removes the ones that
+	// are no longer requested when the host is provisioned again.
+	Traits []Trait `json:"traits,omitempty"`
 }
 
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
@@ -778,6 +1387,8 @@ type ProvisionStatus struct {
 // +kubebuilder:printcolumn:name="Hardware_Profile",type="string",JSONPath=".status.hardwareProfile",description="The type of hardware detected",priority=1
 // +kubebuilder:printcolumn:name="Online",type="string",JSONPath=".spec.online",description="Whether the host is online or not"
 // +kubebuilder:printcolumn:name="Error",type="string",JSONPath=".status.errorType",description="Type of the most recent error"
+// +kubebuilder:printcolumn:name="Provisioner_State",type="string",JSONPath=".status.provisionerHistory[0].state",description="Most recent state in the provisioner",priority=1
+// +kubebuilder:printcolumn:name="Step",type="string",JSONPath=".status.provisionerHistory[0].step",description="Clean or deploy step running in the provisioner",priority=1
 // +kubebuilder:object:root=true
 type BareMetalHost struct {
 	metav1.TypeMeta   `json:",inline"`
@@ -2,7 +2,11 @@
 package v1alpha1
 
 import (
+	"encoding/json"
 	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
 
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
 )
@@ -19,6 +23,22 @@ func (host *BareMetalHost) validateHost() []error {
 		errs = append(errs, err)
 	}
 
+	if err := validatePasswordRotation(host.Spec.BMC.PasswordRotation); err != nil {
+		errs = append(errs, err)
+	}
+
+	if err := validateNetworking(host.Spec.Networking); err != nil {
+		errs = append(errs, err)
+	}
+
+	if err := validateDeploySteps(host.Spec.DeploySteps); err != nil {
+		errs = append(errs, err)
+	}
+
+	if err := validateRootDeviceHints(host.Spec.RootDeviceHints); err != nil {
+		errs = append(errs, err)
+	}
+
 	return errs
 }
 
@@ -40,6 +60,13 @@ func (host *BareMetalHost) validateChanges(old *BareMetalHost) []error {
 		errs = append(errs, fmt.Errorf("bootMACAddress can not be changed once it is set"))
 	}
 
+	// The node of a registered host lives in its Ironic backend, moving
+	// the host to another one would leave the node behind.
+	if old.Status.Provisioning.ID != "" &&
+		host.Labels[IronicBackendLabel] != old.Labels[IronicBackendLabel] {
+		errs = append(errs, fmt.Errorf("%s label can not be changed once the host is registered", IronicBackendLabel))
+	}
+
 	return errs
 }
 
@@ -54,3 +81,159 @@ func validateRAID(r *RAIDConfig) error {
 
 	return nil
 }
+
+func validatePasswordRotation(r *BMCPasswordRotation) error {
+	if r == nil {
+		return nil
+	}
+
+	if r.Interval.Duration <= 0 {
+		return fmt.Errorf("passwordRotation interval must be greater than zero")
+	}
+
+	if r.PasswordCharacters != "" && len(r.PasswordCharacters) < 2 {
+		return fmt.Errorf("passwordRotation passwordCharacters must contain at least 2 characters")
+	}
+
+	return nil
+}
+
+func validateNetworking(n *NetworkingConfig) error {
+	if n == nil {
+		return nil
+	}
+
+	macs := map[string]bool{}
+	for _, nic := range n.NICs {
+		mac := strings.ToLower(nic.MACAddress)
+		if macs[mac] {
+			return fmt.Errorf("networking NIC %s is listed more than once", nic.MACAddress)
+		}
+		macs[mac] = true
+	}
+
+	names := map[string]bool{}
+	members := map[string]string{}
+	for _, bond := range n.Bonds {
+		if names[bond.Name] {
+			return fmt.Errorf("networking bond %s is defined more than once", bond.Name)
+		}
+		names[bond.Name] = true
+		for _, member := range bond.Members {
+			mac := strings.ToLower(member)
+			if other, found := members[mac]; found {
+				return fmt.Errorf("networking NIC %s is a member of bonds %s and %s", member, other, bond.Name)
+			}
+			members[mac] = bond.Name
+		}
+	}
+
+	return nil
+}
+
+func validateDeploySteps(steps []DeployStep) error {
+	seen := map[string]bool{}
+	for _, step := range steps {
+		name := fmt.Sprintf("%s.%s", step.Interface, step.Step)
+		if seen[name] {
+			return fmt.Errorf("deploy step %s is listed more than once", name)
+		}
+		seen[name] = true
+
+		if step.Args != nil && len(step.Args.Raw) > 0 {
+			var args map[string]interface{}
+			if err := json.Unmarshal(step.Args.Raw, &args); err != nil {
+				return fmt.Errorf("arguments of deploy step %s are not a JSON object", name)
+			}
+		}
+	}
+	return nil
+}
+
+// hintOperatorPattern matches the words the provisioner reads as
+// operators in root device hints, which values must not contain.
+var hintOperatorPattern = regexp.MustCompile(`^(<[a-z-]*>|s?(==|!=|<=|>=|<|>)|=|[\[\]()])$`)
+
+// setHintFields returns the fields of the device already compared by
+// the hints other than expressions.
+func (hints *RootDeviceHints) setHintFields() map[RootDeviceHintField]bool {
+	return map[RootDeviceHintField]bool{
+		RootDeviceHintFieldDeviceName:         hints.DeviceName != "",
+		RootDeviceHintFieldByPath:             hints.ByPath != "",
+		RootDeviceHintFieldHCTL:               hints.HCTL != "",
+		RootDeviceHintFieldModel:              hints.Model != "",
+		RootDeviceHintFieldVendor:             hints.Vendor != "",
+		RootDeviceHintFieldSerialNumber:       hints.SerialNumber != "",
+		RootDeviceHintFieldSizeGigabytes:      hints.MinSizeGigabytes != 0 || hints.MaxSizeGigabytes != 0,
+		RootDeviceHintFieldWWN:                hints.WWN != "",
+		RootDeviceHintFieldWWNWithExtension:   hints.WWNWithExtension != "",
+		RootDeviceHintFieldWWNVendorExtension: hints.WWNVendorExtension != "",
+	}
+}
+
+func validateRootDeviceHints(hints *RootDeviceHints) error {
+	if hints == nil {
+		return nil
+	}
+
+	if hints.ByPath != "" && !strings.HasPrefix(hints.ByPath, "/dev/disk/by-path/") {
+		return fmt.Errorf("rootDeviceHints byPath must be a path under /dev/disk/by-path/")
+	}
+
+	if hints.MaxSizeGigabytes != 0 && hints.MinSizeGigabytes > hints.MaxSizeGigabytes {
+		return fmt.Errorf("rootDeviceHints minSizeGigabytes can not be greater than maxSizeGigabytes")
+	}
+
+	used := hints.setHintFields()
+	seen := map[RootDeviceHintField]bool{}
+	for _, expr := range hints.Expressions {
+		if seen[expr.Field] {
+			return fmt.Errorf("rootDeviceHints expression on %s is listed more than once", expr.Field)
+		}
+		seen[expr.Field] = true
+		if used[expr.Field] {
+			return fmt.Errorf("rootDeviceHints %s is compared by both a hint and an expression", expr.Field)
+		}
+		if err := validateRootDeviceHintExpression(expr); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func validateRootDeviceHintExpression(expr RootDeviceHintExpression) error {
+	isSize := expr.Field == RootDeviceHintFieldSizeGigabytes
+	switch expr.Op {
+	case RootDeviceHintIn:
+		if len(expr.Values) == 0 {
+			return fmt.Errorf("rootDeviceHints expression on %s needs at least one value", expr.Field)
+		}
+	default:
+		if len(expr.Values) != 1 {
+			return fmt.Errorf("rootDeviceHints expression on %s with operator %s takes exactly one value", expr.Field, expr.Op)
+		}
+	}
+	if isSize && (expr.Op == RootDeviceHintContains || expr.Op == RootDeviceHintIn) {
+		return fmt.Errorf("rootDeviceHints operator %s can not be used with %s", expr.Op, expr.Field)
+	}
+
+	for _, value := range expr.Values {
+		words := strings.Fields(value)
+		if len(words) == 0 {
+			return fmt.Errorf("rootDeviceHints expression on %s has an empty value", expr.Field)
+		}
+		for _, word := range words {
+			if hintOperatorPattern.MatchString(word) {
+				return fmt.Errorf("rootDeviceHints expression on %s has a value containing the operator %q", expr.Field, word)
+			}
+		}
+		if isSize {
+			if size, err := strconv.Atoi(value); err != nil || size <= 0 {
+				return fmt.Errorf("rootDeviceHints expression on %s has a value %q that is not a positive integer", expr.Field, value)
+			}
+		}
+	}
+
+	return nil
+}
@@ -2,8 +2,10 @@ package v1alpha1
 
 import (
 	"testing"
+	"time"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 )
 
 func errorArrContains(out []error, want string) bool {
@@ -71,6 +73,295 @@ func TestValidateCreate(t *testing.T) {
 			oldBMH:    nil,
 			wantedErr: "hardwareRAIDVolumes and softwareRAIDVolumes can not be set at the same time",
 		},
+		{
+			name: "invalidPasswordRotationInterval",
+			newBMH: &BareMetalHost{
+				TypeMeta:   tm,
+				ObjectMeta: om,
+				Spec: BareMetalHostSpec{
+					BMC: BMCDetails{
+						PasswordRotation: &BMCPasswordRotation{},
+					}}},
+			oldBMH:    nil,
+			wantedErr: "passwordRotation interval must be greater than zero",
+		},
+		{
+			name: "invalidPasswordRotationCharacters",
+			newBMH: &BareMetalHost{
+				TypeMeta:   tm,
+				ObjectMeta: om,
+				Spec: BareMetalHostSpec{
+					BMC: BMCDetails{
+						PasswordRotation: &BMCPasswordRotation{
+							Interval:           metav1.Duration{Duration: time.Hour},
+							PasswordCharacters: "a",
+						},
+					}}},
+			oldBMH:    nil,
+			wantedErr: "passwordRotation passwordCharacters must contain at least 2 characters",
+		},
+		{
+			name: "validNetworking",
+			newBMH: &BareMetalHost{
+				TypeMeta:   tm,
+				ObjectMeta: om,
+				Spec: BareMetalHostSpec{
+					Networking: &NetworkingConfig{
+						NICs: []NICConfig{{MACAddress: "00:00:00:00:00:03"}},
+						Bonds: []BondConfig{{
+							Name:    "bond0",
+							Members: []string{"00:00:00:00:00:01", "00:00:00:00:00:02"},
+						}},
+					}}},
+			oldBMH:    nil,
+			wantedErr: "",
+		},
+		{
+			name: "duplicateNetworkingBond",
+			newBMH: &BareMetalHost{
+				TypeMeta:   tm,
+				ObjectMeta: om,
+				Spec: BareMetalHostSpec{
+					Networking: &NetworkingConfig{
+						Bonds: []BondConfig{
+							{Name: "bond0", Members: []string{"00:00:00:00:00:01"}},
+							{Name: "bond0", Members: []string{"00:00:00:00:00:02"}},
+						},
+					}}},
+			oldBMH:    nil,
+			wantedErr: "networking bond bond0 is defined more than once",
+		},
+		{
+			name: "sharedNetworkingBondMember",
+			newBMH: &BareMetalHost{
+				TypeMeta:   tm,
+				ObjectMeta: om,
+				Spec: BareMetalHostSpec{
+					Networking: &NetworkingConfig{
+						Bonds: []BondConfig{
+							{Name: "bond0", Members: []string{"00:00:00:00:00:01"}},
+							{Name: "bond1", Members: []string{"00:00:00:00:00:01"}},
+						},
+					}}},
+			oldBMH:    nil,
+			wantedErr: "networking NIC 00:00:00:00:00:01 is a member of bonds bond0 and bond1",
+		},
+		{
+			name: "validDeploySteps",
+			newBMH: &BareMetalHost{
+				TypeMeta:   tm,
+				ObjectMeta: om,
+				Spec: BareMetalHostSpec{
+					DeploySteps: []DeployStep{
+						{
+							Interface: DeployStepInterfaceBIOS,
+							Step:      "apply_configuration",
+							Args:      &runtime.RawExtension{Raw: []byte(`{"settings": [{"name": "LogicalProc", "value": "Disabled"}]}`)},
+						},
+						{Interface: DeployStepInterfaceDeploy, Step: "write_image"},
+					},
+					Traits: []Trait{"CUSTOM_HYPERTHREADING_OFF"},
+				}},
+			oldBMH:    nil,
+			wantedErr: "",
+		},
+		{
+			name: "duplicateDeployStep",
+			newBMH: &BareMetalHost{
+				TypeMeta:   tm,
+				ObjectMeta: om,
+				Spec: BareMetalHostSpec{
+					DeploySteps: []DeployStep{
+						{Interface: DeployStepInterfaceRAID, Step: "apply_configuration"},
+						{Interface: DeployStepInterfaceRAID, Step: "apply_configuration"},
+					},
+				}},
+			oldBMH:    nil,
+			wantedErr: "deploy step raid.apply_configuration is listed more than once",
+		},
+		{
+			name: "deployStepArgsNotObject",
+			newBMH: &BareMetalHost{
+				TypeMeta:   tm,
+				ObjectMeta: om,
+				Spec: BareMetalHostSpec{
+					DeploySteps: []DeployStep{{
+						Interface: DeployStepInterfaceBIOS,
+						Step:      "apply_configuration",
+						Args:      &runtime.RawExtension{Raw: []byte(`["LogicalProc"]`)},
+					}},
+				}},
+			oldBMH:    nil,
+			wantedErr: "arguments of deploy step bios.apply_configuration are not a JSON object",
+		},
+		{
+			name: "validRootDeviceHints",
+			newBMH: &BareMetalHost{
+				TypeMeta:   tm,
+				ObjectMeta: om,
+				Spec: BareMetalHostSpec{
+					RootDeviceHints: &RootDeviceHints{
+						ByPath:           "/dev/disk/by-path/pci-0000:00:1f.2-ata-1",
+						MinSizeGigabytes: 400,
+						MaxSizeGigabytes: 1000,
+						Expressions: []RootDeviceHintExpression{
+							{Field: RootDeviceHintFieldDeviceName, Op: RootDeviceHintContains, Values: []string{"nvme"}},
+							{Field: RootDeviceHintFieldModel, Op: RootDeviceHintIn, Values: []string{"Micron 5200", "Micron 5300"}},
+						},
+					},
+				}},
+			oldBMH:    nil,
+			wantedErr: "",
+		},
+		{
+			name: "rootDeviceHintsByPathNotByPath",
+			newBMH: &BareMetalHost{
+				TypeMeta:   tm,
+				ObjectMeta: om,
+				Spec: BareMetalHostSpec{
+					RootDeviceHints: &RootDeviceHints{
+						ByPath: "/dev/sda",
+					},
+				}},
+			oldBMH:    nil,
+			wantedErr: "rootDeviceHints byPath must be a path under /dev/disk/by-path/",
+		},
+		{
+			name: "rootDeviceHintsSizeRange",
+			newBMH: &BareMetalHost{
+				TypeMeta:   tm,
+				ObjectMeta: om,
+				Spec: BareMetalHostSpec{
+					RootDeviceHints: &RootDeviceHints{
+						MinSizeGigabytes: 1000,
+						MaxSizeGigabytes: 400,
+					},
+				}},
+			oldBMH:    nil,
+			wantedErr: "rootDeviceHints minSizeGigabytes can not be greater than maxSizeGigabytes",
+		},
+		{
+			name: "rootDeviceHintsDuplicateExpression",
+			newBMH: &BareMetalHost{
+				TypeMeta:   tm,
+				ObjectMeta: om,
+				Spec: BareMetalHostSpec{
+					RootDeviceHints: &RootDeviceHints{
+						Expressions: []RootDeviceHintExpression{
+							{Field: RootDeviceHintFieldSizeGigabytes, Op: RootDeviceHintGreaterOrEqual, Values: []string{"400"}},
+							{Field: RootDeviceHintFieldSizeGigabytes, Op: RootDeviceHintLess, Values: []string{"1000"}},
+						},
+					},
+				}},
+			oldBMH:    nil,
+			wantedErr: "rootDeviceHints expression on sizeGigabytes is listed more than once",
+		},
+		{
+			name: "rootDeviceHintsExpressionAndHint",
+			newBMH: &BareMetalHost{
+				TypeMeta:   tm,
+				ObjectMeta: om,
+				Spec: BareMetalHostSpec{
+					RootDeviceHints: &RootDeviceHints{
+						Model: "Micron",
+						Expressions: []RootDeviceHintExpression{
+							{Field: RootDeviceHintFieldModel, Op: RootDeviceHintNotEqual, Values: []string{"Micron 5100"}},
+						},
+					},
+				}},
+			oldBMH:    nil,
+			wantedErr: "rootDeviceHints model is compared by both a hint and an expression",
+		},
+		{
+			name: "rootDeviceHintsTooManyValues",
+			newBMH: &BareMetalHost{
+				TypeMeta:   tm,
+				ObjectMeta: om,
+				Spec: BareMetalHostSpec{
+					RootDeviceHints: &RootDeviceHints{
+						Expressions: []RootDeviceHintExpression{
+							{Field: RootDeviceHintFieldVendor, Op: RootDeviceHintEqual, Values: []string{"Dell", "HP"}},
+						},
+					},
+				}},
+			oldBMH:    nil,
+			wantedErr: "rootDeviceHints expression on vendor with operator eq takes exactly one value",
+		},
+		{
+			name: "rootDeviceHintsSizeContains",
+			newBMH: &BareMetalHost{
+				TypeMeta:   tm,
+				ObjectMeta: om,
+				Spec: BareMetalHostSpec{
+					RootDeviceHints: &RootDeviceHints{
+						Expressions: []RootDeviceHintExpression{
+							{Field: RootDeviceHintFieldSizeGigabytes, Op: RootDeviceHintContains, Values: []string{"4"}},
+						},
+					},
+				}},
+			oldBMH:    nil,
+			wantedErr: "rootDeviceHints operator contains can not be used with sizeGigabytes",
+		},
+		{
+			name: "rootDeviceHintsSizeNotNumber",
+			newBMH: &BareMetalHost{
+				TypeMeta:   tm,
+				ObjectMeta: om,
+				Spec: BareMetalHostSpec{
+					RootDeviceHints: &RootDeviceHints{
+						Expressions: []RootDeviceHintExpression{
+							{Field: RootDeviceHintFieldSizeGigabytes, Op: RootDeviceHintGreater, Values: []string{"400GB"}},
+						},
+					},
+				}},
+			oldBMH:    nil,
+			wantedErr: "rootDeviceHints expression on sizeGigabytes has a value \"400GB\" that is not a positive integer",
+		},
+		{
+			name: "rootDeviceHintsSizeNotInteger",
+			newBMH: &BareMetalHost{
+				TypeMeta:   tm,
+				ObjectMeta: om,
+				Spec: BareMetalHostSpec{
+					RootDeviceHints: &RootDeviceHints{
+						Expressions: []RootDeviceHintExpression{
+							{Field: RootDeviceHintFieldSizeGigabytes, Op: RootDeviceHintGreater, Values: []string{"400.5"}},
+						},
+					},
+				}},
+			oldBMH:    nil,
+			wantedErr: "rootDeviceHints expression on sizeGigabytes has a value \"400.5\" that is not a positive integer",
+		},
+		{
+			name: "rootDeviceHintsSizeZero",
+			newBMH: &BareMetalHost{
+				TypeMeta:   tm,
+				ObjectMeta: om,
+				Spec: BareMetalHostSpec{
+					RootDeviceHints: &RootDeviceHints{
+						Expressions: []RootDeviceHintExpression{
+							{Field: RootDeviceHintFieldSizeGigabytes, Op: RootDeviceHintLess, Values: []string{"0"}},
+						},
+					},
+				}},
+			oldBMH:    nil,
+			wantedErr: "rootDeviceHints expression on sizeGigabytes has a value \"0\" that is not a positive integer",
+		},
+		{
+			name: "rootDeviceHintsValueWithOperator",
+			newBMH: &BareMetalHost{
+				TypeMeta:   tm,
+				ObjectMeta: om,
+				Spec: BareMetalHostSpec{
+					RootDeviceHints: &RootDeviceHints{
+						Expressions: []RootDeviceHintExpression{
+							{Field: RootDeviceHintFieldSerialNumber, Op: RootDeviceHintEqual, Values: []string{"ABC <or> DEF"}},
+						},
+					},
+				}},
+			oldBMH:    nil,
+			wantedErr: "rootDeviceHints expression on serialNumber has a value containing the operator \"<or>\"",
+		},
 	}
 
 	for _, tt := range tests {
@@ -123,6 +414,44 @@ func TestValidateUpdate(t *testing.T) {
 				TypeMeta: tm, ObjectMeta: om, Spec: BareMetalHostSpec{BootMACAddress: "test-mac"}},
 			wantedErr: "bootMACAddress can not be changed once it is set",
 		},
+		{
+			name: "updateBackendRegistered",
+			newBMH: &BareMetalHost{
+				TypeMeta: tm,
+				ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "test-namespace",
+					Labels: map[string]string{IronicBackendLabel: "site-b"}},
+				Status: BareMetalHostStatus{Provisioning: ProvisionStatus{ID: "node-uuid"}}},
+			oldBMH: &BareMetalHost{
+				TypeMeta: tm,
+				ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "test-namespace",
+					Labels: map[string]string{IronicBackendLabel: "site-a"}},
+				Status: BareMetalHostStatus{Provisioning: ProvisionStatus{ID: "node-uuid"}}},
+			wantedErr: "baremetalhost.metal3.io/ironic-backend label can not be changed once the host is registered",
+		},
+		{
+			name: "addBackendRegistered",
+			newBMH: &BareMetalHost{
+				TypeMeta: tm,
+				ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "test-namespace",
+					Labels: map[string]string{IronicBackendLabel: "site-b"}},
+				Status: BareMetalHostStatus{Provisioning: ProvisionStatus{ID: "node-uuid"}}},
+			oldBMH: &BareMetalHost{
+				TypeMeta: tm, ObjectMeta: om,
+				Status: BareMetalHostStatus{Provisioning: ProvisionStatus{ID: "node-uuid"}}},
+			wantedErr: "baremetalhost.metal3.io/ironic-backend label can not be changed once the host is registered",
+		},
+		{
+			name: "updateBackendNotRegistered",
+			newBMH: &BareMetalHost{
+				TypeMeta: tm,
+				ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "test-namespace",
+					Labels: map[string]string{IronicBackendLabel: "site-b"}}},
+			oldBMH: &BareMetalHost{
+				TypeMeta: tm,
+				ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "test-namespace",
+					Labels: map[string]string{IronicBackendLabel: "site-a"}}},
+			wantedErr: "",
+		},
 	}
 
 	for _, tt := range tests {
@@ -0,0 +1,147 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+// NOTE: json tags are required.  Any new fields you add must have json tags for the fields to be serialized.
+
+const (
+	// InspectionRuleFinalizer is the name of the finalizer added to
+	// inspection rules, to remove them from the provisioner when they
+	// are deleted.
+	InspectionRuleFinalizer string = "inspectionrule.metal3.io"
+)
+
+// InspectionRuleCondition is a check on the data collected by
+// inspection. A rule only runs its actions when all its conditions
+// match.
+type InspectionRuleCondition struct {
+	// Op is the comparison to make.
+	// +kubebuilder:validation:Enum=eq;ne;lt;gt;le;ge;in-net;matches;contains;is-empty
+	Op string `json:"op"`
+
+	// Field is the path of the compared value, such as
+	// "data://inventory.cpu.count" or "node://properties.cpu_arch".
+	// +kubebuilder:validation:MinLength=1
+	Field string `json:"field"`
+
+	// Value is what the field is compared to. It is not needed by the
+	// is-empty operator.
+	// +optional
+	Value *intstr.IntOrString `json:"value,omitempty"`
+
+	// Invert negates the result of the comparison.
+	// +optional
+	Invert bool `json:"invert,omitempty"`
+
+	// Multiple selects how a field matching several values is
+	// checked: "any" or "all" of them must match, or only the "first"
+	// one is used.
+	// +kubebuilder:validation:Enum=any;all;first
+	// +optional
+	Multiple string `json:"multiple,omitempty"`
+}
+
+// InspectionRuleAction is a change made to a host whose inspection
+// data matches the conditions of the rule.
+type InspectionRuleAction struct {
+	// Action is the name of the action, such as "set-attribute",
+	// "set-capability", "add-trait" or "fail".
+	// +kubebuilder:validation:MinLength=1
+	Action string `json:"action"`
+
+	// Args are the arguments of the action, such as the path and value
+	// of "set-attribute".
+	// +kubebuilder:pruning:PreserveUnknownFields
+	// +optional
+	Args *runtime.RawExtension `json:"args,omitempty"`
+}
+
+// InspectionRuleSpec defines the desired state of InspectionRule
+type InspectionRuleSpec struct {
+	// Description of the rule, shown in the provisioner.
+	// +optional
+	Description string `json:"description,omitempty"`
+
+	// Conditions that must all match for the actions to run. A rule
+	// without conditions applies to every host.
+	// +optional
+	Conditions []InspectionRuleCondition `json:"conditions,omitempty"`
+
+	// Actions run on the hosts matching the conditions.
+	// +kubebuilder:validation:MinItems=1
+	Actions []InspectionRuleAction `json:"actions"`
+}
+
+// InspectionRuleStatus defines the observed state of InspectionRule
+type InspectionRuleStatus struct {
+	// Applied is set when the provisioner holds the rule as last
+	// observed.
+	Applied bool `json:"applied"`
+
+	// UUID of the rule in the provisioner.
+	// +optional
+	UUID string `json:"uuid,omitempty"`
+
+	// ObservedGeneration is the generation of the rule last applied
+	// or tried.
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// ErrorMessage is the error of the last attempt to apply the rule.
+	// +optional
+	ErrorMessage string `json:"errorMessage,omitempty"`
+
+	// Backend is the Ironic backend the rule was last applied to, from
+	// its baremetalhost.metal3.io/ironic-backend label. It is empty for
+	// the default backend.
+	// +optional
+	Backend string `json:"backend,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+//+kubebuilder:printcolumn:name="Applied",type="boolean",JSONPath=".status.applied",description="Whether the rule is applied"
+//+kubebuilder:printcolumn:name="Error",type="string",JSONPath=".status.errorMessage",description="The error applying the rule"
+//+kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
+
+// InspectionRule is the Schema for the inspectionrules API
+type InspectionRule struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   InspectionRuleSpec   `json:"spec,omitempty"`
+	Status InspectionRuleStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// InspectionRuleList contains a list of InspectionRule
+type InspectionRuleList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []InspectionRule `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&InspectionRule{}, &InspectionRuleList{})
+}
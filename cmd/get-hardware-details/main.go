@@ -4,24 +4,128 @@ package main
 
 import (
 	"encoding/json"
+	"flag"
 	"fmt"
+	"io"
+	"io/ioutil"
 	"os"
+	"sort"
 	"strings"
 
+	"github.com/gophercloud/gophercloud"
 	"github.com/gophercloud/gophercloud/openstack/baremetalintrospection/v1/introspection"
+	"github.com/gophercloud/gophercloud/pagination"
+	"github.com/pkg/errors"
 
 	"github.com/shweta50/baremetal-operator/pkg/provisioner/ironic/clients"
-	"github.com/shweta50/baremetal-operator/pkg/provisioner/ironic/hardwaredetails"
 )
 
+const usage = `Usage:
+  get-hardware-details [flags] <inspector URI> <node UUID>
+  get-hardware-details [flags] -all <inspector URI>
+  get-hardware-details [flags] -file <path>
+
+Flags:
+`
+
 type options struct {
 	Endpoint   string
 	AuthConfig clients.AuthConfig
 	NodeID     string
+	File       string
+	All        bool
+	Output     string
 }
 
 func main() {
 	opts := getOptions()
+
+	var nodes []rawNode
+	var err error
+	keyed := opts.All
+	if opts.File != "" {
+		nodes, keyed, err = readFile(opts.File)
+	} else {
+		nodes, err = fetchFromInspector(opts)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "could not get introspection data: %s\n", err)
+		os.Exit(1)
+	}
+
+	if err := writeOutput(os.Stdout, opts.Output, nodes, keyed); err != nil {
+		fmt.Fprintf(os.Stderr, "could not convert introspection data: %s\n", err)
+		os.Exit(1)
+	}
+}
+
+func getOptions() (o options) {
+	flag.Usage = func() {
+		fmt.Fprint(flag.CommandLine.Output(), usage)
+		flag.PrintDefaults()
+	}
+	flag.StringVar(&o.File, "file", "", "read saved introspection data from a file instead of Inspector, \"-\" for stdin")
+	flag.BoolVar(&o.All, "all", false, "get the details of every node introspected by Inspector")
+	flag.StringVar(&o.Output, "output", outputJSON,
+		fmt.Sprintf("output format, one of %s", strings.Join(outputFormats, ", ")))
+	flag.Parse()
+
+	if !validOutput(o.Output) {
+		fmt.Fprintf(os.Stderr, "Invalid output format %q\n", o.Output)
+		os.Exit(1)
+	}
+
+	if o.File != "" {
+		if o.All || flag.NArg() != 0 {
+			fmt.Fprintf(os.Stderr, "-file can not be used with -all or an inspector URI\n")
+			os.Exit(1)
+		}
+		return
+	}
+
+	wantArgs := 2
+	if o.All {
+		wantArgs = 1
+	}
+	if flag.NArg() != wantArgs {
+		flag.Usage()
+		os.Exit(1)
+	}
+
+	var err error
+	o.Endpoint, o.AuthConfig, err = clients.ConfigFromEndpointURL(flag.Arg(0))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %s\n", err)
+		os.Exit(1)
+	}
+	if !o.All {
+		o.NodeID = flag.Arg(1)
+	}
+	return
+}
+
+// readFile loads introspection data saved from Inspector, either for a
+// single node or, as written with "-all -output raw", for several nodes
+// keyed by UUID. keyed is set in the latter case.
+func readFile(path string) (nodes []rawNode, keyed bool, err error) {
+	var input io.Reader = os.Stdin
+	if path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, false, err
+		}
+		defer f.Close()
+		input = f
+	}
+
+	content, err := ioutil.ReadAll(input)
+	if err != nil {
+		return nil, false, err
+	}
+	return parseSavedData(content)
+}
+
+func fetchFromInspector(opts options) ([]rawNode, error) {
 	ironicTrustedCAFile := os.Getenv("IRONIC_CACERT_FILE")
 	ironicInsecureStr := os.Getenv("IRONIC_INSECURE")
 	ironicInsecure := false
@@ -36,38 +140,54 @@ func main() {
 
 	inspector, err := clients.InspectorClient(opts.Endpoint, opts.AuthConfig, tlsConf)
 	if err != nil {
-		fmt.Printf("could not get inspector client: %s", err)
-		os.Exit(1)
+		return nil, errors.Wrap(err, "could not get inspector client")
 	}
 
-	introData := introspection.GetIntrospectionData(inspector, opts.NodeID)
-	data, err := introData.Extract()
-	if err != nil {
-		fmt.Printf("could not get introspection data: %s", err)
-		os.Exit(1)
+	nodeIDs := []string{opts.NodeID}
+	if opts.All {
+		if nodeIDs, err = listIntrospectedNodes(inspector); err != nil {
+			return nil, err
+		}
 	}
 
-	json, err := json.MarshalIndent(hardwaredetails.GetHardwareDetails(data), "", "\t")
-	if err != nil {
-		fmt.Printf("could not convert introspection data: %s", err)
-		os.Exit(1)
+	nodes := make([]rawNode, 0, len(nodeIDs))
+	for _, nodeID := range nodeIDs {
+		result := introspection.GetIntrospectionData(inspector, nodeID)
+		if result.Err != nil {
+			return nil, errors.Wrapf(result.Err, "node %s", nodeID)
+		}
+		data, err := json.Marshal(result.Body)
+		if err != nil {
+			return nil, errors.Wrapf(err, "node %s", nodeID)
+		}
+		nodes = append(nodes, rawNode{ID: nodeID, Data: data})
 	}
-
-	fmt.Println(string(json))
+	return nodes, nil
 }
 
-func getOptions() (o options) {
-	if len(os.Args) != 3 {
-		fmt.Println("Usage: get-hardware-details <inspector URI> <node UUID>")
-		os.Exit(1)
-	}
-
-	var err error
-	o.Endpoint, o.AuthConfig, err = clients.ConfigFromEndpointURL(os.Args[1])
+// listIntrospectedNodes returns the UUIDs of the nodes whose last
+// introspection succeeded, sorted. The others have no data to convert.
+func listIntrospectedNodes(inspector *gophercloud.ServiceClient) (nodeIDs []string, err error) {
+	err = introspection.ListIntrospections(inspector, nil).EachPage(func(page pagination.Page) (bool, error) {
+		introspections, err := introspection.ExtractIntrospections(page)
+		if err != nil {
+			return false, err
+		}
+		for _, intro := range introspections {
+			switch {
+			case !intro.Finished:
+				fmt.Fprintf(os.Stderr, "skipping node %s: introspection is not finished\n", intro.UUID)
+			case intro.Error != "":
+				fmt.Fprintf(os.Stderr, "skipping node %s: introspection failed: %s\n", intro.UUID, intro.Error)
+			default:
+				nodeIDs = append(nodeIDs, intro.UUID)
+			}
+		}
+		return true, nil
+	})
 	if err != nil {
-		fmt.Printf("Error: %s\n", err)
-		os.Exit(1)
+		return nil, errors.Wrap(err, "could not list introspections")
 	}
-	o.NodeID = os.Args[2]
-	return
+	sort.Strings(nodeIDs)
+	return nodeIDs, nil
 }
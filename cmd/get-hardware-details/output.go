@@ -0,0 +1,200 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"text/tabwriter"
+
+	"github.com/pkg/errors"
+	"sigs.k8s.io/yaml"
+
+	metal3v1alpha1 "github.com/shweta50/baremetal-operator/apis/metal3.io/v1alpha1"
+	"github.com/shweta50/baremetal-operator/pkg/provisioner/ironic/hardwaredetails"
+)
+
+const (
+	outputJSON       = "json"
+	outputYAML       = "yaml"
+	outputAnnotation = "annotation"
+	outputTable      = "table"
+	outputRaw        = "raw"
+
+	inspectAnnotation         = "inspect.metal3.io"
+	hardwareDetailsAnnotation = inspectAnnotation + "/hardwaredetails"
+)
+
+var outputFormats = []string{outputJSON, outputYAML, outputAnnotation, outputTable, outputRaw}
+
+func validOutput(format string) bool {
+	for _, f := range outputFormats {
+		if f == format {
+			return true
+		}
+	}
+	return false
+}
+
+// rawNode holds the introspection data of a node as returned by
+// Inspector. ID is empty when the data was loaded for a single unnamed
+// node.
+type rawNode struct {
+	ID   string
+	Data json.RawMessage
+}
+
+// parseSavedData reads introspection data of a single node, or of
+// several nodes keyed by UUID.
+func parseSavedData(content []byte) (nodes []rawNode, keyed bool, err error) {
+	var doc map[string]json.RawMessage
+	if err = json.Unmarshal(content, &doc); err != nil {
+		return nil, false, errors.Wrap(err, "invalid introspection data")
+	}
+	if _, single := doc["inventory"]; single {
+		return []rawNode{{Data: content}}, false, nil
+	}
+
+	for id, data := range doc {
+		nodes = append(nodes, rawNode{ID: id, Data: data})
+	}
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i].ID < nodes[j].ID })
+	return nodes, true, nil
+}
+
+// getDetails converts the introspection data of a node.
+func getDetails(node rawNode) (*metal3v1alpha1.HardwareDetails, error) {
+	data := new(hardwaredetails.Data)
+	if err := json.Unmarshal(node.Data, data); err != nil {
+		return nil, errors.Wrapf(err, "invalid introspection data of node %s", node.ID)
+	}
+	return hardwaredetails.GetHardwareDetails(data), nil
+}
+
+// writeOutput prints the nodes in the given format. keyed output puts
+// the nodes in an object by UUID, or separates them with their UUID.
+func writeOutput(out io.Writer, format string, nodes []rawNode, keyed bool) error {
+	if format == outputRaw {
+		return writeRaw(out, nodes, keyed)
+	}
+
+	details := make([]*metal3v1alpha1.HardwareDetails, len(nodes))
+	for i, node := range nodes {
+		var err error
+		if details[i], err = getDetails(node); err != nil {
+			return err
+		}
+	}
+
+	switch format {
+	case outputAnnotation:
+		return writeAnnotations(out, nodes, details, keyed)
+	case outputTable:
+		return writeTable(out, nodes, details)
+	}
+
+	var value interface{}
+	if keyed {
+		byID := map[string]*metal3v1alpha1.HardwareDetails{}
+		for i, node := range nodes {
+			byID[node.ID] = details[i]
+		}
+		value = byID
+	} else if len(details) > 0 {
+		value = details[0]
+	}
+
+	var content []byte
+	var err error
+	if format == outputYAML {
+		content, err = yaml.Marshal(value)
+	} else {
+		content, err = json.MarshalIndent(value, "", "\t")
+		content = append(content, '\n')
+	}
+	if err != nil {
+		return err
+	}
+	_, err = out.Write(content)
+	return err
+}
+
+// writeRaw prints the introspection data unchanged, so that it can be
+// read back with -file.
+func writeRaw(out io.Writer, nodes []rawNode, keyed bool) error {
+	var value interface{}
+	if keyed {
+		byID := map[string]json.RawMessage{}
+		for _, node := range nodes {
+			byID[node.ID] = node.Data
+		}
+		value = byID
+	} else if len(nodes) > 0 {
+		value = nodes[0].Data
+	}
+
+	content, err := json.MarshalIndent(value, "", "\t")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(out, string(content))
+	return err
+}
+
+// annotationPatch returns a merge patch setting the hardware details of
+// a host, and disabling its inspection so they are used.
+func annotationPatch(details *metal3v1alpha1.HardwareDetails) ([]byte, error) {
+	value, err := json.Marshal(details)
+	if err != nil {
+		return nil, err
+	}
+	patch := map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"annotations": map[string]string{
+				inspectAnnotation:         "disabled",
+				hardwareDetailsAnnotation: string(value),
+			},
+		},
+	}
+	return yaml.Marshal(patch)
+}
+
+func writeAnnotations(out io.Writer, nodes []rawNode, details []*metal3v1alpha1.HardwareDetails, keyed bool) error {
+	for i, node := range nodes {
+		patch, err := annotationPatch(details[i])
+		if err != nil {
+			return err
+		}
+		if keyed {
+			if i > 0 {
+				fmt.Fprintln(out, "---")
+			}
+			fmt.Fprintf(out, "# node %s\n", node.ID)
+		}
+		if _, err = out.Write(patch); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeTable(out io.Writer, nodes []rawNode, details []*metal3v1alpha1.HardwareDetails) error {
+	w := tabwriter.NewWriter(out, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(w, "NODE\tHOSTNAME\tVENDOR\tPRODUCT\tCPU\tRAM (GiB)\tDISKS\tNICS")
+	for i, node := range nodes {
+		d := details[i]
+		id := node.ID
+		if id == "" {
+			id = "-"
+		}
+		var storageBytes metal3v1alpha1.Capacity
+		for _, disk := range d.Storage {
+			storageBytes += disk.SizeBytes
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%d x %s\t%d\t%d (%d GiB)\t%d\n",
+			id, d.Hostname, d.SystemVendor.Manufacturer, d.SystemVendor.ProductName,
+			d.CPU.Count, d.CPU.Arch, d.RAMMebibytes/1024,
+			len(d.Storage), storageBytes/metal3v1alpha1.GibiByte, len(d.NIC))
+	}
+	return w.Flush()
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"sigs.k8s.io/yaml"
+
+	metal3v1alpha1 "github.com/shweta50/baremetal-operator/apis/metal3.io/v1alpha1"
+)
+
+const sampleData = `{
+	"inventory": {
+		"hostname": "worker-0",
+		"system_vendor": {"manufacturer": "QEMU", "product_name": "Standard PC"},
+		"cpu": {"architecture": "x86_64", "count": 4},
+		"disks": [{"name": "/dev/sda", "size": 53687091200}],
+		"interfaces": [{"name": "eth0", "mac_address": "00:b7:8b:bb:3d:f6"}]
+	},
+	"memory_mb": 4096
+}`
+
+func TestParseSavedData(t *testing.T) {
+	nodes, keyed, err := parseSavedData([]byte(sampleData))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if keyed || len(nodes) != 1 || nodes[0].ID != "" {
+		t.Errorf("expected a single unnamed node, got %v (keyed %v)", nodes, keyed)
+	}
+
+	saved := `{"uuid-2": ` + sampleData + `, "uuid-1": ` + sampleData + `}`
+	nodes, keyed, err = parseSavedData([]byte(saved))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !keyed || len(nodes) != 2 || nodes[0].ID != "uuid-1" || nodes[1].ID != "uuid-2" {
+		t.Errorf("expected two nodes sorted by UUID, got %v (keyed %v)", nodes, keyed)
+	}
+
+	if _, _, err = parseSavedData([]byte("not json")); err == nil {
+		t.Error("expected an error for invalid data")
+	}
+}
+
+func TestWriteOutputAnnotation(t *testing.T) {
+	nodes, _, _ := parseSavedData([]byte(sampleData))
+	expected, err := getDetails(nodes[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out := new(bytes.Buffer)
+	if err := writeOutput(out, outputAnnotation, nodes, false); err != nil {
+		t.Fatal(err)
+	}
+
+	var patch struct {
+		Metadata struct {
+			Annotations map[string]string `json:"annotations"`
+		} `json:"metadata"`
+	}
+	if err := yaml.Unmarshal(out.Bytes(), &patch); err != nil {
+		t.Fatal(err)
+	}
+	if patch.Metadata.Annotations[inspectAnnotation] != "disabled" {
+		t.Errorf("expected inspection to be disabled, got %v", patch.Metadata.Annotations)
+	}
+	details := new(metal3v1alpha1.HardwareDetails)
+	if err := json.Unmarshal([]byte(patch.Metadata.Annotations[hardwareDetailsAnnotation]), details); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(details, expected) {
+		t.Errorf("expected %+v, got %+v", expected, details)
+	}
+}
+
+func TestWriteOutputTable(t *testing.T) {
+	nodes, _, _ := parseSavedData([]byte(`{"uuid-1": ` + sampleData + `}`))
+
+	out := new(bytes.Buffer)
+	if err := writeOutput(out, outputTable, nodes, true); err != nil {
+		t.Fatal(err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected a header and one row, got %q", out.String())
+	}
+	for _, field := range []string{"uuid-1", "worker-0", "QEMU", "4 x x86_64", "1 (50 GiB)"} {
+		if !strings.Contains(lines[1], field) {
+			t.Errorf("expected %q in row %q", field, lines[1])
+		}
+	}
+}
+
+func TestWriteOutputRawRoundTrip(t *testing.T) {
+	nodes, _, _ := parseSavedData([]byte(`{"uuid-1": ` + sampleData + `}`))
+
+	out := new(bytes.Buffer)
+	if err := writeOutput(out, outputRaw, nodes, true); err != nil {
+		t.Fatal(err)
+	}
+
+	replayed, keyed, err := parseSavedData(out.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !keyed || len(replayed) != 1 || replayed[0].ID != "uuid-1" {
+		t.Errorf("expected the saved node back, got %v", replayed)
+	}
+}
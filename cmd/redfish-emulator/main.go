@@ -0,0 +1,82 @@
+// redfish-emulator serves an emulated Redfish BMC for one or more
+// systems, for testing the operator without hardware or sushy-tools.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+
+	"sigs.k8s.io/yaml"
+
+	"github.com/shweta50/baremetal-operator/pkg/redfishemulator"
+)
+
+// config is the content of the file given with -config.
+type config struct {
+	Systems []redfishemulator.System `json:"systems"`
+}
+
+func loadSystems(path string, count int) ([]redfishemulator.System, error) {
+	if path == "" {
+		systems := []redfishemulator.System{}
+		for i := 1; i <= count; i++ {
+			system := redfishemulator.DefaultSystem(fmt.Sprintf("%d", i))
+			system.NICs[0].MACAddress = fmt.Sprintf("52:54:00:00:00:%02x", i)
+			systems = append(systems, system)
+		}
+		return systems, nil
+	}
+
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	c := config{}
+	if err = yaml.UnmarshalStrict(content, &c); err != nil {
+		return nil, fmt.Errorf("could not parse %s: %s", path, err)
+	}
+	for i, system := range c.Systems {
+		if system.ID == "" {
+			return nil, fmt.Errorf("system %d in %s has no id", i, path)
+		}
+	}
+	return c.Systems, nil
+}
+
+func main() {
+	listen := flag.String("listen", "127.0.0.1:8000", "the address to listen on")
+	username := flag.String("username", "admin", "the BMC username")
+	password := flag.String("password", "password", "the BMC password")
+	configFile := flag.String("config", "", "a YAML file describing the systems to emulate")
+	count := flag.Int("systems", 1, "the number of default systems to emulate when no config is given")
+	tlsCert := flag.String("tls-cert", "", "the TLS certificate file, serve plain HTTP when empty")
+	tlsKey := flag.String("tls-key", "", "the TLS private key file")
+	flag.Parse()
+
+	systems, err := loadSystems(*configFile, *count)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %s\n", err)
+		os.Exit(1)
+	}
+
+	emulator := redfishemulator.New(*username, *password)
+	scheme := "redfish+http"
+	if *tlsCert != "" {
+		scheme = "redfish+https"
+	}
+	for _, system := range systems {
+		emulator.AddSystem(system)
+		fmt.Printf("%s: %s://%s/redfish/v1/Systems/%s\n", system.ID, scheme, *listen, system.ID)
+	}
+
+	if *tlsCert != "" {
+		err = http.ListenAndServeTLS(*listen, *tlsCert, *tlsKey, emulator)
+	} else {
+		err = http.ListenAndServe(*listen, emulator)
+	}
+	fmt.Fprintf(os.Stderr, "Error: %s\n", err)
+	os.Exit(1)
+}
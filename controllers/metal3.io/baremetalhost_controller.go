@@ -44,6 +44,7 @@ import (
 	"github.com/shweta50/baremetal-operator/pkg/bmc"
 	"github.com/shweta50/baremetal-operator/pkg/hardware"
 	"github.com/shweta50/baremetal-operator/pkg/provisioner"
+	"github.com/shweta50/baremetal-operator/pkg/provisioner/ironic/devicehints"
 	"github.com/shweta50/baremetal-operator/pkg/utils"
 )
 
@@ -65,6 +66,18 @@ type BareMetalHostReconciler struct {
 	Log                logr.Logger
 	ProvisionerFactory provisioner.Factory
 	APIReader          client.Reader
+
+	// PasswordRotatorFactory creates the client used to rotate BMC
+	// passwords. Defaults to bmc.NewPasswordRotator when nil.
+	PasswordRotatorFactory bmc.PasswordRotatorFactory
+
+	// CredentialsProvider looks up BMC credentials. Defaults to
+	// reading Secrets through Client and APIReader when nil.
+	CredentialsProvider bmc.CredentialsProvider
+
+	// NetworkSwitcher moves hosts between the provisioning and tenant
+	// networks. Hosts are left on a flat network when nil.
+	NetworkSwitcher provisioner.NetworkSwitcher
 }
 
 // Instead of passing a zillion arguments to the action of a phase,
@@ -222,6 +235,30 @@ func (r *BareMetalHostReconciler) Reconcile(ctx context.Context, request ctrl.Re
 	}
 
 	prov, err := r.ProvisionerFactory.NewProvisioner(provisioner.BuildHostData(*host, *bmcCreds), info.publishEvent)
+	if configErr, ok := err.(provisioner.HostConfigError); ok {
+		if !host.DeletionTimestamp.IsZero() {
+			// No provisioner can reach the host, so there is nothing
+			// to deprovision before letting it be deleted.
+			host.Finalizers = utils.FilterStringFromList(
+				host.Finalizers, metal3v1alpha1.BareMetalHostFinalizer)
+			reqLogger.Info("removed finalizer without deprovisioning",
+				"reason", configErr.Error(), "remaining", host.Finalizers)
+			if err := r.Update(context.Background(), host); err != nil {
+				return ctrl.Result{}, errors.Wrap(err, "failed to remove finalizer")
+			}
+			return ctrl.Result{}, nil
+		}
+
+		// Changing the host settings triggers a new reconcile, so
+		// there is no point in retrying before that.
+		if host.Status.ErrorMessage != configErr.Error() {
+			if saveErr := r.setErrorCondition(request, host, metal3v1alpha1.RegistrationError, configErr.Error()); saveErr != nil {
+				return ctrl.Result{Requeue: true}, saveErr
+			}
+			r.publishEvent(request, host.NewEvent("ProvisionerConfigError", configErr.Error()))
+		}
+		return ctrl.Result{}, nil
+	}
 	if err != nil {
 		return ctrl.Result{}, errors.Wrap(err, "failed to create provisioner")
 	}
@@ -244,11 +281,16 @@ func (r *BareMetalHostReconciler) Reconcile(ctx context.Context, request ctrl.Re
 		return
 	}
 
+	dirty := actResult.Dirty()
+	if _, deleted := actResult.(deleteComplete); !deleted && recordHostState(info, prov.LastHostState()) {
+		dirty = true
+	}
+
 	// Only save status when we're told to, otherwise we
 	// introduce an infinite loop reconciling the same object over and
 	// over when there is an unrecoverable error (tracked through the
 	// error state of the host).
-	if actResult.Dirty() {
+	if dirty {
 
 		// Save Host
 		info.log.Info("saving host status",
@@ -274,6 +316,49 @@ func (r *BareMetalHostReconciler) Reconcile(ctx context.Context, request ctrl.Re
 	return
 }
 
+// recordHostState adds the state of the host in the provisioner to
+// the history in the status, if it changed since the last record, and
+// publishes an event for it. It returns whether the status changed.
+func recordHostState(info *reconcileInfo, state *provisioner.HostState) bool {
+	if state == nil {
+		return false
+	}
+	history := info.host.Status.ProvisionerHistory
+	if len(history) > 0 {
+		last := history[0]
+		if last.State == state.State && last.Target == state.Target &&
+			last.Step == state.Step && last.LastError == state.LastError {
+			return false
+		}
+	}
+
+	record := metal3v1alpha1.ProvisionerStateRecord{
+		State:     state.State,
+		Target:    state.Target,
+		Step:      state.Step,
+		LastError: state.LastError,
+		Time:      metav1.Now(),
+	}
+	history = append([]metal3v1alpha1.ProvisionerStateRecord{record}, history...)
+	if len(history) > metal3v1alpha1.MaxProvisionerHistory {
+		history = history[:metal3v1alpha1.MaxProvisionerHistory]
+	}
+	info.host.Status.ProvisionerHistory = history
+
+	message := fmt.Sprintf("Provisioner state %q", state.State)
+	if state.Target != "" {
+		message += fmt.Sprintf(", target %q", state.Target)
+	}
+	if state.Step != "" {
+		message += fmt.Sprintf(", step %s", state.Step)
+	}
+	if state.LastError != "" {
+		message += fmt.Sprintf(", last error: %s", state.LastError)
+	}
+	info.publishEvent("ProvisionerStateChanged", message)
+	return true
+}
+
 // Consume inspect.metal3.io/hardwaredetails when either
 // inspect.metal3.io=disabled or there are no existing HardwareDetails
 func (r *BareMetalHostReconciler) updateHardwareDetails(request ctrl.Request, host *metal3v1alpha1.BareMetalHost) (bool, error) {
@@ -347,11 +432,12 @@ func recordActionFailure(info *reconcileInfo, errorType metal3v1alpha1.ErrorType
 func recordActionDelayed(info *reconcileInfo, state metal3v1alpha1.ProvisioningState) actionResult {
 	var counter prometheus.Counter
 
-	switch state {
-	case metal3v1alpha1.StateDeprovisioning, metal3v1alpha1.StateDeleting:
-		counter = delayedDeprovisioningHostCounters.With(hostMetricLabels(info.request))
+	operation := operationForState(state)
+	switch operation {
+	case provisioner.OperationCleaning, provisioner.OperationDeleting:
+		counter = delayedDeprovisioningHostCounters.With(delayedHostMetricLabels(info.request, operation))
 	default:
-		counter = delayedProvisioningHostCounters.With(hostMetricLabels(info.request))
+		counter = delayedProvisioningHostCounters.With(delayedHostMetricLabels(info.request, operation))
 	}
 
 	info.postSaveCallbacks = append(info.postSaveCallbacks, counter.Inc)
@@ -689,6 +775,7 @@ func (r *BareMetalHostReconciler) actionInspecting(prov provisioner.Provisioner,
 	provResult, started, details, err := prov.InspectHardware(
 		provisioner.InspectData{
 			BootMode: info.host.Status.Provisioning.BootMode,
+			Mode:     info.host.Spec.InspectionMode,
 		},
 		info.host.Status.ErrorType == metal3v1alpha1.InspectionError,
 		refresh)
@@ -718,6 +805,7 @@ func (r *BareMetalHostReconciler) actionInspecting(prov provisioner.Provisioner,
 	}
 
 	clearError(info.host)
+	recordHardwareDrift(info, details)
 	info.host.Status.HardwareDetails = details
 	return actionComplete{}
 }
@@ -778,8 +866,12 @@ func (r *BareMetalHostReconciler) actionPreparing(prov provisioner.Provisioner,
 		RAIDConfig:      newStatus.Provisioning.RAID.DeepCopy(),
 		RootDeviceHints: newStatus.Provisioning.RootDeviceHints.DeepCopy(),
 		FirmwareConfig:  newStatus.Provisioning.Firmware.DeepCopy(),
+		Networking:      newStatus.Provisioning.Networking.DeepCopy(),
 	}
-	
+	if err := checkNetworkingNICs(info.host, prepareData.Networking); err != nil {
+		return recordActionFailure(info, metal3v1alpha1.PreparationError, err.Error())
+	}
+
 	provResult, started, err := prov.Prepare(prepareData,
 		dirty || info.host.Status.ErrorType == metal3v1alpha1.PreparationError)
 	if err != nil {
@@ -837,14 +929,38 @@ func (r *BareMetalHostReconciler) actionProvisioning(prov provisioner.Provisione
 		return actionContinue{}
 	}
 
+	rootDevice, err := selectRootDevice(info.host)
+	if err != nil {
+		return recordActionFailure(info, metal3v1alpha1.ProvisioningError, err.Error())
+	}
+	if !reflect.DeepEqual(rootDevice, info.host.Status.Provisioning.RootDevice) {
+		info.log.Info("updating root device in status")
+		info.host.Status.Provisioning.RootDevice = rootDevice
+		return actionUpdate{}
+	}
+
+	if err := checkStorageHealth(info.host); err != nil {
+		return recordActionFailure(info, metal3v1alpha1.ProvisioningError, err.Error())
+	}
+
 	var image metal3v1alpha1.Image
 	if info.host.Spec.Image != nil {
 		image = *info.host.Spec.Image.DeepCopy()
 	}
 
+	// Once the image is written, the host stays on its tenant networks
+	if info.host.Status.Provisioning.NetworkPhase != string(provisioner.NetworkPhaseProvisioned) {
+		if result := r.switchNetwork(info, provisioner.NetworkPhaseProvisioning, metal3v1alpha1.ProvisioningError); result != nil {
+			return result
+		}
+	}
+
 	provResult, err := prov.Provision(provisioner.ProvisionData{
 		Image:           image,
 		CustomDeploy:    info.host.Spec.CustomDeploy.DeepCopy(),
+		DeploySteps:     info.host.Spec.DeploySteps,
+		Traits:          info.host.Spec.Traits,
+		PreviousTraits:  info.host.Status.Provisioning.Traits,
 		HostConfig:      hostConf,
 		BootMode:        info.host.Status.Provisioning.BootMode,
 		HardwareProfile: hwProf,
@@ -881,6 +997,29 @@ func (r *BareMetalHostReconciler) actionProvisioning(prov provisioner.Provisione
 		info.host.Status.Provisioning.CustomDeploy = info.host.Spec.CustomDeploy.DeepCopy()
 	}
 
+	if (len(info.host.Spec.DeploySteps) != 0 || len(info.host.Status.Provisioning.DeploySteps) != 0) &&
+		!reflect.DeepEqual(info.host.Spec.DeploySteps, info.host.Status.Provisioning.DeploySteps) {
+		info.log.Info("updating deploy steps in status")
+		info.host.Status.Provisioning.DeploySteps = nil
+		for _, step := range info.host.Spec.DeploySteps {
+			info.host.Status.Provisioning.DeploySteps = append(info.host.Status.Provisioning.DeploySteps, *step.DeepCopy())
+		}
+	}
+
+	if (len(info.host.Spec.Traits) != 0 || len(info.host.Status.Provisioning.Traits) != 0) &&
+		!reflect.DeepEqual(info.host.Spec.Traits, info.host.Status.Provisioning.Traits) {
+		info.log.Info("updating traits in status")
+		info.host.Status.Provisioning.Traits = append([]metal3v1alpha1.Trait(nil), info.host.Spec.Traits...)
+	}
+
+	// The network phase is saved with the rest of the status when
+	// provisioning completes, so a finished switch needs no extra pass.
+	if result := r.switchNetwork(info, provisioner.NetworkPhaseProvisioned, metal3v1alpha1.ProvisioningError); result != nil {
+		if _, switched := result.(actionUpdate); !switched {
+			return result
+		}
+	}
+
 	// After provisioning we always requeue to ensure we enter the
 	// "provisioned" state and start monitoring power status.
 	return actionComplete{}
@@ -891,9 +1030,11 @@ func (r *BareMetalHostReconciler) actionProvisioning(prov provisioner.Provisione
 // fields of a host.
 func clearHostProvisioningSettings(host *metal3v1alpha1.BareMetalHost) {
 	host.Status.Provisioning.RootDeviceHints = nil
+	host.Status.Provisioning.RootDevice = nil
 	host.Status.Provisioning.RAID = nil
 	
 	host.Status.Provisioning.Firmware = nil
+	host.Status.Provisioning.Networking = nil
 }
 
 func (r *BareMetalHostReconciler) actionDeprovisioning(prov provisioner.Provisioner, info *reconcileInfo) actionResult {
@@ -920,6 +1061,10 @@ func (r *BareMetalHostReconciler) actionDeprovisioning(prov provisioner.Provisio
 
 	info.log.Info("deprovisioning")
 
+	if result := r.switchNetwork(info, provisioner.NetworkPhaseDeprovisioning, metal3v1alpha1.ProvisioningError); result != nil {
+		return result
+	}
+
 	provResult, err := prov.Deprovision(info.host.Status.ErrorType == metal3v1alpha1.ProvisioningError)
 	if err != nil {
 		return actionError{errors.Wrap(err, "failed to deprovision")}
@@ -948,6 +1093,7 @@ func (r *BareMetalHostReconciler) actionDeprovisioning(prov provisioner.Provisio
 	// so we transition to the next state.
 	info.host.Status.Provisioning.Image = metal3v1alpha1.Image{}
 	info.host.Status.Provisioning.CustomDeploy = nil
+	info.host.Status.Provisioning.DeploySteps = nil
 	clearHostProvisioningSettings(info.host)
 
 	return actionComplete{}
@@ -1069,6 +1215,10 @@ func (r *BareMetalHostReconciler) actionManageSteadyState(prov provisioner.Provi
 		return result
 	}
 
+	if rotateResult := r.manageBMCPasswordRotation(info); rotateResult != nil {
+		return rotateResult
+	}
+
 	return r.manageHostPower(prov, info)
 }
 
@@ -1081,6 +1231,9 @@ func (r *BareMetalHostReconciler) actionManageReady(prov provisioner.Provisioner
 		clearError(info.host)
 		return actionComplete{}
 	}
+	if rotateResult := r.manageBMCPasswordRotation(info); rotateResult != nil {
+		return rotateResult
+	}
 	return r.manageHostPower(prov, info)
 }
 
@@ -1111,8 +1264,8 @@ func saveHostProvisioningSettings(host *metal3v1alpha1.BareMetalHost) (dirty boo
 		}
 		hintSource = &hwProf.RootDeviceHints
 	}
-	if (hintSource != nil && host.Status.Provisioning.RootDeviceHints == nil) || *hintSource != *(host.Status.Provisioning.RootDeviceHints) {
-		host.Status.Provisioning.RootDeviceHints = hintSource
+	if !reflect.DeepEqual(hintSource, host.Status.Provisioning.RootDeviceHints) {
+		host.Status.Provisioning.RootDeviceHints = hintSource.DeepCopy()
 		dirty = true
 	}
 
@@ -1163,9 +1316,83 @@ func saveHostProvisioningSettings(host *metal3v1alpha1.BareMetalHost) (dirty boo
 		dirty = true
 	}
 
+	// Copy NICs and bonds
+	if !reflect.DeepEqual(host.Status.Provisioning.Networking, host.Spec.Networking) {
+		host.Status.Provisioning.Networking = host.Spec.Networking.DeepCopy()
+		dirty = true
+	}
+
 	return
 }
 
+// checkNetworkingNICs returns an error if a NIC listed in the
+// networking settings was not found by the inspection of the host.
+// Nothing is checked for hosts that were not inspected.
+func checkNetworkingNICs(host *metal3v1alpha1.BareMetalHost, networking *metal3v1alpha1.NetworkingConfig) error {
+	if networking == nil || host.Status.HardwareDetails == nil {
+		return nil
+	}
+
+	found := map[string]bool{}
+	for _, nic := range host.Status.HardwareDetails.NIC {
+		found[strings.ToLower(nic.MAC)] = true
+	}
+
+	macs := []string{}
+	for _, nic := range networking.NICs {
+		macs = append(macs, nic.MACAddress)
+	}
+	for _, bond := range networking.Bonds {
+		macs = append(macs, bond.Members...)
+	}
+	for _, mac := range macs {
+		if !found[strings.ToLower(mac)] {
+			return fmt.Errorf("NIC %s was not found on the host", mac)
+		}
+	}
+	return nil
+}
+
+// checkStorageHealth returns an error if the inspection of the host
+// found that the disk selected as root device reports it is failing.
+// Failing data disks do not prevent provisioning.
+func checkStorageHealth(host *metal3v1alpha1.BareMetalHost) error {
+	rootDevice := host.Status.Provisioning.RootDevice
+	if rootDevice == nil {
+		return nil
+	}
+	for _, name := range host.Status.HardwareDetails.FailingStorage() {
+		if name == rootDevice.Name {
+			return fmt.Errorf("root device %s reports it is failing", name)
+		}
+	}
+	return nil
+}
+
+// selectRootDevice returns the disk found by the inspection of the host
+// that the root device hints select, or an error if they match no disk
+// or several. Nothing is selected for hosts that were not inspected or
+// whose disks are not known before deploying: with RAID, the root
+// device is a volume created during cleaning. Disks not named after
+// their device, as the hardware details set by hand may be, can not be
+// matched the way Ironic does either.
+func selectRootDevice(host *metal3v1alpha1.BareMetalHost) (*metal3v1alpha1.Storage, error) {
+	details := host.Status.HardwareDetails
+	if details == nil || len(details.Storage) == 0 || host.Spec.Image.IsLiveISO() {
+		return nil, nil
+	}
+	for _, disk := range details.Storage {
+		if !strings.HasPrefix(disk.Name, "/dev/") {
+			return nil, nil
+		}
+	}
+	if raid := host.Status.Provisioning.RAID; raid != nil &&
+		(len(raid.HardwareRAIDVolumes) != 0 || len(raid.SoftwareRAIDVolumes) != 0) {
+		return nil, nil
+	}
+	return devicehints.SelectRootDevice(host.Status.Provisioning.RootDeviceHints, details.Storage)
+}
+
 func (r *BareMetalHostReconciler) saveHostStatus(host *metal3v1alpha1.BareMetalHost) error {
 	t := metav1.Now()
 	host.Status.LastUpdated = &t
@@ -1227,14 +1454,62 @@ func (r *BareMetalHostReconciler) setErrorCondition(request ctrl.Request, host *
 	return
 }
 
-// Retrieve the secret containing the credentials for talking to the BMC.
-func (r *BareMetalHostReconciler) getBMCSecretAndSetOwner(request ctrl.Request, host *metal3v1alpha1.BareMetalHost) (bmcCredsSecret *corev1.Secret, err error) {
+func (r *BareMetalHostReconciler) networkSwitcher() provisioner.NetworkSwitcher {
+	if r.NetworkSwitcher != nil {
+		return r.NetworkSwitcher
+	}
+	return provisioner.NullNetworkSwitcher{}
+}
 
-	if host.Spec.BMC.CredentialsName == "" {
-		return nil, &EmptyBMCSecretError{message: "The BMC secret reference is empty"}
+// switchNetwork moves the host to the networks needed for the phase,
+// unless that was the last move done. It returns nil once the host is
+// there, and actionUpdate when it just got there.
+func (r *BareMetalHostReconciler) switchNetwork(info *reconcileInfo, phase provisioner.NetworkPhase, errorType metal3v1alpha1.ErrorType) actionResult {
+	switcher := r.networkSwitcher()
+	if _, flat := switcher.(provisioner.NullNetworkSwitcher); flat {
+		// Hosts on a flat network never move, so there is nothing to
+		// record in the status.
+		return nil
+	}
+	if info.host.Status.Provisioning.NetworkPhase == string(phase) {
+		return nil
+	}
+
+	provResult, err := switcher.SwitchNetwork(provisioner.BuildNetworkSwitchData(*info.host, phase))
+	if err != nil {
+		return actionError{errors.Wrap(err, "failed to switch the host networks")}
+	}
+	if provResult.ErrorMessage != "" {
+		return recordActionFailure(info, errorType, provResult.ErrorMessage)
 	}
+	if provResult.Dirty {
+		return actionContinue{provResult.RequeueAfter}
+	}
+
+	info.log.Info("host networks switched", "phase", phase)
+	info.publishEvent("NetworkSwitched", fmt.Sprintf("Host moved to the %s networks", phase))
+	info.host.Status.Provisioning.NetworkPhase = string(phase)
+	return actionUpdate{}
+}
+
+func (r *BareMetalHostReconciler) credentialsProvider() bmc.CredentialsProvider {
+	if r.CredentialsProvider != nil {
+		return r.CredentialsProvider
+	}
+	return &bmc.SecretCredentialsProvider{Client: r.Client, APIReader: r.APIReader}
+}
 
-	bmcCredsSecret, err = getSecret(r.Client, r.APIReader, host.CredentialsKey())
+// usesSecretCredentials returns true when BMC credentials are stored
+// in Kubernetes Secrets the operator can take ownership of and update.
+func (r *BareMetalHostReconciler) usesSecretCredentials() bool {
+	_, ok := r.credentialsProvider().(*bmc.SecretCredentialsProvider)
+	return ok
+}
+
+// Retrieve the secret containing the credentials for talking to the BMC.
+func (r *BareMetalHostReconciler) getBMCSecretAndSetOwner(request ctrl.Request, host *metal3v1alpha1.BareMetalHost, provider *bmc.SecretCredentialsProvider) (bmcCredsSecret *corev1.Secret, err error) {
+
+	bmcCredsSecret, err = provider.GetSecret(host.CredentialsKey())
 	if err != nil {
 		return nil, &ResolveBMCSecretRefError{message: fmt.Sprintf("The BMC secret %s does not exist", host.CredentialsKey())}
 	}
@@ -1251,18 +1526,28 @@ func (r *BareMetalHostReconciler) getBMCSecretAndSetOwner(request ctrl.Request,
 	return bmcCredsSecret, nil
 }
 
-func credentialsFromSecret(bmcCredsSecret *corev1.Secret) *bmc.Credentials {
-	// We trim surrounding whitespace because those characters are
-	// unlikely to be part of the username or password and it is
-	// common for users to encode the values with a command like
-	//
-	//     echo "my-password" | base64
-	//
-	// which introduces a trailing newline.
-	return &bmc.Credentials{
-		Username: strings.TrimSpace(string(bmcCredsSecret.Data["username"])),
-		Password: strings.TrimSpace(string(bmcCredsSecret.Data["password"])),
+// Retrieve the credentials for talking to the BMC from an external
+// provider. The returned Secret only carries the reference and
+// version of the credentials, so that changes to them are tracked in
+// the host status the same way as changes to a real Secret.
+func (r *BareMetalHostReconciler) getExternalBMCCredentials(host *metal3v1alpha1.BareMetalHost, provider bmc.CredentialsProvider) (bmcCreds *bmc.Credentials, bmcCredsSecret *corev1.Secret, err error) {
+	key := host.CredentialsKey()
+	bmcCreds, version, err := provider.GetCredentials(key)
+	if err != nil {
+		if _, notFound := err.(*bmc.CredentialsNotFoundError); notFound {
+			return nil, nil, &ResolveBMCSecretRefError{message: err.Error()}
+		}
+		return nil, nil, err
+	}
+
+	bmcCredsSecret = &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            key.Name,
+			Namespace:       key.Namespace,
+			ResourceVersion: version,
+		},
 	}
+	return bmcCreds, bmcCredsSecret, nil
 }
 
 // Make sure the credentials for the management controller look
@@ -1270,10 +1555,23 @@ func credentialsFromSecret(bmcCredsSecret *corev1.Secret) *bmc.Credentials {
 // to use the credentials.
 func (r *BareMetalHostReconciler) buildAndValidateBMCCredentials(request ctrl.Request, host *metal3v1alpha1.BareMetalHost) (bmcCreds *bmc.Credentials, bmcCredsSecret *corev1.Secret, err error) {
 
-	// Retrieve the BMC secret from Kubernetes for this host
-	bmcCredsSecret, err = r.getBMCSecretAndSetOwner(request, host)
-	if err != nil {
-		return nil, nil, err
+	if host.Spec.BMC.CredentialsName == "" {
+		return nil, nil, &EmptyBMCSecretError{message: "The BMC secret reference is empty"}
+	}
+
+	// Retrieve the BMC credentials for this host
+	switch provider := r.credentialsProvider().(type) {
+	case *bmc.SecretCredentialsProvider:
+		bmcCredsSecret, err = r.getBMCSecretAndSetOwner(request, host, provider)
+		if err != nil {
+			return nil, nil, err
+		}
+		bmcCreds = bmc.CredentialsFromSecret(bmcCredsSecret)
+	default:
+		bmcCreds, bmcCredsSecret, err = r.getExternalBMCCredentials(host, provider)
+		if err != nil {
+			return nil, nil, err
+		}
 	}
 
 	// Check for a "discovered" host vs. one that we have all the info for
@@ -1282,8 +1580,6 @@ func (r *BareMetalHostReconciler) buildAndValidateBMCCredentials(request ctrl.Re
 		return nil, nil, &EmptyBMCAddressError{message: "Missing BMC connection detail 'Address'"}
 	}
 
-	bmcCreds = credentialsFromSecret(bmcCredsSecret)
-
 	// Verify that the secret contains the expected info.
 	err = bmcCreds.Validate()
 	if err != nil {
@@ -25,6 +25,7 @@ import (
 
 	metal3v1alpha1 "github.com/shweta50/baremetal-operator/apis/metal3.io/v1alpha1"
 	"github.com/shweta50/baremetal-operator/pkg/bmc"
+	"github.com/shweta50/baremetal-operator/pkg/provisioner"
 	"github.com/shweta50/baremetal-operator/pkg/provisioner/fixture"
 	"github.com/shweta50/baremetal-operator/pkg/utils"
 )
@@ -825,6 +826,50 @@ func TestUpdateGoodCredentialsOnBadSecret(t *testing.T) {
 	)
 }
 
+type staticCredentialsProvider struct {
+	creds   map[types.NamespacedName]bmc.Credentials
+	version string
+}
+
+func (p *staticCredentialsProvider) GetCredentials(ref types.NamespacedName) (*bmc.Credentials, string, error) {
+	creds, ok := p.creds[ref]
+	if !ok {
+		return nil, "", &bmc.CredentialsNotFoundError{Reference: ref, Provider: "static"}
+	}
+	return &creds, p.version, nil
+}
+
+// TestExternalCredentialsProvider ensures that credentials can come
+// from a provider other than Kubernetes Secrets, and that changes to
+// their version are tracked like changes to a Secret.
+func TestExternalCredentialsProvider(t *testing.T) {
+	host := newDefaultHost(t)
+	host.Spec.BMC.CredentialsName = "external-creds"
+	r := newTestReconciler(host)
+	provider := &staticCredentialsProvider{version: "1"}
+	r.CredentialsProvider = provider
+
+	waitForError(t, r, host)
+	assert.Contains(t, host.Status.ErrorMessage, "No BMC credentials")
+
+	provider.creds = map[types.NamespacedName]bmc.Credentials{
+		host.CredentialsKey(): {Username: "User", Password: "Pass"},
+	}
+	tryReconcile(t, r, host,
+		func(host *metal3v1alpha1.BareMetalHost, result reconcile.Result) bool {
+			return host.Status.GoodCredentials.Version == "1"
+		},
+	)
+	assert.Equal(t, "external-creds", host.Status.GoodCredentials.Reference.Name)
+
+	provider.version = "2"
+	tryReconcile(t, r, host,
+		func(host *metal3v1alpha1.BareMetalHost, result reconcile.Result) bool {
+			return host.Status.GoodCredentials.Version == "2"
+		},
+	)
+}
+
 // TestDiscoveredHost ensures that a host without a BMC IP and
 // credentials is placed into the "discovered" state.
 func TestDiscoveredHost(t *testing.T) {
@@ -1180,6 +1225,59 @@ func TestProvision(t *testing.T) {
 	)
 }
 
+// TestProvisionRootDevice ensures that the disk selected by the root
+// device hints is recorded, and that hosts whose hints match several
+// disks are not provisioned.
+func TestProvisionRootDevice(t *testing.T) {
+	details, err := json.Marshal(metal3v1alpha1.HardwareDetails{
+		Storage: []metal3v1alpha1.Storage{
+			{Name: "/dev/vda", SizeBytes: 100 * metal3v1alpha1.GibiByte, Model: "Dell CFJ61"},
+			{Name: "/dev/vdb", SizeBytes: 100 * metal3v1alpha1.GibiByte, Model: "Dell CFJ61"},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	newInspectedHost := func(name string, hints *metal3v1alpha1.RootDeviceHints) *metal3v1alpha1.BareMetalHost {
+		host := newDefaultNamedHost(name, t)
+		host.Annotations = map[string]string{
+			inspectAnnotationPrefix:   "disabled",
+			hardwareDetailsAnnotation: string(details),
+		}
+		host.Spec.Image = &metal3v1alpha1.Image{
+			URL:      "https://example.com/image-name",
+			Checksum: "12345",
+		}
+		host.Spec.Online = true
+		host.Spec.RootDeviceHints = hints
+		return host
+	}
+
+	host := newInspectedHost("selected", &metal3v1alpha1.RootDeviceHints{DeviceName: "/dev/vda", MinSizeGigabytes: 40})
+	r := newTestReconciler(host)
+
+	tryReconcile(t, r, host,
+		func(host *metal3v1alpha1.BareMetalHost, result reconcile.Result) bool {
+			return host.Status.Provisioning.State == metal3v1alpha1.StateProvisioned
+		},
+	)
+	if assert.NotNil(t, host.Status.Provisioning.RootDevice) {
+		assert.Equal(t, "/dev/vda", host.Status.Provisioning.RootDevice.Name)
+	}
+
+	host = newInspectedHost("ambiguous", &metal3v1alpha1.RootDeviceHints{Model: "CFJ61"})
+	r = newTestReconciler(host)
+
+	tryReconcile(t, r, host,
+		func(host *metal3v1alpha1.BareMetalHost, result reconcile.Result) bool {
+			return host.Status.ErrorType == metal3v1alpha1.ProvisioningError
+		},
+	)
+	assert.Equal(t, "root device hints model \"<in> CFJ61\" match several disks: /dev/vda, /dev/vdb",
+		host.Status.ErrorMessage)
+	assert.Nil(t, host.Status.Provisioning.RootDevice)
+}
+
 // TestProvisionCustomDeploy ensures that the Provisioning.CustomDeploy portion
 // of the status block is filled in for provisioned hosts.
 func TestProvisionCustomDeploy(t *testing.T) {
@@ -1226,6 +1324,30 @@ func TestProvisionCustomDeployWithURL(t *testing.T) {
 	)
 }
 
+// TestProvisionDeployStepsAndTraits ensures that the deploy steps and
+// traits a host was provisioned with are saved in its status.
+func TestProvisionDeployStepsAndTraits(t *testing.T) {
+	host := newDefaultHost(t)
+	host.Spec.DeploySteps = []metal3v1alpha1.DeployStep{
+		{Interface: metal3v1alpha1.DeployStepInterfaceRAID, Step: "apply_configuration"},
+	}
+	host.Spec.Traits = []metal3v1alpha1.Trait{"CUSTOM_HYPERTHREADING_OFF"}
+	host.Spec.Image = &metal3v1alpha1.Image{
+		URL:      "https://example.com/image-name",
+		Checksum: "12345",
+	}
+	host.Spec.Online = true
+	r := newTestReconciler(host)
+
+	tryReconcile(t, r, host,
+		func(host *metal3v1alpha1.BareMetalHost, result reconcile.Result) bool {
+			return host.Status.Provisioning.State == metal3v1alpha1.StateProvisioned
+		},
+	)
+	assert.Equal(t, host.Spec.DeploySteps, host.Status.Provisioning.DeploySteps)
+	assert.Equal(t, host.Spec.Traits, host.Status.Provisioning.Traits)
+}
+
 // TestExternallyProvisionedTransitions ensures that host enters the
 // expected states when it looks like it has been provisioned by
 // another tool.
@@ -1723,6 +1845,249 @@ func TestGetImageDeprovisioning(t *testing.T) {
 	assert.Exactly(t, host.Status.Provisioning.Image, *img)
 }
 
+func TestCheckNetworkingNICs(t *testing.T) {
+	networking := &metal3v1alpha1.NetworkingConfig{
+		NICs: []metal3v1alpha1.NICConfig{{MACAddress: "00:00:00:00:00:03"}},
+		Bonds: []metal3v1alpha1.BondConfig{{
+			Name:    "bond0",
+			Members: []string{"00:00:00:00:00:01", "00:00:00:00:00:02"},
+		}},
+	}
+	details := &metal3v1alpha1.HardwareDetails{
+		NIC: []metal3v1alpha1.NIC{
+			{MAC: "00:00:00:00:00:01"},
+			{MAC: "00:00:00:00:00:02"},
+		},
+	}
+
+	testCases := []struct {
+		Scenario      string
+		Networking    *metal3v1alpha1.NetworkingConfig
+		Details       *metal3v1alpha1.HardwareDetails
+		ExtraNIC      string
+		ExpectedError string
+	}{
+		{
+			Scenario: "no networking",
+			Details:  details,
+		},
+		{
+			Scenario:   "not inspected",
+			Networking: networking,
+		},
+		{
+			Scenario:      "missing NIC",
+			Networking:    networking,
+			Details:       details,
+			ExpectedError: "NIC 00:00:00:00:00:03 was not found on the host",
+		},
+		{
+			Scenario:   "all NICs found",
+			Networking: networking,
+			Details:    details,
+			ExtraNIC:   "00:00:00:00:00:03",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Scenario, func(t *testing.T) {
+			host := &metal3v1alpha1.BareMetalHost{}
+			host.Status.HardwareDetails = tc.Details.DeepCopy()
+			if tc.ExtraNIC != "" {
+				host.Status.HardwareDetails.NIC = append(host.Status.HardwareDetails.NIC,
+					metal3v1alpha1.NIC{MAC: tc.ExtraNIC})
+			}
+
+			err := checkNetworkingNICs(host, tc.Networking)
+			if tc.ExpectedError == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, tc.ExpectedError)
+			}
+		})
+	}
+}
+
+func TestCheckStorageHealth(t *testing.T) {
+	failing := &metal3v1alpha1.HardwareDetails{
+		Storage: []metal3v1alpha1.Storage{
+			{Name: "/dev/sda", Health: &metal3v1alpha1.StorageHealth{Status: metal3v1alpha1.StorageHealthFailing}},
+			{Name: "/dev/sdb", Health: &metal3v1alpha1.StorageHealth{Status: metal3v1alpha1.StorageHealthOK}},
+			{Name: "/dev/sdc", Health: &metal3v1alpha1.StorageHealth{Status: metal3v1alpha1.StorageHealthFailing}},
+		},
+	}
+
+	testCases := []struct {
+		Scenario      string
+		Details       *metal3v1alpha1.HardwareDetails
+		RootDevice    string
+		ExpectedError string
+	}{
+		{
+			Scenario:   "not inspected",
+			RootDevice: "/dev/sda",
+		},
+		{
+			Scenario: "healthy",
+			Details: &metal3v1alpha1.HardwareDetails{
+				Storage: []metal3v1alpha1.Storage{
+					{Name: "/dev/sda", Health: &metal3v1alpha1.StorageHealth{Status: metal3v1alpha1.StorageHealthOK}},
+					{Name: "/dev/sdb"},
+				},
+			},
+			RootDevice: "/dev/sda",
+		},
+		{
+			Scenario:   "failing data disks",
+			Details:    failing,
+			RootDevice: "/dev/sdb",
+		},
+		{
+			Scenario: "no root device selected",
+			Details:  failing,
+		},
+		{
+			// As loaded from the hardwaredetails annotation, without
+			// the storageFailing flag
+			Scenario:      "failing root device",
+			Details:       failing,
+			RootDevice:    "/dev/sdc",
+			ExpectedError: "root device /dev/sdc reports it is failing",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Scenario, func(t *testing.T) {
+			host := &metal3v1alpha1.BareMetalHost{}
+			host.Status.HardwareDetails = tc.Details
+			if tc.RootDevice != "" {
+				host.Status.Provisioning.RootDevice = &metal3v1alpha1.Storage{Name: tc.RootDevice}
+			}
+
+			err := checkStorageHealth(host)
+			if tc.ExpectedError == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, tc.ExpectedError)
+			}
+		})
+	}
+}
+
+func TestSelectRootDevice(t *testing.T) {
+	disks := []metal3v1alpha1.Storage{
+		{Name: "/dev/sda", SizeBytes: 500 * metal3v1alpha1.GibiByte},
+		{Name: "/dev/sdb", SizeBytes: 500 * metal3v1alpha1.GibiByte},
+	}
+	liveISO := "live-iso"
+
+	testCases := []struct {
+		Scenario      string
+		Details       *metal3v1alpha1.HardwareDetails
+		Hints         *metal3v1alpha1.RootDeviceHints
+		RAID          *metal3v1alpha1.RAIDConfig
+		Image         *metal3v1alpha1.Image
+		Expected      string
+		ExpectedError string
+	}{
+		{
+			Scenario: "not inspected",
+			Hints:    &metal3v1alpha1.RootDeviceHints{DeviceName: "/dev/sda"},
+		},
+		{
+			Scenario: "selected",
+			Details:  &metal3v1alpha1.HardwareDetails{Storage: disks},
+			Hints:    &metal3v1alpha1.RootDeviceHints{DeviceName: "/dev/sdb"},
+			Expected: "/dev/sdb",
+		},
+		{
+			Scenario:      "no match",
+			Details:       &metal3v1alpha1.HardwareDetails{Storage: disks},
+			Hints:         &metal3v1alpha1.RootDeviceHints{DeviceName: "/dev/vda"},
+			ExpectedError: "no disk matches the root device hints name \"s== /dev/vda\"",
+		},
+		{
+			Scenario:      "ambiguous",
+			Details:       &metal3v1alpha1.HardwareDetails{Storage: disks},
+			Hints:         &metal3v1alpha1.RootDeviceHints{MinSizeGigabytes: 100},
+			ExpectedError: "root device hints size \">= 100\" match several disks: /dev/sda, /dev/sdb",
+		},
+		{
+			Scenario: "hardware RAID",
+			Details:  &metal3v1alpha1.HardwareDetails{Storage: disks},
+			Hints:    &metal3v1alpha1.RootDeviceHints{HCTL: "0:2:0:0"},
+			RAID: &metal3v1alpha1.RAIDConfig{
+				HardwareRAIDVolumes: []metal3v1alpha1.HardwareRAIDVolume{{Level: "1"}},
+			},
+		},
+		{
+			Scenario: "disks not named after devices",
+			Details: &metal3v1alpha1.HardwareDetails{Storage: []metal3v1alpha1.Storage{
+				{Name: "disk-1 (boot)", SizeBytes: 500 * metal3v1alpha1.GibiByte},
+			}},
+			Hints: &metal3v1alpha1.RootDeviceHints{DeviceName: "/dev/vda"},
+		},
+		{
+			Scenario: "live ISO",
+			Details:  &metal3v1alpha1.HardwareDetails{Storage: disks},
+			Hints:    &metal3v1alpha1.RootDeviceHints{DeviceName: "/dev/vda"},
+			Image:    &metal3v1alpha1.Image{URL: "http://example.com/live.iso", DiskFormat: &liveISO},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Scenario, func(t *testing.T) {
+			host := &metal3v1alpha1.BareMetalHost{}
+			host.Spec.Image = tc.Image
+			host.Status.HardwareDetails = tc.Details
+			host.Status.Provisioning.RootDeviceHints = tc.Hints
+			host.Status.Provisioning.RAID = tc.RAID
+
+			device, err := selectRootDevice(host)
+			if tc.ExpectedError != "" {
+				assert.EqualError(t, err, tc.ExpectedError)
+				return
+			}
+			assert.NoError(t, err)
+			if tc.Expected == "" {
+				assert.Nil(t, device)
+			} else if assert.NotNil(t, device) {
+				assert.Equal(t, tc.Expected, device.Name)
+			}
+		})
+	}
+}
+
+func TestRecordHostState(t *testing.T) {
+	host := host(metal3v1alpha1.StateProvisioning).build()
+	info := makeDefaultReconcileInfo(host)
+
+	assert.False(t, recordHostState(info, nil))
+	assert.Empty(t, host.Status.ProvisionerHistory)
+
+	deploying := &provisioner.HostState{State: "deploying", Target: "active", Step: "deploy.write_image"}
+	assert.True(t, recordHostState(info, deploying))
+	assert.False(t, recordHostState(info, deploying))
+	if assert.Len(t, info.events, 1) {
+		assert.Equal(t, "ProvisionerStateChanged", info.events[0].Reason)
+		assert.Equal(t, `Provisioner state "deploying", target "active", step deploy.write_image`, info.events[0].Message)
+	}
+
+	failed := &provisioner.HostState{State: "deploy failed", Target: "active", LastError: "disk full"}
+	assert.True(t, recordHostState(info, failed))
+	if assert.Len(t, host.Status.ProvisionerHistory, 2) {
+		assert.Equal(t, "deploy failed", host.Status.ProvisionerHistory[0].State)
+		assert.Equal(t, "disk full", host.Status.ProvisionerHistory[0].LastError)
+		assert.Equal(t, "deploy.write_image", host.Status.ProvisionerHistory[1].Step)
+	}
+
+	for i := 0; i < metal3v1alpha1.MaxProvisionerHistory; i++ {
+		recordHostState(info, &provisioner.HostState{State: fmt.Sprintf("state %d", i)})
+	}
+	assert.Len(t, host.Status.ProvisionerHistory, metal3v1alpha1.MaxProvisionerHistory)
+	assert.Equal(t, "state 9", host.Status.ProvisionerHistory[0].State)
+}
+
 func TestGetImageExternallyPprovisioned(t *testing.T) {
 	host := metal3v1alpha1.BareMetalHost{
 		Spec: metal3v1alpha1.BareMetalHostSpec{
@@ -1935,6 +2300,53 @@ func TestInvalidBMHCanBeDeleted(t *testing.T) {
 	})
 }
 
+type hostConfigErrorFactory struct{}
+
+func (hostConfigErrorFactory) NewProvisioner(hostData provisioner.HostData, publish provisioner.EventPublisher) (provisioner.Provisioner, error) {
+	return nil, provisioner.HostConfigError{Err: fmt.Errorf("unknown Ironic backend site-c")}
+}
+
+// TestHostConfigError ensures that a provisioner refusing the settings
+// of a host is reported in the host status.
+func TestHostConfigError(t *testing.T) {
+	host := newDefaultHost(t)
+	r := newTestReconciler(host)
+	r.ProvisionerFactory = hostConfigErrorFactory{}
+
+	waitForError(t, r, host)
+	assert.Equal(t, metal3v1alpha1.OperationalStatusError, host.Status.OperationalStatus)
+	assert.Equal(t, metal3v1alpha1.RegistrationError, host.Status.ErrorType)
+	assert.Equal(t, "unknown Ironic backend site-c", host.Status.ErrorMessage)
+
+	result, err := r.Reconcile(context.Background(), newRequest(host))
+	assert.NoError(t, err)
+	assert.Equal(t, reconcile.Result{}, result)
+}
+
+// TestHostConfigErrorDelete ensures that a host the provisioner refuses
+// the settings of can still be deleted.
+func TestHostConfigErrorDelete(t *testing.T) {
+	now := metav1.Now()
+	host := newDefaultHost(t)
+	host.Labels = map[string]string{metal3v1alpha1.IronicBackendLabel: "site-c"}
+	host.Finalizers = append(host.Finalizers, metal3v1alpha1.BareMetalHostFinalizer)
+	host.Status.Provisioning.ID = "made-up-id"
+	host.DeletionTimestamp = &now
+	r := newTestReconciler(host)
+	r.ProvisionerFactory = hostConfigErrorFactory{}
+
+	_, err := r.Reconcile(context.Background(), newRequest(host))
+	assert.NoError(t, err)
+
+	saved := &metal3v1alpha1.BareMetalHost{}
+	err = r.Get(context.Background(), types.NamespacedName{Namespace: host.Namespace, Name: host.Name}, saved)
+	if err == nil {
+		assert.NotContains(t, saved.Finalizers, metal3v1alpha1.BareMetalHostFinalizer)
+	} else {
+		assert.True(t, errors.IsNotFound(err))
+	}
+}
+
 func TestCredentialsFromSecret(t *testing.T) {
 	cases := []struct {
 		name     string
@@ -1989,7 +2401,7 @@ func TestCredentialsFromSecret(t *testing.T) {
 
 	for _, c := range cases {
 		t.Run(c.name, func(t *testing.T) {
-			actual := credentialsFromSecret(&c.input)
+			actual := bmc.CredentialsFromSecret(&c.input)
 			assert.Equal(t, c.expected, *actual)
 		})
 	}
@@ -0,0 +1,161 @@
+package controllers
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	metal3v1alpha1 "github.com/shweta50/baremetal-operator/apis/metal3.io/v1alpha1"
+	"github.com/shweta50/baremetal-operator/pkg/bmc"
+)
+
+const (
+	// passwordRotationRetryDelay is the time to wait after a failed
+	// rotation before trying again.
+	passwordRotationRetryDelay = time.Hour
+
+	rotationResultSuccess         = "success"
+	rotationResultFailure         = "failure"
+	rotationResultRolledBack      = "rolled_back"
+	rotationResultRollbackFailure = "rollback_failure"
+)
+
+// passwordRotationDue returns true when the BMC password of the host
+// should be rotated at the given time.
+func passwordRotationDue(host *metal3v1alpha1.BareMetalHost, secret *corev1.Secret, now time.Time) bool {
+	policy := host.Spec.BMC.PasswordRotation
+	if policy == nil || policy.Interval.Duration <= 0 {
+		return false
+	}
+
+	// Until the first rotation, measure the interval from the time the
+	// secret was created.
+	lastRotated := secret.CreationTimestamp.Time
+	status := host.Status.PasswordRotation
+	if status != nil {
+		if status.LastRotated != nil {
+			lastRotated = status.LastRotated.Time
+		}
+		if status.ErrorMessage != "" && status.LastAttempt != nil &&
+			now.Before(status.LastAttempt.Add(passwordRotationRetryDelay)) {
+			return false
+		}
+	}
+
+	return !now.Before(lastRotated.Add(policy.Interval.Duration))
+}
+
+func (r *BareMetalHostReconciler) newPasswordRotator(host *metal3v1alpha1.BareMetalHost) (bmc.PasswordRotator, error) {
+	factory := r.PasswordRotatorFactory
+	if factory == nil {
+		factory = bmc.NewPasswordRotator
+	}
+	return factory(host.Spec.BMC.Address, host.Spec.BMC.DisableCertificateVerification)
+}
+
+// recordPasswordRotationFailure stores the error in the host status
+// so the rotation is retried after passwordRotationRetryDelay.
+func recordPasswordRotationFailure(info *reconcileInfo, now metav1.Time, result string, err error) actionResult {
+	info.log.Info("BMC password rotation failed", "result", result, "error", err.Error())
+	info.host.Status.PasswordRotation.LastAttempt = &now
+	info.host.Status.PasswordRotation.ErrorMessage = err.Error()
+	info.publishEvent("BMCPasswordRotationFailed", err.Error())
+	info.postSaveCallbacks = append(info.postSaveCallbacks, func() {
+		passwordRotations.WithLabelValues(result).Inc()
+	})
+	return actionUpdate{}
+}
+
+// rollbackBMCPassword restores the previous password on the BMC after
+// a failed rotation.
+func rollbackBMCPassword(info *reconcileInfo, rotator bmc.PasswordRotator, oldCreds, newCreds bmc.Credentials) string {
+	if err := rotator.ChangePassword(newCreds, oldCreds.Password); err != nil {
+		info.log.Info("failed to restore previous BMC password", "error", err.Error())
+		return rotationResultRollbackFailure
+	}
+	return rotationResultRolledBack
+}
+
+// manageBMCPasswordRotation changes the BMC password of a host in a
+// steady state when its rotation policy says it is due. The new
+// password is set on the BMC and verified before the credentials
+// secret is updated, and the BMC is rolled back to the previous
+// password if either of the later steps fails. Updating the secret
+// causes the host to be re-registered with the new credentials.
+func (r *BareMetalHostReconciler) manageBMCPasswordRotation(info *reconcileInfo) actionResult {
+	host := info.host
+	secret := info.bmcCredsSecret
+	if secret == nil || host.Status.ErrorType != "" || !r.usesSecretCredentials() {
+		return nil
+	}
+	// Only rotate credentials we know to be working.
+	if !host.Status.GoodCredentials.Match(*secret) {
+		return nil
+	}
+
+	now := metav1.Now()
+	if !passwordRotationDue(host, secret, now.Time) {
+		return nil
+	}
+
+	info.log.Info("rotating BMC password")
+	if host.Status.PasswordRotation == nil {
+		host.Status.PasswordRotation = &metal3v1alpha1.PasswordRotationStatus{}
+	}
+
+	rotator, err := r.newPasswordRotator(host)
+	if err != nil {
+		return recordPasswordRotationFailure(info, now, rotationResultFailure, err)
+	}
+
+	policy := host.Spec.BMC.PasswordRotation
+	newPassword, err := bmc.GeneratePassword(policy.PasswordLength, policy.PasswordCharacters)
+	if err != nil {
+		return recordPasswordRotationFailure(info, now, rotationResultFailure, err)
+	}
+
+	oldCreds := *bmc.CredentialsFromSecret(secret)
+	newCreds := bmc.Credentials{Username: oldCreds.Username, Password: newPassword}
+
+	if err = rotator.ChangePassword(oldCreds, newPassword); err != nil {
+		return recordPasswordRotationFailure(info, now, rotationResultFailure, err)
+	}
+
+	if err = rotator.VerifyCredentials(newCreds); err != nil {
+		result := rollbackBMCPassword(info, rotator, oldCreds, newCreds)
+		return recordPasswordRotationFailure(info, now, result, err)
+	}
+
+	// The update carries the resource version we read, so it fails
+	// rather than overwriting a secret someone else changed meanwhile.
+	updated := secret.DeepCopy()
+	if updated.Data == nil {
+		updated.Data = map[string][]byte{}
+	}
+	updated.Data["password"] = []byte(newPassword)
+	if template := policy.SecretTemplate; template != nil {
+		for k, v := range template.Labels {
+			metav1.SetMetaDataLabel(&updated.ObjectMeta, k, v)
+		}
+		for k, v := range template.Annotations {
+			metav1.SetMetaDataAnnotation(&updated.ObjectMeta, k, v)
+		}
+	}
+	if err = r.Update(context.TODO(), updated); err != nil {
+		result := rollbackBMCPassword(info, rotator, oldCreds, newCreds)
+		return recordPasswordRotationFailure(info, now, result,
+			errors.Wrap(err, "failed to update BMC credentials secret"))
+	}
+
+	host.Status.PasswordRotation.LastRotated = &now
+	host.Status.PasswordRotation.LastAttempt = &now
+	host.Status.PasswordRotation.ErrorMessage = ""
+	info.publishEvent("BMCPasswordRotated", "Rotated the BMC password")
+	info.postSaveCallbacks = append(info.postSaveCallbacks, func() {
+		passwordRotations.WithLabelValues(rotationResultSuccess).Inc()
+	})
+	return actionUpdate{}
+}
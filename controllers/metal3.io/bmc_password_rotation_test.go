@@ -0,0 +1,186 @@
+package controllers
+
+import (
+	goctx "context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	metal3v1alpha1 "github.com/shweta50/baremetal-operator/apis/metal3.io/v1alpha1"
+	"github.com/shweta50/baremetal-operator/pkg/bmc"
+)
+
+type fakePasswordRotator struct {
+	password    string
+	changeErr   error
+	verifyErr   error
+	changeCalls int
+}
+
+func (f *fakePasswordRotator) ChangePassword(creds bmc.Credentials, newPassword string) error {
+	f.changeCalls++
+	if f.changeErr != nil {
+		return f.changeErr
+	}
+	if creds.Password != f.password {
+		return fmt.Errorf("bad credentials")
+	}
+	f.password = newPassword
+	return nil
+}
+
+func (f *fakePasswordRotator) VerifyCredentials(creds bmc.Credentials) error {
+	if f.verifyErr != nil {
+		return f.verifyErr
+	}
+	if creds.Password != f.password {
+		return fmt.Errorf("bad credentials")
+	}
+	return nil
+}
+
+func newPasswordRotationTest(t *testing.T, rotator *fakePasswordRotator) (*BareMetalHostReconciler, *reconcileInfo) {
+	r := newTestReconciler()
+	r.PasswordRotatorFactory = func(address string, disableCertificateVerification bool) (bmc.PasswordRotator, error) {
+		return rotator, nil
+	}
+
+	secret := &corev1.Secret{}
+	err := r.Get(goctx.TODO(), types.NamespacedName{Namespace: namespace, Name: defaultSecretName}, secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rotator.password = string(secret.Data["password"])
+
+	host := newDefaultHost(t)
+	host.Spec.BMC.Address = "redfish://192.168.122.1/redfish/v1/Systems/1"
+	host.Spec.BMC.PasswordRotation = &metal3v1alpha1.BMCPasswordRotation{
+		Interval:       metav1.Duration{Duration: time.Hour},
+		PasswordLength: 16,
+		SecretTemplate: &metal3v1alpha1.BMCSecretTemplate{
+			Annotations: map[string]string{"example.com/rotated": "true"},
+		},
+	}
+	host.UpdateGoodCredentials(*secret)
+	lastRotated := metav1.NewTime(time.Now().Add(-2 * time.Hour))
+	host.Status.PasswordRotation = &metal3v1alpha1.PasswordRotationStatus{LastRotated: &lastRotated}
+
+	info := makeReconcileInfo(host)
+	info.bmcCredsSecret = secret
+	return r, info
+}
+
+func TestPasswordRotationDue(t *testing.T) {
+	now := time.Now()
+	hourAgo := metav1.NewTime(now.Add(-time.Hour))
+	minuteAgo := metav1.NewTime(now.Add(-time.Minute))
+	policy := &metal3v1alpha1.BMCPasswordRotation{Interval: metav1.Duration{Duration: 30 * time.Minute}}
+
+	for _, tc := range []struct {
+		Scenario string
+		Policy   *metal3v1alpha1.BMCPasswordRotation
+		Status   *metal3v1alpha1.PasswordRotationStatus
+		Created  metav1.Time
+		Expected bool
+	}{
+		{
+			Scenario: "no policy",
+			Created:  hourAgo,
+			Expected: false,
+		},
+		{
+			Scenario: "never rotated, old secret",
+			Policy:   policy,
+			Created:  hourAgo,
+			Expected: true,
+		},
+		{
+			Scenario: "never rotated, new secret",
+			Policy:   policy,
+			Created:  minuteAgo,
+			Expected: false,
+		},
+		{
+			Scenario: "rotated recently",
+			Policy:   policy,
+			Created:  hourAgo,
+			Status:   &metal3v1alpha1.PasswordRotationStatus{LastRotated: &minuteAgo},
+			Expected: false,
+		},
+		{
+			Scenario: "recent failure",
+			Policy:   policy,
+			Created:  hourAgo,
+			Status: &metal3v1alpha1.PasswordRotationStatus{
+				LastRotated:  &hourAgo,
+				LastAttempt:  &minuteAgo,
+				ErrorMessage: "failed",
+			},
+			Expected: false,
+		},
+	} {
+		t.Run(tc.Scenario, func(t *testing.T) {
+			host := newDefaultHost(t)
+			host.Spec.BMC.PasswordRotation = tc.Policy
+			host.Status.PasswordRotation = tc.Status
+			secret := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{CreationTimestamp: tc.Created}}
+			assert.Equal(t, tc.Expected, passwordRotationDue(host, secret, now))
+		})
+	}
+}
+
+func TestManageBMCPasswordRotation(t *testing.T) {
+	rotator := &fakePasswordRotator{}
+	r, info := newPasswordRotationTest(t, rotator)
+	oldPassword := rotator.password
+
+	result := r.manageBMCPasswordRotation(info)
+	assert.Equal(t, actionUpdate{}, result)
+	assert.Empty(t, info.host.Status.PasswordRotation.ErrorMessage)
+	assert.NotEqual(t, oldPassword, rotator.password)
+	assert.Len(t, rotator.password, 16)
+
+	secret := &corev1.Secret{}
+	err := r.Get(goctx.TODO(), types.NamespacedName{Namespace: namespace, Name: defaultSecretName}, secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, rotator.password, string(secret.Data["password"]))
+	assert.Equal(t, "true", secret.Annotations["example.com/rotated"])
+	assert.False(t, info.host.Status.GoodCredentials.Match(*secret))
+}
+
+func TestManageBMCPasswordRotationNotDue(t *testing.T) {
+	rotator := &fakePasswordRotator{}
+	r, info := newPasswordRotationTest(t, rotator)
+	now := metav1.Now()
+	info.host.Status.PasswordRotation.LastRotated = &now
+
+	assert.Nil(t, r.manageBMCPasswordRotation(info))
+	assert.Equal(t, 0, rotator.changeCalls)
+}
+
+func TestManageBMCPasswordRotationRollback(t *testing.T) {
+	rotator := &fakePasswordRotator{verifyErr: fmt.Errorf("verification failed")}
+	r, info := newPasswordRotationTest(t, rotator)
+	oldPassword := rotator.password
+
+	result := r.manageBMCPasswordRotation(info)
+	assert.Equal(t, actionUpdate{}, result)
+	assert.Equal(t, oldPassword, rotator.password)
+	assert.Equal(t, 2, rotator.changeCalls)
+	assert.Contains(t, info.host.Status.PasswordRotation.ErrorMessage, "verification failed")
+	assert.NotNil(t, info.host.Status.PasswordRotation.LastAttempt)
+
+	secret := &corev1.Secret{}
+	err := r.Get(goctx.TODO(), types.NamespacedName{Namespace: namespace, Name: defaultSecretName}, secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, oldPassword, string(secret.Data["password"]))
+}
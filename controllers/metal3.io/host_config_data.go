@@ -6,13 +6,12 @@ import (
 
 	"github.com/go-logr/logr"
 	"github.com/pkg/errors"
-	corev1 "k8s.io/api/core/v1"
-	k8serrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	metal3v1alpha1 "github.com/shweta50/baremetal-operator/apis/metal3.io/v1alpha1"
+	"github.com/shweta50/baremetal-operator/pkg/bmc"
 )
 
 // hostConfigData is an implementation of host configuration data interface.
@@ -24,33 +23,6 @@ type hostConfigData struct {
 	apiReader client.Reader
 }
 
-// Generic method for retrieving a secret. If the secret is not found in the
-// filtered cache, then the object is retrieved directly from the API
-func getSecret(client client.Client, apiReader client.Reader, secretKey types.NamespacedName) (secret *corev1.Secret, err error) {
-
-	secret = &corev1.Secret{}
-
-	// Look for secret in the filtered cache
-	err = client.Get(context.TODO(), secretKey, secret)
-	if err == nil {
-		return secret, nil
-	}
-	if !k8serrors.IsNotFound(err) {
-		return nil, err
-	}
-
-	// Secret not in cache; check API directly for unlabelled Secret
-	err = apiReader.Get(context.TODO(), secretKey, secret)
-	if err != nil {
-		if k8serrors.IsNotFound(err) {
-			return nil, err
-		}
-		return nil, err
-	}
-
-	return secret, nil
-}
-
 // Generic method for data extraction from a Secret. Function uses dataKey
 // parameter to detirmine which data to return in case secret contins multiple
 // keys
@@ -60,7 +32,8 @@ func (hcd *hostConfigData) getSecretData(name, namespace, dataKey string) (strin
 		Namespace: namespace,
 	}
 
-	secret, err := getSecret(hcd.client, hcd.apiReader, key)
+	secrets := &bmc.SecretCredentialsProvider{Client: hcd.client, APIReader: hcd.apiReader}
+	secret, err := secrets.GetSecret(key)
 	if err != nil {
 		return "", errors.Wrap(err, fmt.Sprintf("failed to fetch user data from secret %s defined in namespace %s", name, namespace))
 	}
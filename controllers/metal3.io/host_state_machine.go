@@ -2,6 +2,8 @@ package controllers
 
 import (
 	"fmt"
+	"strconv"
+	"time"
 
 	metal3v1alpha1 "github.com/shweta50/baremetal-operator/apis/metal3.io/v1alpha1"
 	"github.com/shweta50/baremetal-operator/pkg/provisioner"
@@ -79,8 +81,42 @@ func recordStateEnd(info *reconcileInfo, host *metal3v1alpha1.BareMetalHost, sta
 	return
 }
 
+// operationForState returns the class of provisioner operation
+// started when entering the state.
+func operationForState(state metal3v1alpha1.ProvisioningState) provisioner.Operation {
+	switch state {
+	case metal3v1alpha1.StateInspecting:
+		return provisioner.OperationInspection
+	case metal3v1alpha1.StateDeprovisioning:
+		return provisioner.OperationCleaning
+	case metal3v1alpha1.StateDeleting:
+		return provisioner.OperationDeleting
+	default:
+		return provisioner.OperationProvisioning
+	}
+}
+
+// provisioningPriority returns the priority of the host when waiting
+// for a provisioning slot, as set with the
+// ProvisioningPriorityAnnotation.
+func provisioningPriority(info *reconcileInfo) int {
+	value, ok := info.host.Annotations[metal3v1alpha1.ProvisioningPriorityAnnotation]
+	if !ok {
+		return 0
+	}
+	priority, err := strconv.Atoi(value)
+	if err != nil {
+		info.log.Info("ignoring invalid provisioning priority", "priority", value)
+		return 0
+	}
+	return priority
+}
+
 func (hsm *hostStateMachine) ensureCapacity(info *reconcileInfo, state metal3v1alpha1.ProvisioningState) actionResult {
-	hasCapacity, err := hsm.Provisioner.HasCapacity()
+	hasCapacity, err := hsm.Provisioner.HasCapacity(provisioner.CapacityData{
+		Operation: operationForState(state),
+		Priority:  provisioningPriority(info),
+	})
 	if err != nil {
 		return actionError{errors.Wrap(err, "failed to determine current provisioner capacity")}
 	}
@@ -412,9 +448,18 @@ func (hsm *hostStateMachine) handleReady(info *reconcileInfo) actionResult {
 		return actionComplete{}
 	}
 
-	if dirty, _, err := getHostProvisioningSettings(info.host); err != nil {
+	dirty, _, err := getHostProvisioningSettings(info.host)
+	if err != nil {
 		return actionError{err}
-	} else if dirty {
+	}
+
+	// Periodic re-inspection only happens while the host is idle, so it
+	// never delays preparing or provisioning the host.
+	if !dirty && !provisioningRequested(hsm.Host) && reinspectionDue(hsm.Host, time.Now()) {
+		return hsm.Reconciler.requestReinspection(info)
+	}
+
+	if dirty {
 		hsm.NextState = metal3v1alpha1.StatePreparing
 		return actionComplete{}
 	}
@@ -135,7 +135,7 @@ func TestProvisioningCapacity(t *testing.T) {
 			assert.Equal(t, tc.ExpectedDelayed, assert.ObjectsAreEqual(actionDelayed{}, result), "Expected actionDelayed")
 
 			if tc.ExpectedDelayed {
-				counter, _ := delayedProvisioningHostCounters.GetMetricWith(hostMetricLabels(info.request))
+				counter, _ := delayedProvisioningHostCounters.GetMetricWith(delayedHostMetricLabels(info.request, prov.capacityData.Operation))
 				initialCounterValue := promutil.ToFloat64(counter)
 				for _, sb := range info.postSaveCallbacks {
 					sb()
@@ -189,7 +189,7 @@ func TestDeprovisioningCapacity(t *testing.T) {
 			assert.Equal(t, tc.ExpectedDelayed, assert.ObjectsAreEqual(actionDelayed{}, result), "Expected actionDelayed")
 
 			if tc.ExpectedDelayed {
-				counter, _ := delayedDeprovisioningHostCounters.GetMetricWith(hostMetricLabels(info.request))
+				counter, _ := delayedDeprovisioningHostCounters.GetMetricWith(delayedHostMetricLabels(info.request, prov.capacityData.Operation))
 				initialCounterValue := promutil.ToFloat64(counter)
 				for _, sb := range info.postSaveCallbacks {
 					sb()
@@ -200,6 +200,99 @@ func TestDeprovisioningCapacity(t *testing.T) {
 	}
 }
 
+func TestCapacityData(t *testing.T) {
+	testCases := []struct {
+		Scenario string
+		Host     *metal3v1alpha1.BareMetalHost
+		Priority string
+
+		ExpectedData provisioner.CapacityData
+	}{
+		{
+			Scenario:     "inspection",
+			Host:         host(metal3v1alpha1.StateRegistering).build(),
+			ExpectedData: provisioner.CapacityData{Operation: provisioner.OperationInspection},
+		},
+		{
+			Scenario:     "provisioning with priority",
+			Host:         host(metal3v1alpha1.StateReady).SaveHostProvisioningSettings().build(),
+			Priority:     "10",
+			ExpectedData: provisioner.CapacityData{Operation: provisioner.OperationProvisioning, Priority: 10},
+		},
+		{
+			Scenario:     "deprovisioning with invalid priority",
+			Host:         host(metal3v1alpha1.StateDeprovisioning).build(),
+			Priority:     "high",
+			ExpectedData: provisioner.CapacityData{Operation: provisioner.OperationCleaning},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Scenario, func(t *testing.T) {
+			if tc.Priority != "" {
+				tc.Host.Annotations = map[string]string{metal3v1alpha1.ProvisioningPriorityAnnotation: tc.Priority}
+			}
+			prov := newMockProvisioner()
+			hsm := newHostStateMachine(tc.Host, &BareMetalHostReconciler{}, prov, true)
+			info := makeDefaultReconcileInfo(tc.Host)
+
+			hsm.ReconcileState(info)
+
+			assert.Equal(t, tc.ExpectedData, prov.capacityData)
+		})
+	}
+}
+
+type fakeNetworkSwitcher struct {
+	phases []provisioner.NetworkPhase
+	result provisioner.Result
+}
+
+func (f *fakeNetworkSwitcher) SwitchNetwork(data provisioner.NetworkSwitchData) (provisioner.Result, error) {
+	f.phases = append(f.phases, data.Phase)
+	return f.result, nil
+}
+
+func TestNetworkSwitching(t *testing.T) {
+	switcher := &fakeNetworkSwitcher{result: provisioner.Result{Dirty: true, RequeueAfter: time.Second}}
+	reconciler := &BareMetalHostReconciler{NetworkSwitcher: switcher}
+	host := host(metal3v1alpha1.StateProvisioning).build()
+	prov := newMockProvisioner()
+	hsm := newHostStateMachine(host, reconciler, prov, true)
+
+	// The switch is in progress
+	result := hsm.ReconcileState(makeDefaultReconcileInfo(host))
+	assert.False(t, result.Dirty())
+	assert.Equal(t, "", host.Status.Provisioning.NetworkPhase)
+
+	switcher.result = provisioner.Result{}
+	result = hsm.ReconcileState(makeDefaultReconcileInfo(host))
+	assert.True(t, result.Dirty())
+	assert.Equal(t, string(provisioner.NetworkPhaseProvisioning), host.Status.Provisioning.NetworkPhase)
+
+	// The image is written, the host moves to its tenant networks and
+	// provisioning completes in the same pass
+	result = hsm.ReconcileState(makeDefaultReconcileInfo(host))
+	assert.True(t, result.Dirty())
+	assert.Equal(t, string(provisioner.NetworkPhaseProvisioned), host.Status.Provisioning.NetworkPhase)
+	assert.Equal(t, metal3v1alpha1.StateProvisioned, host.Status.Provisioning.State)
+	assert.Equal(t, []provisioner.NetworkPhase{
+		provisioner.NetworkPhaseProvisioning,
+		provisioner.NetworkPhaseProvisioning,
+		provisioner.NetworkPhaseProvisioned,
+	}, switcher.phases)
+
+	// A refusal fails the operation
+	switcher.result = provisioner.Result{ErrorMessage: "no such VLAN"}
+	host = host.DeepCopy()
+	host.Status.Provisioning.State = metal3v1alpha1.StateDeprovisioning
+	hsm = newHostStateMachine(host, reconciler, prov, true)
+	hsm.ReconcileState(makeDefaultReconcileInfo(host))
+	assert.Equal(t, metal3v1alpha1.ProvisioningError, host.Status.ErrorType)
+	assert.Equal(t, "no such VLAN", host.Status.ErrorMessage)
+	assert.Equal(t, string(provisioner.NetworkPhaseProvisioned), host.Status.Provisioning.NetworkPhase)
+}
+
 func TestDetach(t *testing.T) {
 	testCases := []struct {
 		Scenario                  string
@@ -1102,8 +1195,10 @@ func newMockProvisioner() *mockProvisioner {
 
 type mockProvisioner struct {
 	hasCapacity  bool
+	capacityData provisioner.CapacityData
 	nextResults  map[string]provisioner.Result
 	callsNoError map[string]bool
+	hostState    *provisioner.HostState
 }
 
 func (m *mockProvisioner) getNextResultByMethod(name string) (result provisioner.Result) {
@@ -1119,10 +1214,15 @@ func (m *mockProvisioner) setHasCapacity(hasCapacity bool) {
 	m.hasCapacity = hasCapacity
 }
 
-func (m *mockProvisioner) HasCapacity() (result bool, err error) {
+func (m *mockProvisioner) HasCapacity(data provisioner.CapacityData) (result bool, err error) {
+	m.capacityData = data
 	return m.hasCapacity, nil
 }
 
+func (m *mockProvisioner) LastHostState() *provisioner.HostState {
+	return m.hostState
+}
+
 func (m *mockProvisioner) setNextError(methodName, msg string) {
 	m.nextResults[methodName] = provisioner.Result{
 		ErrorMessage: msg,
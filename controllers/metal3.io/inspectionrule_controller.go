@@ -0,0 +1,156 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/pkg/errors"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	metal3v1alpha1 "github.com/shweta50/baremetal-operator/apis/metal3.io/v1alpha1"
+	"github.com/shweta50/baremetal-operator/pkg/provisioner"
+	"github.com/shweta50/baremetal-operator/pkg/utils"
+)
+
+const (
+	// inspectionRuleResyncInterval is the time between two checks
+	// that an applied rule is still present in the provisioner, which
+	// may lose it when restarted.
+	inspectionRuleResyncInterval = 5 * time.Minute
+
+	// inspectionRuleRetryDelay is the time to wait before applying a
+	// rule again after a failure.
+	inspectionRuleRetryDelay = time.Minute
+)
+
+// InspectionRuleReconciler reconciles an InspectionRule object
+type InspectionRuleReconciler struct {
+	client.Client
+	Log         logr.Logger
+	RuleManager provisioner.InspectionRuleManager
+}
+
+// +kubebuilder:rbac:groups=metal3.io,resources=inspectionrules,verbs=get;list;watch;update;patch
+// +kubebuilder:rbac:groups=metal3.io,resources=inspectionrules/status,verbs=get;update;patch
+
+// Reconcile applies InspectionRule resources to the provisioner
+func (r *InspectionRuleReconciler) Reconcile(ctx context.Context, request ctrl.Request) (ctrl.Result, error) {
+	reqLogger := r.Log.WithValues("inspectionrule", request.NamespacedName)
+
+	rule := &metal3v1alpha1.InspectionRule{}
+	if err := r.Get(ctx, request.NamespacedName, rule); err != nil {
+		if k8serrors.IsNotFound(err) {
+			return ctrl.Result{}, nil
+		}
+		return ctrl.Result{}, errors.Wrap(err, "could not load inspection rule")
+	}
+	data := provisioner.BuildInspectionRuleData(*rule)
+
+	if !rule.DeletionTimestamp.IsZero() {
+		if !utils.StringInList(rule.Finalizers, metal3v1alpha1.InspectionRuleFinalizer) {
+			return ctrl.Result{}, nil
+		}
+		if err := r.removeFromPreviousBackend(rule, data); err != nil {
+			return ctrl.Result{}, err
+		}
+		if err := r.RuleManager.RemoveInspectionRule(data); err != nil {
+			return ctrl.Result{}, errors.Wrap(err, "failed to remove inspection rule")
+		}
+		rule.Finalizers = utils.FilterStringFromList(rule.Finalizers, metal3v1alpha1.InspectionRuleFinalizer)
+		reqLogger.Info("inspection rule removed")
+		if err := r.Update(ctx, rule); err != nil {
+			return ctrl.Result{}, errors.Wrap(err, "failed to remove finalizer")
+		}
+		return ctrl.Result{}, nil
+	}
+
+	if !utils.StringInList(rule.Finalizers, metal3v1alpha1.InspectionRuleFinalizer) {
+		rule.Finalizers = append(rule.Finalizers, metal3v1alpha1.InspectionRuleFinalizer)
+		if err := r.Update(ctx, rule); err != nil {
+			return ctrl.Result{}, errors.Wrap(err, "failed to add finalizer")
+		}
+		return ctrl.Result{Requeue: true}, nil
+	}
+
+	if err := r.removeFromPreviousBackend(rule, data); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	// Rules the provisioner holds are only replaced when the spec
+	// changed or the last attempt failed.
+	replace := !rule.Status.Applied || rule.Status.ObservedGeneration != rule.Generation
+	applyErr := r.RuleManager.ApplyInspectionRule(data, replace)
+
+	status := metal3v1alpha1.InspectionRuleStatus{
+		Applied:            applyErr == nil,
+		UUID:               data.ID,
+		ObservedGeneration: rule.Generation,
+		Backend:            rule.Labels[metal3v1alpha1.IronicBackendLabel],
+	}
+	if applyErr != nil {
+		reqLogger.Info("failed to apply inspection rule", "error", applyErr.Error())
+		status.ErrorMessage = applyErr.Error()
+	}
+	if status != rule.Status {
+		rule.Status = status
+		if err := r.Status().Update(ctx, rule); err != nil {
+			return ctrl.Result{}, errors.Wrap(err, "failed to update inspection rule status")
+		}
+	}
+
+	if applyErr != nil {
+		return ctrl.Result{RequeueAfter: inspectionRuleRetryDelay}, nil
+	}
+	return ctrl.Result{RequeueAfter: inspectionRuleResyncInterval}, nil
+}
+
+// removeFromPreviousBackend deletes the rule from the backend it was
+// last applied to, when its IronicBackendLabel changed since then, so
+// that backend stops running it.
+func (r *InspectionRuleReconciler) removeFromPreviousBackend(rule *metal3v1alpha1.InspectionRule, data provisioner.InspectionRuleData) error {
+	backend := rule.Labels[metal3v1alpha1.IronicBackendLabel]
+	if rule.Status.UUID == "" || rule.Status.Backend == backend {
+		return nil
+	}
+
+	previous := data
+	previous.ObjectMeta = *data.ObjectMeta.DeepCopy()
+	delete(previous.ObjectMeta.Labels, metal3v1alpha1.IronicBackendLabel)
+	if rule.Status.Backend != "" {
+		if previous.ObjectMeta.Labels == nil {
+			previous.ObjectMeta.Labels = map[string]string{}
+		}
+		previous.ObjectMeta.Labels[metal3v1alpha1.IronicBackendLabel] = rule.Status.Backend
+	}
+	if err := r.RuleManager.RemoveInspectionRule(previous); err != nil {
+		return errors.Wrap(err, "failed to remove inspection rule from the previous backend")
+	}
+	r.Log.Info("inspection rule removed from the previous backend",
+		"inspectionrule", rule.Namespace+"/"+rule.Name, "backend", rule.Status.Backend)
+	return nil
+}
+
+// SetupWithManager registers the reconciler to be run by the manager
+func (r *InspectionRuleReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&metal3v1alpha1.InspectionRule{}).
+		Complete(r)
+}
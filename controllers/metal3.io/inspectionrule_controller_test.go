@@ -0,0 +1,166 @@
+package controllers
+
+import (
+	goctx "context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	fakeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	metal3v1alpha1 "github.com/shweta50/baremetal-operator/apis/metal3.io/v1alpha1"
+	"github.com/shweta50/baremetal-operator/pkg/provisioner"
+)
+
+type fakeRuleManager struct {
+	rules       map[string]provisioner.InspectionRuleData
+	replaced    int
+	removedFrom []string
+	err         error
+}
+
+func (m *fakeRuleManager) ApplyInspectionRule(rule provisioner.InspectionRuleData, replace bool) error {
+	if m.err != nil {
+		return m.err
+	}
+	if _, exists := m.rules[rule.ID]; exists && !replace {
+		return nil
+	}
+	if replace {
+		m.replaced++
+	}
+	m.rules[rule.ID] = rule
+	return nil
+}
+
+func (m *fakeRuleManager) RemoveInspectionRule(rule provisioner.InspectionRuleData) error {
+	m.removedFrom = append(m.removedFrom, rule.ObjectMeta.Labels[metal3v1alpha1.IronicBackendLabel])
+	delete(m.rules, rule.ID)
+	return nil
+}
+
+func TestInspectionRuleReconcile(t *testing.T) {
+	rule := &metal3v1alpha1.InspectionRule{
+		ObjectMeta: metav1.ObjectMeta{Name: "set-arch", Namespace: namespace, UID: "2d5b7c3c-7b89-4b1e-a0a3-3f5a2b8e6d01"},
+		Spec: metal3v1alpha1.InspectionRuleSpec{
+			Actions: []metal3v1alpha1.InspectionRuleAction{{Action: "add-trait"}},
+		},
+	}
+	c := fakeclient.NewFakeClient(rule)
+	manager := &fakeRuleManager{rules: map[string]provisioner.InspectionRuleData{}}
+	r := &InspectionRuleReconciler{
+		Client:      c,
+		Log:         ctrl.Log.WithName("controllers").WithName("InspectionRule"),
+		RuleManager: manager,
+	}
+	request := ctrl.Request{NamespacedName: types.NamespacedName{Namespace: namespace, Name: "set-arch"}}
+	reconcile := func() *metal3v1alpha1.InspectionRule {
+		_, err := r.Reconcile(goctx.TODO(), request)
+		assert.NoError(t, err)
+		result := &metal3v1alpha1.InspectionRule{}
+		if err = c.Get(goctx.TODO(), request.NamespacedName, result); err != nil {
+			t.Fatal(err)
+		}
+		return result
+	}
+
+	saved := reconcile()
+	assert.Contains(t, saved.Finalizers, metal3v1alpha1.InspectionRuleFinalizer)
+	assert.Empty(t, manager.rules)
+
+	saved = reconcile()
+	assert.True(t, saved.Status.Applied)
+	assert.Equal(t, string(rule.UID), saved.Status.UUID)
+	assert.Contains(t, manager.rules, string(rule.UID))
+	assert.Equal(t, 1, manager.replaced)
+
+	// Nothing changed, the rule is only recreated if missing
+	delete(manager.rules, string(rule.UID))
+	reconcile()
+	assert.Contains(t, manager.rules, string(rule.UID))
+	assert.Equal(t, 1, manager.replaced)
+
+	manager.err = fmt.Errorf("inspector unavailable")
+	saved.Generation++
+	if err := c.Update(goctx.TODO(), saved); err != nil {
+		t.Fatal(err)
+	}
+	saved = reconcile()
+	assert.False(t, saved.Status.Applied)
+	assert.Equal(t, "inspector unavailable", saved.Status.ErrorMessage)
+
+	manager.err = nil
+	saved = reconcile()
+	assert.True(t, saved.Status.Applied)
+	assert.Empty(t, saved.Status.ErrorMessage)
+	assert.Equal(t, 2, manager.replaced)
+
+	if err := c.Delete(goctx.TODO(), saved); err != nil {
+		t.Fatal(err)
+	}
+	_, err := r.Reconcile(goctx.TODO(), request)
+	assert.NoError(t, err)
+	assert.Empty(t, manager.rules)
+}
+
+func TestInspectionRuleBackendChange(t *testing.T) {
+	rule := &metal3v1alpha1.InspectionRule{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:       "set-arch",
+			Namespace:  namespace,
+			UID:        "2d5b7c3c-7b89-4b1e-a0a3-3f5a2b8e6d01",
+			Finalizers: []string{metal3v1alpha1.InspectionRuleFinalizer},
+		},
+		Spec: metal3v1alpha1.InspectionRuleSpec{
+			Actions: []metal3v1alpha1.InspectionRuleAction{{Action: "add-trait"}},
+		},
+	}
+	c := fakeclient.NewFakeClient(rule)
+	manager := &fakeRuleManager{rules: map[string]provisioner.InspectionRuleData{}}
+	r := &InspectionRuleReconciler{
+		Client:      c,
+		Log:         ctrl.Log.WithName("controllers").WithName("InspectionRule"),
+		RuleManager: manager,
+	}
+	request := ctrl.Request{NamespacedName: types.NamespacedName{Namespace: namespace, Name: "set-arch"}}
+	reconcile := func() *metal3v1alpha1.InspectionRule {
+		_, err := r.Reconcile(goctx.TODO(), request)
+		assert.NoError(t, err)
+		result := &metal3v1alpha1.InspectionRule{}
+		if err = c.Get(goctx.TODO(), request.NamespacedName, result); err != nil {
+			t.Fatal(err)
+		}
+		return result
+	}
+
+	saved := reconcile()
+	assert.True(t, saved.Status.Applied)
+	assert.Empty(t, saved.Status.Backend)
+	assert.Empty(t, manager.removedFrom)
+
+	saved.Labels = map[string]string{metal3v1alpha1.IronicBackendLabel: "rack-1"}
+	if err := c.Update(goctx.TODO(), saved); err != nil {
+		t.Fatal(err)
+	}
+	saved = reconcile()
+	assert.Equal(t, []string{""}, manager.removedFrom, "removed from the default backend")
+	assert.Equal(t, "rack-1", saved.Status.Backend)
+	assert.Equal(t, "rack-1", manager.rules[string(rule.UID)].ObjectMeta.Labels[metal3v1alpha1.IronicBackendLabel])
+
+	reconcile()
+	assert.Len(t, manager.removedFrom, 1, "nothing to remove when the backend did not change")
+
+	saved.Labels[metal3v1alpha1.IronicBackendLabel] = "rack-2"
+	if err := c.Update(goctx.TODO(), saved); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Delete(goctx.TODO(), saved); err != nil {
+		t.Fatal(err)
+	}
+	_, err := r.Reconcile(goctx.TODO(), request)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"", "rack-1", "rack-2"}, manager.removedFrom, "removed from both backends when deleted")
+}
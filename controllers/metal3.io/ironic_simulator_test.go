@@ -0,0 +1,136 @@
+package controllers
+
+import (
+	goctx "context"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	metal3v1alpha1 "github.com/shweta50/baremetal-operator/apis/metal3.io/v1alpha1"
+	"github.com/shweta50/baremetal-operator/pkg/provisioner/ironic"
+	"github.com/shweta50/baremetal-operator/pkg/provisioner/ironic/testserver"
+)
+
+func newSimulatedIronicReconciler(t *testing.T, host *metal3v1alpha1.BareMetalHost) (*BareMetalHostReconciler, *testserver.Simulator) {
+	sim := testserver.NewSimulator(t).Start()
+	t.Cleanup(sim.Stop)
+
+	env := map[string]string{
+		"IRONIC_ENDPOINT":           sim.Endpoint(),
+		"IRONIC_INSPECTOR_ENDPOINT": sim.InspectorEndpoint(),
+		"DEPLOY_KERNEL_URL":         "http://deploy.test/ipa.kernel",
+		"DEPLOY_RAMDISK_URL":        "http://deploy.test/ipa.initramfs",
+		"METAL3_AUTH_ROOT_DIR":      t.TempDir(),
+	}
+	for name, value := range env {
+		old, found := os.LookupEnv(name)
+		os.Setenv(name, value)
+		t.Cleanup(func() {
+			if found {
+				os.Setenv(name, old)
+			} else {
+				os.Unsetenv(name)
+			}
+		})
+	}
+
+	r := newTestReconciler(host)
+	r.ProvisionerFactory = ironic.NewProvisionerFactory()
+	return r, sim
+}
+
+// reconcileUntilState reconciles the host, letting the simulated
+// Ironic make progress between each pass, until it reaches the given
+// provisioning state.
+func reconcileUntilState(t *testing.T, r *BareMetalHostReconciler, sim *testserver.Simulator, host *metal3v1alpha1.BareMetalHost, state metal3v1alpha1.ProvisioningState) {
+	request := newRequest(host)
+	for i := 0; i < 50; i++ {
+		if _, err := r.Reconcile(goctx.Background(), request); err != nil {
+			t.Fatalf("reconcile failed: %s", err)
+		}
+		sim.Tick()
+
+		if err := r.Get(goctx.TODO(), request.NamespacedName, host); err != nil {
+			t.Fatal(err)
+		}
+		if host.Status.ErrorMessage != "" {
+			t.Fatalf("host has an error: %s", host.Status.ErrorMessage)
+		}
+		if host.Status.Provisioning.State == state {
+			return
+		}
+	}
+	t.Fatalf("host stuck in state %s waiting for %s", host.Status.Provisioning.State, state)
+}
+
+func TestSimulatedIronicLifecycle(t *testing.T) {
+	host := newHost("simulated", &metal3v1alpha1.BareMetalHostSpec{
+		BMC: metal3v1alpha1.BMCDetails{
+			Address:         "ipmi://192.168.122.1:6233",
+			CredentialsName: defaultSecretName,
+		},
+		BootMACAddress:  "52:54:00:00:00:01",
+		Online:          true,
+		RootDeviceHints: &metal3v1alpha1.RootDeviceHints{DeviceName: "/dev/sda"},
+	})
+	r, sim := newSimulatedIronicReconciler(t, host)
+
+	reconcileUntilState(t, r, sim, host, metal3v1alpha1.StateReady)
+	node, found := sim.Node(host.Status.Provisioning.ID)
+	if !found {
+		t.Fatalf("no node registered for %s", host.Status.Provisioning.ID)
+	}
+	assert.Equal(t, "test-namespace~simulated", node.Name)
+	assert.Equal(t, "available", node.ProvisionState)
+	if assert.NotNil(t, host.Status.HardwareDetails) {
+		assert.Equal(t, "52:54:00:00:00:01", host.Status.HardwareDetails.NIC[0].MAC)
+	}
+
+	host.Spec.Image = &metal3v1alpha1.Image{
+		URL:      "http://images.test/image.qcow2",
+		Checksum: "http://images.test/image.qcow2.md5sum",
+	}
+	if err := r.Update(goctx.TODO(), host); err != nil {
+		t.Fatal(err)
+	}
+	reconcileUntilState(t, r, sim, host, metal3v1alpha1.StateProvisioned)
+	node, _ = sim.Node(host.Status.Provisioning.ID)
+	assert.Equal(t, "active", node.ProvisionState)
+	assert.Equal(t, "power on", node.PowerState)
+	assert.Equal(t, "http://images.test/image.qcow2", node.InstanceInfo["image_source"])
+
+	host.Spec.Image = nil
+	if err := r.Update(goctx.TODO(), host); err != nil {
+		t.Fatal(err)
+	}
+	reconcileUntilState(t, r, sim, host, metal3v1alpha1.StateReady)
+	node, _ = sim.Node(host.Status.Provisioning.ID)
+	assert.Equal(t, "available", node.ProvisionState)
+}
+
+func TestSimulatedIronicInspectionFailure(t *testing.T) {
+	host := newHost("simulated", &metal3v1alpha1.BareMetalHostSpec{
+		BMC: metal3v1alpha1.BMCDetails{
+			Address:         "ipmi://192.168.122.1:6233",
+			CredentialsName: defaultSecretName,
+		},
+		BootMACAddress: "52:54:00:00:00:01",
+	})
+	r, sim := newSimulatedIronicReconciler(t, host)
+	sim.InjectFailure(testserver.Failure{State: "inspect wait", Message: "ramdisk timed out"})
+
+	request := newRequest(host)
+	for i := 0; i < 20 && host.Status.ErrorMessage == ""; i++ {
+		if _, err := r.Reconcile(goctx.Background(), request); err != nil {
+			t.Fatalf("reconcile failed: %s", err)
+		}
+		sim.Tick()
+		if err := r.Get(goctx.TODO(), request.NamespacedName, host); err != nil {
+			t.Fatal(err)
+		}
+	}
+	assert.Equal(t, metal3v1alpha1.StateInspecting, host.Status.Provisioning.State)
+	assert.Equal(t, metal3v1alpha1.InspectionError, host.Status.ErrorType)
+	assert.Contains(t, host.Status.ErrorMessage, "ramdisk timed out")
+}
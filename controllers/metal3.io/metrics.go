@@ -7,6 +7,7 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/metrics"
 
 	metal3v1alpha1 "github.com/shweta50/baremetal-operator/apis/metal3.io/v1alpha1"
+	"github.com/shweta50/baremetal-operator/pkg/provisioner"
 )
 
 const (
@@ -17,6 +18,8 @@ const (
 	labelPrevState     = "prev_state"
 	labelNewState      = "new_state"
 	labelHostDataType  = "host_data_type"
+	labelResult        = "result"
+	labelOperation     = "operation"
 )
 
 var reconcileCounters = prometheus.NewCounterVec(prometheus.CounterOpts{
@@ -57,6 +60,10 @@ var noManagementAccess = prometheus.NewCounter(prometheus.CounterOpts{
 	Name: "metal3_credentials_no_management_access_total",
 	Help: "Number of times a host management interface is unavailable",
 })
+var passwordRotations = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Name: "metal3_bmc_password_rotation_total",
+	Help: "Number of BMC password rotations by outcome",
+}, []string{labelResult})
 var hostConfigDataError = prometheus.NewCounterVec(prometheus.CounterOpts{
 	Name: "metal3_host_config_data_error_total",
 	Help: "Number of times the operator has failed to retrieve host configuration data",
@@ -64,11 +71,11 @@ var hostConfigDataError = prometheus.NewCounterVec(prometheus.CounterOpts{
 var delayedProvisioningHostCounters = prometheus.NewCounterVec(prometheus.CounterOpts{
 	Name: "metal3_delayed__provisioning_total",
 	Help: "The number of times hosts have been delayed while provisioning due a busy provisioner",
-}, []string{labelHostNamespace, labelHostName})
+}, []string{labelHostNamespace, labelHostName, labelOperation})
 var delayedDeprovisioningHostCounters = prometheus.NewCounterVec(prometheus.CounterOpts{
 	Name: "metal3_delayed__deprovisioning_total",
 	Help: "The number of times hosts have been delayed while deprovisioning due a busy provisioner",
-}, []string{labelHostNamespace, labelHostName})
+}, []string{labelHostNamespace, labelHostName, labelOperation})
 
 var slowOperationBuckets = []float64{30, 90, 180, 360, 720, 1440}
 
@@ -133,6 +140,7 @@ func init() {
 		unhandledCredentialsError,
 		updatedCredentials,
 		noManagementAccess,
+		passwordRotations,
 		hostConfigDataError)
 
 	metrics.Registry.MustRegister(
@@ -149,6 +157,12 @@ func hostMetricLabels(request ctrl.Request) prometheus.Labels {
 	}
 }
 
+func delayedHostMetricLabels(request ctrl.Request, operation provisioner.Operation) prometheus.Labels {
+	labels := hostMetricLabels(request)
+	labels[labelOperation] = string(operation)
+	return labels
+}
+
 func stateChangeMetricLabels(prevState, newState metal3v1alpha1.ProvisioningState) prometheus.Labels {
 	return prometheus.Labels{
 		labelPrevState: string(prevState),
@@ -0,0 +1,85 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	metal3v1alpha1 "github.com/shweta50/baremetal-operator/apis/metal3.io/v1alpha1"
+	"github.com/shweta50/baremetal-operator/pkg/hardware"
+)
+
+// reinspectionDue returns true when an available host should be
+// inspected again at the given time.
+func reinspectionDue(host *metal3v1alpha1.BareMetalHost, now time.Time) bool {
+	policy := host.Spec.Reinspection
+	if policy == nil || policy.Interval.Duration <= 0 || inspectionDisabled(host) {
+		return false
+	}
+
+	// Hosts inspected before the history was recorded are inspected
+	// right away.
+	lastInspected := host.Status.OperationHistory.Inspect.End
+	if lastInspected.IsZero() {
+		return true
+	}
+	return !now.Before(lastInspected.Add(policy.Interval.Duration))
+}
+
+// provisioningRequested returns whether an image or a custom deploy is
+// requested for the host.
+func provisioningRequested(host *metal3v1alpha1.BareMetalHost) bool {
+	if host.Spec.Image != nil && host.Spec.Image.URL != "" {
+		return true
+	}
+	return host.Spec.CustomDeploy != nil && host.Spec.CustomDeploy.Method != ""
+}
+
+// requestReinspection sets the inspect annotation on the host, so it
+// goes through inspection again the way it would when a user asks for
+// it.
+func (r *BareMetalHostReconciler) requestReinspection(info *reconcileInfo) actionResult {
+	info.log.Info("requesting periodic re-inspection")
+	if info.host.Annotations == nil {
+		info.host.Annotations = map[string]string{}
+	}
+	info.host.Annotations[inspectAnnotationPrefix] = ""
+	if err := r.Update(context.TODO(), info.host); err != nil {
+		return actionError{errors.Wrap(err, "failed to request re-inspection")}
+	}
+	info.publishEvent("ReinspectionRequested", "Periodic hardware inspection requested")
+	return actionContinue{}
+}
+
+// recordHardwareDrift compares the hardware details found by an
+// inspection with the previous ones, and reports the changes in an
+// event each and in the host status.
+func recordHardwareDrift(info *reconcileInfo, details *metal3v1alpha1.HardwareDetails) {
+	changes := hardware.Diff(info.host.Status.HardwareDetails, details)
+	if len(changes) == 0 {
+		return
+	}
+
+	info.log.Info("hardware changed since the last inspection", "changes", len(changes))
+	for _, change := range changes {
+		info.publishEvent("HardwareChanged", describeHardwareChange(change))
+	}
+	info.host.Status.HardwareDrift = &metal3v1alpha1.HardwareDrift{
+		DetectedAt: metav1.Now(),
+		Changes:    changes,
+	}
+}
+
+func describeHardwareChange(change metal3v1alpha1.HardwareChange) string {
+	subject := string(change.Component)
+	if change.Name != "" {
+		subject = fmt.Sprintf("%s %s", subject, change.Name)
+	}
+	if change.Type != metal3v1alpha1.HardwareChanged {
+		return fmt.Sprintf("%s %s", subject, change.Type)
+	}
+	return fmt.Sprintf("%s %s changed from %q to %q", subject, change.Field, change.Old, change.New)
+}
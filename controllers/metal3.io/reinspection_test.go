@@ -0,0 +1,173 @@
+package controllers
+
+import (
+	goctx "context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	metal3v1alpha1 "github.com/shweta50/baremetal-operator/apis/metal3.io/v1alpha1"
+)
+
+func TestReinspectionDue(t *testing.T) {
+	now := time.Now()
+	hourly := &metal3v1alpha1.ReinspectionPolicy{Interval: metav1.Duration{Duration: time.Hour}}
+
+	for _, tc := range []struct {
+		Scenario    string
+		Policy      *metal3v1alpha1.ReinspectionPolicy
+		Annotations map[string]string
+		Inspected   metav1.Time
+		Expected    bool
+	}{
+		{
+			Scenario:  "no policy",
+			Inspected: metav1.NewTime(now.Add(-48 * time.Hour)),
+		},
+		{
+			Scenario:  "zero interval",
+			Policy:    &metal3v1alpha1.ReinspectionPolicy{},
+			Inspected: metav1.NewTime(now.Add(-48 * time.Hour)),
+		},
+		{
+			Scenario:  "inspected recently",
+			Policy:    hourly,
+			Inspected: metav1.NewTime(now.Add(-time.Minute)),
+		},
+		{
+			Scenario:  "interval elapsed",
+			Policy:    hourly,
+			Inspected: metav1.NewTime(now.Add(-2 * time.Hour)),
+			Expected:  true,
+		},
+		{
+			Scenario: "never inspected",
+			Policy:   hourly,
+			Expected: true,
+		},
+		{
+			Scenario:    "inspection disabled",
+			Policy:      hourly,
+			Annotations: map[string]string{inspectAnnotationPrefix: "disabled"},
+			Inspected:   metav1.NewTime(now.Add(-2 * time.Hour)),
+		},
+	} {
+		t.Run(tc.Scenario, func(t *testing.T) {
+			host := newDefaultHost(t)
+			host.Annotations = tc.Annotations
+			host.Spec.Reinspection = tc.Policy
+			host.Status.OperationHistory.Inspect.End = tc.Inspected
+			assert.Equal(t, tc.Expected, reinspectionDue(host, now))
+		})
+	}
+}
+
+func TestRequestReinspection(t *testing.T) {
+	host := newDefaultHost(t)
+	r := newTestReconciler(host)
+	info := makeReconcileInfo(host)
+
+	result := r.requestReinspection(info)
+	assert.Equal(t, actionContinue{}, result)
+	assert.Len(t, info.events, 1)
+
+	saved := &metal3v1alpha1.BareMetalHost{}
+	err := r.Get(goctx.TODO(), types.NamespacedName{Namespace: host.Namespace, Name: host.Name}, saved)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.True(t, hasInspectAnnotation(saved))
+}
+
+func TestReinspectionOnlyWhenIdle(t *testing.T) {
+	for _, tc := range []struct {
+		Scenario        string
+		Image           *metal3v1alpha1.Image
+		CustomDeploy    *metal3v1alpha1.CustomDeploy
+		Dirty           bool
+		ExpectedInspect bool
+		ExpectedState   metal3v1alpha1.ProvisioningState
+	}{
+		{
+			Scenario:        "idle",
+			ExpectedInspect: true,
+			ExpectedState:   metal3v1alpha1.StateReady,
+		},
+		{
+			Scenario:      "image requested",
+			Image:         &metal3v1alpha1.Image{URL: "http://example.test/image"},
+			ExpectedState: metal3v1alpha1.StateProvisioning,
+		},
+		{
+			Scenario:      "custom deploy requested",
+			CustomDeploy:  &metal3v1alpha1.CustomDeploy{Method: "install_everything"},
+			ExpectedState: metal3v1alpha1.StateProvisioning,
+		},
+		{
+			Scenario:      "provisioning settings changed",
+			Dirty:         true,
+			ExpectedState: metal3v1alpha1.StatePreparing,
+		},
+	} {
+		t.Run(tc.Scenario, func(t *testing.T) {
+			host := newDefaultHost(t)
+			host.Spec.Online = true
+			host.Status.Provisioning.State = metal3v1alpha1.StateReady
+			host.Spec.Reinspection = &metal3v1alpha1.ReinspectionPolicy{Interval: metav1.Duration{Duration: time.Hour}}
+			host.Status.OperationHistory.Inspect.End = metav1.NewTime(time.Now().Add(-2 * time.Hour))
+			if !tc.Dirty {
+				if _, err := saveHostProvisioningSettings(host); err != nil {
+					t.Fatal(err)
+				}
+			}
+			host.Spec.Image = tc.Image
+			host.Spec.CustomDeploy = tc.CustomDeploy
+
+			r := newTestReconciler(host)
+			hsm := newHostStateMachine(host, r, newMockProvisioner(), true)
+			info := makeReconcileInfo(host)
+
+			hsm.handleReady(info)
+
+			assert.Equal(t, tc.ExpectedInspect, hasInspectAnnotation(host))
+			assert.Equal(t, tc.ExpectedState, hsm.NextState)
+		})
+	}
+}
+
+func TestRecordHardwareDrift(t *testing.T) {
+	host := newDefaultHost(t)
+	info := makeReconcileInfo(host)
+	details := &metal3v1alpha1.HardwareDetails{
+		RAMMebibytes: 16384,
+		NIC:          []metal3v1alpha1.NIC{{Name: "eth0", MAC: "00:00:00:00:00:01"}},
+	}
+
+	recordHardwareDrift(info, details)
+	assert.Nil(t, host.Status.HardwareDrift, "first inspection")
+	assert.Empty(t, info.events)
+
+	host.Status.HardwareDetails = details.DeepCopy()
+	recordHardwareDrift(info, details)
+	assert.Nil(t, host.Status.HardwareDrift, "no change")
+
+	changed := details.DeepCopy()
+	changed.RAMMebibytes = 8192
+	changed.NIC = nil
+	recordHardwareDrift(info, changed)
+	if assert.NotNil(t, host.Status.HardwareDrift) {
+		assert.Equal(t, []metal3v1alpha1.HardwareChange{
+			{Component: metal3v1alpha1.HardwareComponentNIC, Type: metal3v1alpha1.HardwareRemoved, Name: "00:00:00:00:00:01"},
+			{Component: metal3v1alpha1.HardwareComponentRAM, Type: metal3v1alpha1.HardwareChanged,
+				Field: "ramMebibytes", Old: "16384", New: "8192"},
+		}, host.Status.HardwareDrift.Changes)
+	}
+	if assert.Len(t, info.events, 2) {
+		assert.Equal(t, "HardwareChanged", info.events[0].Reason)
+		assert.Equal(t, "nic 00:00:00:00:00:01 removed", info.events[0].Message)
+		assert.Equal(t, `ram ramMebibytes changed from "16384" to "8192"`, info.events[1].Message)
+	}
+}
@@ -35,10 +35,13 @@ import (
 	metal3iov1alpha1 "github.com/shweta50/baremetal-operator/apis/metal3.io/v1alpha1"
 	controllers "github.com/shweta50/baremetal-operator/controllers/metal3.io"
 	metal3iocontroller "github.com/shweta50/baremetal-operator/controllers/metal3.io"
+	"github.com/shweta50/baremetal-operator/pkg/bmc"
 	"github.com/shweta50/baremetal-operator/pkg/provisioner"
 	"github.com/shweta50/baremetal-operator/pkg/provisioner/demo"
 	"github.com/shweta50/baremetal-operator/pkg/provisioner/fixture"
 	"github.com/shweta50/baremetal-operator/pkg/provisioner/ironic"
+	"github.com/shweta50/baremetal-operator/pkg/provisioner/networkswitch"
+	"github.com/shweta50/baremetal-operator/pkg/provisioner/redfish"
 	"github.com/shweta50/baremetal-operator/pkg/version"
 	// +kubebuilder:scaffold:imports
 )
@@ -90,6 +93,7 @@ func main() {
 	var devLogging bool
 	var runInTestMode bool
 	var runInDemoMode bool
+	var provisionerName string
 	var webhookPort int
 
 	// From CAPI point of view, BMO should be able to watch all namespaces
@@ -107,6 +111,8 @@ func main() {
 	flag.BoolVar(&runInTestMode, "test-mode", false, "disable ironic communication")
 	flag.BoolVar(&runInDemoMode, "demo-mode", false,
 		"use the demo provisioner to set host states")
+	flag.StringVar(&provisionerName, "provisioner", "ironic",
+		"the provisioner used to manage hosts, either ironic or redfish")
 	flag.StringVar(&healthAddr, "health-addr", ":9440",
 		"The address the health endpoint binds to.")
 	flag.IntVar(&webhookPort, "webhook-port", 9443,
@@ -155,19 +161,54 @@ func main() {
 		ctrl.Log.Info("using demo provisioner")
 		provisionerFactory = &demo.Demo{}
 	} else {
-		provisionerFactory = ironic.NewProvisionerFactory()
+		switch provisionerName {
+		case "ironic":
+			provisionerFactory = ironic.NewProvisionerFactory()
+		case "redfish":
+			ctrl.Log.Info("using redfish provisioner")
+			provisionerFactory = redfish.NewProvisionerFactory()
+		default:
+			setupLog.Error(fmt.Errorf("unknown provisioner %q", provisionerName),
+				"unable to create provisioner")
+			os.Exit(1)
+		}
+	}
+
+	credentialsProvider, err := bmc.NewCredentialsProviderFromEnv(mgr.GetClient(), mgr.GetAPIReader())
+	if err != nil {
+		setupLog.Error(err, "unable to configure BMC credentials provider")
+		os.Exit(1)
+	}
+
+	networkSwitcher, err := networkswitch.NewFromEnv()
+	if err != nil {
+		setupLog.Error(err, "unable to configure network switcher")
+		os.Exit(1)
 	}
 
 	if err = (&metal3iocontroller.BareMetalHostReconciler{
-		Client:             mgr.GetClient(),
-		Log:                ctrl.Log.WithName("controllers").WithName("BareMetalHost"),
-		ProvisionerFactory: provisionerFactory,
-		APIReader:          mgr.GetAPIReader(),
+		Client:              mgr.GetClient(),
+		Log:                 ctrl.Log.WithName("controllers").WithName("BareMetalHost"),
+		ProvisionerFactory:  provisionerFactory,
+		APIReader:           mgr.GetAPIReader(),
+		CredentialsProvider: credentialsProvider,
+		NetworkSwitcher:     networkSwitcher,
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "BareMetalHost")
 		os.Exit(1)
 	}
 
+	if ruleManager, ok := provisionerFactory.(provisioner.InspectionRuleManager); ok {
+		if err = (&metal3iocontroller.InspectionRuleReconciler{
+			Client:      mgr.GetClient(),
+			Log:         ctrl.Log.WithName("controllers").WithName("InspectionRule"),
+			RuleManager: ruleManager,
+		}).SetupWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create controller", "controller", "InspectionRule")
+			os.Exit(1)
+		}
+	}
+
 	setupChecks(mgr)
 	
 	if enableWebhook {
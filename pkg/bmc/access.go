@@ -62,6 +62,10 @@ type AccessDetails interface {
 	RAIDInterface() string
 	VendorInterface() string
 
+	// The inspect interface collecting the hardware details through
+	// the BMC, without booting the host. Empty when the driver cannot.
+	OutOfBandInspectInterface() string
+
 	// Whether the driver supports changing secure boot state.
 	SupportsSecureBoot() bool
 
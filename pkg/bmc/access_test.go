@@ -444,6 +444,7 @@ func TestStaticDriverInfo(t *testing.T) {
 		power      string
 		raid       string
 		vendor     string
+		inspect    string
 	}{
 		{
 			Scenario:   "ipmi",
@@ -503,6 +504,7 @@ func TestStaticDriverInfo(t *testing.T) {
 			power:      "",
 			raid:       "no-raid",
 			vendor:     "",
+			inspect:    "redfish",
 		},
 
 		{
@@ -515,6 +517,7 @@ func TestStaticDriverInfo(t *testing.T) {
 			power:      "",
 			raid:       "no-raid",
 			vendor:     "",
+			inspect:    "redfish",
 		},
 
 		{
@@ -527,6 +530,7 @@ func TestStaticDriverInfo(t *testing.T) {
 			power:      "",
 			raid:       "no-raid",
 			vendor:     "",
+			inspect:    "redfish",
 		},
 
 		{
@@ -539,6 +543,7 @@ func TestStaticDriverInfo(t *testing.T) {
 			power:      "",
 			raid:       "no-raid",
 			vendor:     "",
+			inspect:    "redfish",
 		},
 
 		{
@@ -551,6 +556,7 @@ func TestStaticDriverInfo(t *testing.T) {
 			power:      "idrac-redfish",
 			raid:       "no-raid",
 			vendor:     "no-vendor",
+			inspect:    "idrac-redfish",
 		},
 
 		{
@@ -559,6 +565,7 @@ func TestStaticDriverInfo(t *testing.T) {
 			needsMac: true,
 			driver:   "redfish",
 			boot:     "redfish-virtual-media",
+			inspect:  "redfish",
 		},
 
 		{
@@ -567,6 +574,7 @@ func TestStaticDriverInfo(t *testing.T) {
 			needsMac: true,
 			driver:   "redfish",
 			boot:     "redfish-virtual-media",
+			inspect:  "redfish",
 		},
 
 		{
@@ -575,6 +583,7 @@ func TestStaticDriverInfo(t *testing.T) {
 			needsMac: true,
 			driver:   "redfish",
 			boot:     "redfish-virtual-media",
+			inspect:  "redfish",
 		},
 
 		{
@@ -587,6 +596,7 @@ func TestStaticDriverInfo(t *testing.T) {
 			power:      "idrac-redfish",
 			raid:       "no-raid",
 			vendor:     "no-vendor",
+			inspect:    "idrac-redfish",
 		},
 
 		{
@@ -599,6 +609,7 @@ func TestStaticDriverInfo(t *testing.T) {
 			power:      "idrac-redfish",
 			raid:       "no-raid",
 			vendor:     "no-vendor",
+			inspect:    "idrac-redfish",
 		},
 
 		{
@@ -611,6 +622,7 @@ func TestStaticDriverInfo(t *testing.T) {
 			power:      "idrac-redfish",
 			raid:       "no-raid",
 			vendor:     "no-vendor",
+			inspect:    "idrac-redfish",
 		},
 
 		{
@@ -664,6 +676,10 @@ func TestStaticDriverInfo(t *testing.T) {
 				t.Fatalf("Unexpected boot interface %q, expected %q",
 					acc.BootInterface(), tc.boot)
 			}
+			if acc.OutOfBandInspectInterface() != tc.inspect {
+				t.Fatalf("Unexpected out-of-band inspect interface %q, expected %q",
+					acc.OutOfBandInspectInterface(), tc.inspect)
+			}
 		})
 	}
 }
@@ -700,6 +716,37 @@ func TestDriverInfo(t *testing.T) {
 			},
 		},
 
+		{
+			Scenario: "ipmi cipher suite and protocol version",
+			input:    "ipmi://192.168.122.1?ciphersuite=17&protocolversion=2.0&privilegelevel=user",
+			expects: map[string]interface{}{
+				"ipmi_port":             ipmiDefaultPort,
+				"ipmi_password":         "",
+				"ipmi_username":         "",
+				"ipmi_address":          "192.168.122.1",
+				"ipmi_verify_ca":        false,
+				"ipmi_priv_level":       "USER",
+				"ipmi_cipher_suite":     "17",
+				"ipmi_protocol_version": "2.0",
+			},
+		},
+
+		{
+			Scenario: "ipmi single bridging",
+			input:    "ipmi://192.168.122.1:6230?bridging=single&targetchannel=7&targetaddress=0x72",
+			expects: map[string]interface{}{
+				"ipmi_port":           "6230",
+				"ipmi_password":       "",
+				"ipmi_username":       "",
+				"ipmi_address":        "192.168.122.1",
+				"ipmi_verify_ca":      false,
+				"ipmi_priv_level":     "ADMINISTRATOR",
+				"ipmi_bridging":       "single",
+				"ipmi_target_channel": "7",
+				"ipmi_target_address": "0x72",
+			},
+		},
+
 		{
 			Scenario: "idrac",
 			input:    "idrac://192.168.122.1",
@@ -1132,6 +1179,81 @@ func TestDriverInfo(t *testing.T) {
 	}
 }
 
+func TestIPMIOptionErrors(t *testing.T) {
+	for _, tc := range []struct {
+		Scenario string
+		input    string
+	}{
+		{
+			Scenario: "unknown option",
+			input:    "ipmi://192.168.122.1?privlevel=OPERATOR",
+		},
+		{
+			Scenario: "invalid privilege level",
+			input:    "ipmi://192.168.122.1?privilegelevel=ROOT",
+		},
+		{
+			Scenario: "invalid cipher suite",
+			input:    "ipmi://192.168.122.1?ciphersuite=18",
+		},
+		{
+			Scenario: "invalid protocol version",
+			input:    "ipmi://192.168.122.1?protocolversion=3",
+		},
+		{
+			Scenario: "repeated option",
+			input:    "ipmi://192.168.122.1?ciphersuite=3&ciphersuite=17",
+		},
+		{
+			Scenario: "invalid address",
+			input:    "ipmi://192.168.122.1?bridging=single&targetchannel=7&targetaddress=0x100",
+		},
+		{
+			Scenario: "binary address",
+			input:    "ipmi://192.168.122.1?bridging=single&targetchannel=7&targetaddress=0b101",
+		},
+		{
+			Scenario: "octal address",
+			input:    "ipmi://192.168.122.1?bridging=single&targetchannel=7&targetaddress=0o17",
+		},
+		{
+			Scenario: "address with a leading zero",
+			input:    "ipmi://192.168.122.1?bridging=single&targetchannel=7&targetaddress=010",
+		},
+		{
+			Scenario: "address with an underscore",
+			input:    "ipmi://192.168.122.1?bridging=single&targetchannel=7&targetaddress=1_0",
+		},
+		{
+			Scenario: "hexadecimal address with an underscore",
+			input:    "ipmi://192.168.122.1?bridging=single&targetchannel=7&targetaddress=0x1_0",
+		},
+		{
+			Scenario: "address with a sign",
+			input:    "ipmi://192.168.122.1?bridging=single&targetchannel=7&targetaddress=%2B10",
+		},
+		{
+			Scenario: "empty hexadecimal address",
+			input:    "ipmi://192.168.122.1?bridging=single&targetchannel=7&targetaddress=0x",
+		},
+		{
+			Scenario: "dual bridging without transit",
+			input:    "ipmi://192.168.122.1?bridging=dual&targetchannel=7&targetaddress=0x72",
+		},
+		{
+			Scenario: "libvirt unknown option",
+			input:    "libvirt://192.168.122.1:6233/?abc=def",
+		},
+	} {
+		t.Run(tc.Scenario, func(t *testing.T) {
+			_, err := NewAccessDetails(tc.input, true)
+			if _, ok := err.(*InvalidBMCOptionError); !ok {
+				t.Fatalf("expected InvalidBMCOptionError, got %v", err)
+			}
+		})
+	}
+}
+
 func TestUnknownType(t *testing.T) {
 	acc, err := NewAccessDetails("foo://192.168.122.1", false)
 	if err == nil || acc != nil {
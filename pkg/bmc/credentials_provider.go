@@ -0,0 +1,307 @@
+package bmc
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// Names of the supported credentials providers.
+const (
+	SecretCredentialsProviderName = "secret"
+	FileCredentialsProviderName   = "file"
+	VaultCredentialsProviderName  = "vault"
+
+	vaultHTTPTimeout = time.Second * 30
+)
+
+// CredentialsProvider looks up the credentials for a BMC. The
+// credentials are referenced by the namespace of the host and the
+// name in its spec.bmc.credentialsName field.
+type CredentialsProvider interface {
+	// GetCredentials returns the credentials stored under the
+	// reference, along with a version string that changes whenever
+	// the credentials do.
+	GetCredentials(ref types.NamespacedName) (creds *Credentials, version string, err error)
+}
+
+// CredentialsNotFoundError is returned by a CredentialsProvider when
+// there are no credentials stored under the reference.
+type CredentialsNotFoundError struct {
+	Reference types.NamespacedName
+	Provider  string
+}
+
+func (e CredentialsNotFoundError) Error() string {
+	return fmt.Sprintf("No BMC credentials %s found in %s provider",
+		e.Reference, e.Provider)
+}
+
+// CredentialsFromSecret builds Credentials from the "username" and
+// "password" keys of a Secret.
+func CredentialsFromSecret(secret *corev1.Secret) *Credentials {
+	return credentialsFromData(secret.Data["username"], secret.Data["password"])
+}
+
+func credentialsFromData(username, password []byte) *Credentials {
+	// We trim surrounding whitespace because those characters are
+	// unlikely to be part of the username or password and it is
+	// common for users to encode the values with a command like
+	//
+	//     echo "my-password" | base64
+	//
+	// which introduces a trailing newline.
+	return &Credentials{
+		Username: strings.TrimSpace(string(username)),
+		Password: strings.TrimSpace(string(password)),
+	}
+}
+
+// SecretCredentialsProvider reads credentials from Kubernetes Secrets
+// in the namespace of the host. This is the default provider.
+type SecretCredentialsProvider struct {
+	// Client is used first, and is expected to be backed by a cache
+	// that only holds the Secrets labelled for use by the operator.
+	Client client.Reader
+	// APIReader is used for Secrets that are not in the cache yet.
+	APIReader client.Reader
+}
+
+// GetSecret returns the Secret stored under the reference. If the
+// Secret is not found in the filtered cache, then it is retrieved
+// directly from the API.
+func (p *SecretCredentialsProvider) GetSecret(ref types.NamespacedName) (*corev1.Secret, error) {
+	secret := &corev1.Secret{}
+
+	err := p.Client.Get(context.TODO(), ref, secret)
+	if err == nil {
+		return secret, nil
+	}
+	if !k8serrors.IsNotFound(err) {
+		return nil, err
+	}
+
+	// Secret not in cache; check API directly for unlabelled Secret
+	if err = p.APIReader.Get(context.TODO(), ref, secret); err != nil {
+		return nil, err
+	}
+	return secret, nil
+}
+
+// GetCredentials returns the credentials from the Secret and its
+// resource version.
+func (p *SecretCredentialsProvider) GetCredentials(ref types.NamespacedName) (*Credentials, string, error) {
+	secret, err := p.GetSecret(ref)
+	if err != nil {
+		if k8serrors.IsNotFound(err) {
+			return nil, "", &CredentialsNotFoundError{Reference: ref, Provider: SecretCredentialsProviderName}
+		}
+		return nil, "", err
+	}
+	return CredentialsFromSecret(secret), secret.ResourceVersion, nil
+}
+
+// FileCredentialsProvider reads credentials from files, such as the
+// ones mounted by the Secrets Store CSI driver. The credentials for a
+// reference are read from the "username" and "password" files in the
+// <Directory>/<namespace>/<name> directory.
+type FileCredentialsProvider struct {
+	Directory string
+}
+
+// GetCredentials returns the credentials from the files. The version
+// is the target of the "..data" symlink that Kubernetes volumes swap
+// when their content is updated, or else the latest modification time
+// of the files. It is stored in the host status, so it must not be
+// derived from the credentials.
+func (p *FileCredentialsProvider) GetCredentials(ref types.NamespacedName) (*Credentials, string, error) {
+	dir := filepath.Join(p.Directory, filepath.Base(ref.Namespace), filepath.Base(ref.Name))
+
+	read := func(key string) ([]byte, error) {
+		content, err := ioutil.ReadFile(filepath.Join(dir, key)) // #nosec
+		if os.IsNotExist(err) {
+			return nil, &CredentialsNotFoundError{Reference: ref, Provider: FileCredentialsProviderName}
+		}
+		return content, err
+	}
+
+	username, err := read("username")
+	if err != nil {
+		return nil, "", err
+	}
+	password, err := read("password")
+	if err != nil {
+		return nil, "", err
+	}
+
+	version, err := filesVersion(dir, "username", "password")
+	if err != nil {
+		return nil, "", err
+	}
+	return credentialsFromData(username, password), version, nil
+}
+
+func filesVersion(dir string, files ...string) (string, error) {
+	if target, err := os.Readlink(filepath.Join(dir, "..data")); err == nil {
+		return target, nil
+	}
+
+	var latest time.Time
+	for _, file := range files {
+		info, err := os.Stat(filepath.Join(dir, file))
+		if err != nil {
+			return "", err
+		}
+		if info.ModTime().After(latest) {
+			latest = info.ModTime()
+		}
+	}
+	return strconv.FormatInt(latest.UnixNano(), 10), nil
+}
+
+// VaultCredentialsProvider reads credentials from a HashiCorp Vault KV
+// version 2 secrets engine. The credentials for a reference are read
+// from the "username" and "password" keys of the
+// <PathPrefix>/<namespace>/<name> secret.
+type VaultCredentialsProvider struct {
+	// Address of the Vault server, e.g. https://vault.example:8200
+	Address string
+	// Token used to authenticate with Vault.
+	Token string
+	// TokenFile is read for the token on every request when Token is
+	// empty, so that a token renewed by an agent sidecar is picked up.
+	TokenFile string
+	// Mount is the path the KV engine is mounted at.
+	Mount string
+	// PathPrefix is prepended to the path of every secret.
+	PathPrefix string
+	// HTTPClient is used to talk to Vault, defaults to a client with
+	// a 30 second timeout.
+	HTTPClient *http.Client
+}
+
+type vaultKVResponse struct {
+	Data struct {
+		Data     map[string]string `json:"data"`
+		Metadata struct {
+			Version int `json:"version"`
+		} `json:"metadata"`
+	} `json:"data"`
+}
+
+func (p *VaultCredentialsProvider) token() (string, error) {
+	if p.Token != "" || p.TokenFile == "" {
+		return p.Token, nil
+	}
+	content, err := ioutil.ReadFile(p.TokenFile)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to read Vault token")
+	}
+	return strings.TrimSpace(string(content)), nil
+}
+
+// GetCredentials returns the credentials from Vault, using the KV
+// version of the secret as the version.
+func (p *VaultCredentialsProvider) GetCredentials(ref types.NamespacedName) (*Credentials, string, error) {
+	path := strings.Trim(strings.Join([]string{p.PathPrefix, ref.Namespace, ref.Name}, "/"), "/")
+	url := fmt.Sprintf("%s/v1/%s/data/%s",
+		strings.TrimRight(p.Address, "/"), strings.Trim(p.Mount, "/"), path)
+
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, "", err
+	}
+	token, err := p.token()
+	if err != nil {
+		return nil, "", err
+	}
+	req.Header.Set("X-Vault-Token", token)
+
+	httpClient := p.HTTPClient
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: vaultHTTPTimeout}
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, "", errors.Wrap(err, "failed to read BMC credentials from Vault")
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return nil, "", &CredentialsNotFoundError{Reference: ref, Provider: VaultCredentialsProviderName}
+	default:
+		return nil, "", fmt.Errorf("failed to read BMC credentials from Vault: GET %s returned %s",
+			url, resp.Status)
+	}
+
+	secret := vaultKVResponse{}
+	if err = json.NewDecoder(resp.Body).Decode(&secret); err != nil {
+		return nil, "", errors.Wrap(err, "failed to decode Vault response")
+	}
+	creds := credentialsFromData(
+		[]byte(secret.Data.Data["username"]), []byte(secret.Data.Data["password"]))
+	return creds, strconv.Itoa(secret.Data.Metadata.Version), nil
+}
+
+// NewCredentialsProviderFromEnv returns the CredentialsProvider
+// selected by the BMC_CREDENTIALS_PROVIDER environment variable. The
+// secret provider is built from the Kubernetes readers.
+func NewCredentialsProviderFromEnv(cachedReader, apiReader client.Reader) (CredentialsProvider, error) {
+	switch name := os.Getenv("BMC_CREDENTIALS_PROVIDER"); name {
+	case "", SecretCredentialsProviderName:
+		return &SecretCredentialsProvider{Client: cachedReader, APIReader: apiReader}, nil
+
+	case FileCredentialsProviderName:
+		dir := os.Getenv("BMC_CREDENTIALS_DIR")
+		if dir == "" {
+			return nil, errors.New("No BMC_CREDENTIALS_DIR variable set")
+		}
+		return &FileCredentialsProvider{Directory: dir}, nil
+
+	case VaultCredentialsProviderName:
+		provider := &VaultCredentialsProvider{
+			Address:    os.Getenv("VAULT_ADDR"),
+			Token:      os.Getenv("VAULT_TOKEN"),
+			TokenFile:  os.Getenv("VAULT_TOKEN_FILE"),
+			Mount:      os.Getenv("VAULT_KV_MOUNT"),
+			PathPrefix: os.Getenv("VAULT_KV_PATH_PREFIX"),
+		}
+		if provider.Address == "" {
+			return nil, errors.New("No VAULT_ADDR variable set")
+		}
+		if provider.Token == "" && provider.TokenFile == "" {
+			return nil, errors.New("Either VAULT_TOKEN or VAULT_TOKEN_FILE must be set")
+		}
+		if provider.Mount == "" {
+			provider.Mount = "secret"
+		}
+		if provider.PathPrefix == "" {
+			provider.PathPrefix = "baremetal"
+		}
+		if strings.ToLower(os.Getenv("VAULT_SKIP_VERIFY")) == "true" {
+			transport := http.DefaultTransport.(*http.Transport).Clone()
+			transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: true} // #nosec
+			provider.HTTPClient = &http.Client{Transport: transport, Timeout: vaultHTTPTimeout}
+		}
+		return provider, nil
+
+	default:
+		return nil, fmt.Errorf("Unknown BMC_CREDENTIALS_PROVIDER %q", name)
+	}
+}
@@ -0,0 +1,253 @@
+package bmc
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	fakeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+var testCredentialsRef = types.NamespacedName{Namespace: "metal3", Name: "node-0-bmc-secret"}
+
+func TestSecretCredentialsProvider(t *testing.T) {
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      testCredentialsRef.Name,
+			Namespace: testCredentialsRef.Namespace,
+		},
+		Data: map[string][]byte{
+			"username": []byte("admin\n"),
+			"password": []byte("password\n"),
+		},
+	}
+	cache := fakeclient.NewFakeClient()
+	api := fakeclient.NewFakeClient(secret)
+
+	// The secret is only found through the API reader.
+	provider := &SecretCredentialsProvider{Client: cache, APIReader: api}
+	creds, version, err := provider.GetCredentials(testCredentialsRef)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if *creds != (Credentials{Username: "admin", Password: "password"}) {
+		t.Errorf("unexpected credentials %v", creds)
+	}
+	if version == "" {
+		t.Error("expected a resource version")
+	}
+
+	provider = &SecretCredentialsProvider{Client: cache, APIReader: cache}
+	_, _, err = provider.GetCredentials(testCredentialsRef)
+	if _, ok := err.(*CredentialsNotFoundError); !ok {
+		t.Errorf("expected CredentialsNotFoundError, got %v", err)
+	}
+}
+
+func TestFileCredentialsProvider(t *testing.T) {
+	dir, err := ioutil.TempDir("", "bmc-credentials")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	provider := &FileCredentialsProvider{Directory: dir}
+	_, _, err = provider.GetCredentials(testCredentialsRef)
+	if _, ok := err.(*CredentialsNotFoundError); !ok {
+		t.Fatalf("expected CredentialsNotFoundError, got %v", err)
+	}
+
+	secretDir := filepath.Join(dir, testCredentialsRef.Namespace, testCredentialsRef.Name)
+	write := func(key, value string) {
+		if err := os.MkdirAll(secretDir, 0700); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(filepath.Join(secretDir, key), []byte(value), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("username", "admin\n")
+	write("password", "password\n")
+
+	creds, version, err := provider.GetCredentials(testCredentialsRef)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if *creds != (Credentials{Username: "admin", Password: "password"}) {
+		t.Errorf("unexpected credentials %v", creds)
+	}
+
+	if strings.Contains(version, "password") {
+		t.Errorf("version %q reveals the credentials", version)
+	}
+
+	write("password", "new-password")
+	later := time.Now().Add(time.Minute)
+	if err := os.Chtimes(filepath.Join(secretDir, "password"), later, later); err != nil {
+		t.Fatal(err)
+	}
+	_, newVersion, err := provider.GetCredentials(testCredentialsRef)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if newVersion == version {
+		t.Error("expected the version to change with the password")
+	}
+
+	// Kubernetes volumes point ..data to the directory of the current
+	// content
+	if err := os.Symlink("..2021_01_01_00_00_00.123456789", filepath.Join(secretDir, "..data")); err != nil {
+		t.Fatal(err)
+	}
+	_, version, err = provider.GetCredentials(testCredentialsRef)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if version != "..2021_01_01_00_00_00.123456789" {
+		t.Errorf("expected the version from the ..data symlink, got %q", version)
+	}
+}
+
+func TestVaultCredentialsProvider(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Vault-Token") != "s.token" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		if r.URL.Path != "/v1/kv/data/baremetal/metal3/node-0-bmc-secret" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"data": map[string]interface{}{
+				"data": map[string]string{
+					"username": "admin",
+					"password": "password",
+				},
+				"metadata": map[string]interface{}{
+					"version": 3,
+				},
+			},
+		})
+	}))
+	defer server.Close()
+
+	provider := &VaultCredentialsProvider{
+		Address:    server.URL,
+		Token:      "s.token",
+		Mount:      "kv",
+		PathPrefix: "baremetal",
+	}
+	creds, version, err := provider.GetCredentials(testCredentialsRef)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if *creds != (Credentials{Username: "admin", Password: "password"}) {
+		t.Errorf("unexpected credentials %v", creds)
+	}
+	if version != "3" {
+		t.Errorf("expected version 3, got %q", version)
+	}
+
+	_, _, err = provider.GetCredentials(types.NamespacedName{Namespace: "metal3", Name: "missing"})
+	if _, ok := err.(*CredentialsNotFoundError); !ok {
+		t.Errorf("expected CredentialsNotFoundError, got %v", err)
+	}
+
+	provider.Token = "s.wrong"
+	if _, _, err = provider.GetCredentials(testCredentialsRef); err == nil {
+		t.Error("expected an error with a bad token")
+	}
+}
+
+func TestNewCredentialsProviderFromEnv(t *testing.T) {
+	for _, tc := range []struct {
+		Scenario    string
+		Env         map[string]string
+		ExpectError bool
+		Check       func(CredentialsProvider) bool
+	}{
+		{
+			Scenario: "default",
+			Check: func(p CredentialsProvider) bool {
+				_, ok := p.(*SecretCredentialsProvider)
+				return ok
+			},
+		},
+		{
+			Scenario:    "file without directory",
+			Env:         map[string]string{"BMC_CREDENTIALS_PROVIDER": "file"},
+			ExpectError: true,
+		},
+		{
+			Scenario: "file",
+			Env: map[string]string{
+				"BMC_CREDENTIALS_PROVIDER": "file",
+				"BMC_CREDENTIALS_DIR":      "/etc/bmc-credentials",
+			},
+			Check: func(p CredentialsProvider) bool {
+				fp, ok := p.(*FileCredentialsProvider)
+				return ok && fp.Directory == "/etc/bmc-credentials"
+			},
+		},
+		{
+			Scenario: "vault defaults",
+			Env: map[string]string{
+				"BMC_CREDENTIALS_PROVIDER": "vault",
+				"VAULT_ADDR":               "https://vault.example:8200",
+				"VAULT_TOKEN_FILE":         "/var/run/secrets/vault/token",
+			},
+			Check: func(p CredentialsProvider) bool {
+				vp, ok := p.(*VaultCredentialsProvider)
+				return ok && vp.Mount == "secret" && vp.PathPrefix == "baremetal"
+			},
+		},
+		{
+			Scenario: "vault without token",
+			Env: map[string]string{
+				"BMC_CREDENTIALS_PROVIDER": "vault",
+				"VAULT_ADDR":               "https://vault.example:8200",
+			},
+			ExpectError: true,
+		},
+		{
+			Scenario:    "unknown",
+			Env:         map[string]string{"BMC_CREDENTIALS_PROVIDER": "etcd"},
+			ExpectError: true,
+		},
+	} {
+		t.Run(tc.Scenario, func(t *testing.T) {
+			for _, name := range []string{"BMC_CREDENTIALS_PROVIDER", "BMC_CREDENTIALS_DIR",
+				"VAULT_ADDR", "VAULT_TOKEN", "VAULT_TOKEN_FILE", "VAULT_KV_MOUNT", "VAULT_KV_PATH_PREFIX"} {
+				os.Unsetenv(name)
+			}
+			for k, v := range tc.Env {
+				os.Setenv(k, v)
+				defer os.Unsetenv(k)
+			}
+
+			provider, err := NewCredentialsProviderFromEnv(fakeclient.NewFakeClient(), nil)
+			if tc.ExpectError {
+				if err == nil {
+					t.Error("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !tc.Check(provider) {
+				t.Errorf("unexpected provider %#v", provider)
+			}
+		})
+	}
+}
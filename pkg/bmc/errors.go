@@ -16,6 +16,18 @@ func (e UnknownBMCTypeError) Error() string {
 		e.bmcType, e.address)
 }
 
+// InvalidBMCOptionError is returned when an option given in the BMC
+// address is unknown or has an invalid value.
+type InvalidBMCOptionError struct {
+	option  string
+	message string
+}
+
+func (e InvalidBMCOptionError) Error() string {
+	return fmt.Sprintf("Invalid BMC address option '%s': %s",
+		e.option, e.message)
+}
+
 // CredentialsValidationError is returned when the provided BMC credentials
 // are invalid (e.g. null)
 type CredentialsValidationError struct {
@@ -26,3 +38,15 @@ func (e CredentialsValidationError) Error() string {
 	return fmt.Sprintf("Validation error with BMC credentials: %s",
 		e.message)
 }
+
+// UnsupportedPasswordRotationError is returned when the BMC type does
+// not support changing the account password.
+type UnsupportedPasswordRotationError struct {
+	address string
+	bmcType string
+}
+
+func (e UnsupportedPasswordRotationError) Error() string {
+	return fmt.Sprintf("Password rotation is not supported for BMC type '%s' at address %s",
+		e.bmcType, e.address)
+}
@@ -101,6 +101,10 @@ func (a *ibmcAccessDetails) VendorInterface() string {
 	return ""
 }
 
+func (a *ibmcAccessDetails) OutOfBandInspectInterface() string {
+	return ""
+}
+
 func (a *ibmcAccessDetails) SupportsSecureBoot() bool {
 	return false
 }
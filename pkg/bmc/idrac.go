@@ -96,6 +96,10 @@ func (a *iDracAccessDetails) VendorInterface() string {
 	return ""
 }
 
+func (a *iDracAccessDetails) OutOfBandInspectInterface() string {
+	return ""
+}
+
 // NOTE(dtantsur): change to true if we switch to redfish-based implementations
 // by default.
 func (a *iDracAccessDetails) SupportsSecureBoot() bool {
@@ -90,6 +90,10 @@ func (a *redfishiDracVirtualMediaAccessDetails) VendorInterface() string {
 	return "no-vendor"
 }
 
+func (a *redfishiDracVirtualMediaAccessDetails) OutOfBandInspectInterface() string {
+	return "idrac-redfish"
+}
+
 func (a *redfishiDracVirtualMediaAccessDetails) SupportsSecureBoot() bool {
 	return true
 }
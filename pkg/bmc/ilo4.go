@@ -92,6 +92,10 @@ func (a *iLOAccessDetails) VendorInterface() string {
 	return ""
 }
 
+func (a *iLOAccessDetails) OutOfBandInspectInterface() string {
+	return ""
+}
+
 func (a *iLOAccessDetails) SupportsSecureBoot() bool {
 	return true
 }
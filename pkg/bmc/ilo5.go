@@ -92,6 +92,10 @@ func (a *iLO5AccessDetails) VendorInterface() string {
 	return ""
 }
 
+func (a *iLO5AccessDetails) OutOfBandInspectInterface() string {
+	return ""
+}
+
 func (a *iLO5AccessDetails) SupportsSecureBoot() bool {
 	return true
 }
@@ -3,6 +3,10 @@ package bmc
 import (
 	"fmt"
 	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
 
 	metal3v1alpha1 "github.com/shweta50/baremetal-operator/apis/metal3.io/v1alpha1"
 )
@@ -12,24 +16,133 @@ func init() {
 	RegisterFactory("libvirt", newIPMIAccessDetails, []string{})
 }
 
-func getPrivilegeLevel(rawquery string) string {
-	privilegelevel := "ADMINISTRATOR"
+// ipmiOptions maps the query parameters accepted in IPMI addresses to
+// the driver_info fields they set in Ironic.
+var ipmiOptions = map[string]string{
+	"privilegelevel":  "ipmi_priv_level",
+	"ciphersuite":     "ipmi_cipher_suite",
+	"protocolversion": "ipmi_protocol_version",
+	"bridging":        "ipmi_bridging",
+	"localaddress":    "ipmi_local_address",
+	"transitchannel":  "ipmi_transit_channel",
+	"transitaddress":  "ipmi_transit_address",
+	"targetchannel":   "ipmi_target_channel",
+	"targetaddress":   "ipmi_target_address",
+}
+
+const ipmiDefaultPrivilegeLevel = "ADMINISTRATOR"
+
+// parseIPMIByte checks that value is a byte written in decimal without
+// leading zeros, or in hexadecimal after a 0x prefix.
+func parseIPMIByte(value string) bool {
+	digits, base := value, 10
+	if strings.HasPrefix(value, "0x") {
+		digits, base = value[2:], 16
+	} else if len(value) > 1 && value[0] == '0' {
+		return false
+	}
+	_, err := strconv.ParseUint(digits, base, 8)
+	return err == nil
+}
+
+func validateIPMIOption(name, value string) (string, error) {
+	invalid := func(message string) (string, error) {
+		return "", &InvalidBMCOptionError{option: name, message: message}
+	}
+
+	switch name {
+	case "privilegelevel":
+		value = strings.ToUpper(value)
+		switch value {
+		case "ADMINISTRATOR", "OPERATOR", "USER", "CALLBACK":
+		default:
+			return invalid("must be one of ADMINISTRATOR, OPERATOR, USER or CALLBACK")
+		}
+	case "ciphersuite":
+		suite, err := strconv.Atoi(value)
+		if err != nil || suite < 0 || suite > 17 {
+			return invalid("must be a number between 0 and 17")
+		}
+	case "protocolversion":
+		if value != "1.5" && value != "2.0" {
+			return invalid("must be 1.5 or 2.0")
+		}
+	case "bridging":
+		if value != "no" && value != "single" && value != "dual" {
+			return invalid("must be one of no, single or dual")
+		}
+	default:
+		// Addresses and channels are single bytes, written in decimal
+		// or with a 0x prefix in hexadecimal. Other forms, such as a
+		// leading zero, are refused since Ironic and ipmitool would
+		// not read them the same way.
+		if !parseIPMIByte(value) {
+			return invalid("must be a number between 0 and 0xff")
+		}
+	}
+	return value, nil
+}
+
+// getIPMIOptions returns the driver_info fields set by the query
+// parameters of an IPMI address. Unknown parameters are rejected
+// rather than ignored, so that typos do not go unnoticed.
+func getIPMIOptions(rawquery string) (map[string]string, error) {
 	q, err := url.ParseQuery(rawquery)
 	if err != nil {
-		return privilegelevel
+		return nil, errors.Wrap(err, "failed to parse BMC address options")
+	}
+
+	options := map[string]string{
+		"ipmi_priv_level": ipmiDefaultPrivilegeLevel,
+	}
+	for name, values := range q {
+		field, ok := ipmiOptions[name]
+		if !ok {
+			return nil, &InvalidBMCOptionError{option: name, message: "unknown option"}
+		}
+		if len(values) != 1 {
+			return nil, &InvalidBMCOptionError{option: name, message: "must be set only once"}
+		}
+		value, err := validateIPMIOption(name, values[0])
+		if err != nil {
+			return nil, err
+		}
+		options[field] = value
 	}
-	if val, ok := q["privilegelevel"]; ok {
-		return val[0]
+
+	required := []string{}
+	switch options["ipmi_bridging"] {
+	case "single":
+		required = []string{"targetchannel", "targetaddress"}
+	case "dual":
+		required = []string{"targetchannel", "targetaddress", "transitchannel", "transitaddress"}
+	}
+	for _, name := range required {
+		if _, ok := options[ipmiOptions[name]]; !ok {
+			return nil, &InvalidBMCOptionError{option: name,
+				message: fmt.Sprintf("is required when bridging is %s", options["ipmi_bridging"])}
+		}
 	}
-	return privilegelevel
+
+	return options, nil
 }
 
 func newIPMIAccessDetails(parsedURL *url.URL, disableCertificateVerification bool) (AccessDetails, error) {
+	options, err := getIPMIOptions(parsedURL.RawQuery)
+	if err != nil {
+		return nil, err
+	}
+	if port := parsedURL.Port(); port != "" {
+		if _, err := strconv.ParseUint(port, 10, 16); err != nil {
+			return nil, &InvalidBMCOptionError{option: "port", message: "must be a number between 0 and 65535"}
+		}
+	}
+
 	return &ipmiAccessDetails{
 		bmcType:                        parsedURL.Scheme,
 		portNum:                        parsedURL.Port(),
 		hostname:                       parsedURL.Hostname(),
-		privilegelevel:                 getPrivilegeLevel(parsedURL.RawQuery),
+		options:                        options,
 		disableCertificateVerification: disableCertificateVerification,
 	}, nil
 }
@@ -38,7 +151,7 @@ type ipmiAccessDetails struct {
 	bmcType                        string
 	portNum                        string
 	hostname                       string
-	privilegelevel                 string
+	options                        map[string]string
 	disableCertificateVerification bool
 }
 
@@ -75,11 +188,14 @@ func (a *ipmiAccessDetails) DisableCertificateVerification() bool {
 // the kernel and ramdisk locations).
 func (a *ipmiAccessDetails) DriverInfo(bmcCreds Credentials) map[string]interface{} {
 	result := map[string]interface{}{
-		"ipmi_port":       a.portNum,
-		"ipmi_username":   bmcCreds.Username,
-		"ipmi_password":   bmcCreds.Password,
-		"ipmi_address":    a.hostname,
-		"ipmi_priv_level": a.privilegelevel,
+		"ipmi_port":     a.portNum,
+		"ipmi_username": bmcCreds.Username,
+		"ipmi_password": bmcCreds.Password,
+		"ipmi_address":  a.hostname,
+	}
+
+	for field, value := range a.options {
+		result[field] = value
 	}
 
 	if a.disableCertificateVerification {
@@ -111,6 +227,10 @@ func (a *ipmiAccessDetails) VendorInterface() string {
 	return ""
 }
 
+func (a *ipmiAccessDetails) OutOfBandInspectInterface() string {
+	return ""
+}
+
 func (a *ipmiAccessDetails) SupportsSecureBoot() bool {
 	return false
 }
@@ -90,6 +90,10 @@ func (a *iRMCAccessDetails) VendorInterface() string {
 	return ""
 }
 
+func (a *iRMCAccessDetails) OutOfBandInspectInterface() string {
+	return ""
+}
+
 func (a *iRMCAccessDetails) SupportsSecureBoot() bool {
 	return true
 }
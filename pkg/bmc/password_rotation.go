@@ -0,0 +1,225 @@
+package bmc
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// DefaultPasswordLength is the length of generated passwords when
+	// the rotation policy does not specify one.
+	DefaultPasswordLength = 24
+
+	// DefaultPasswordCharacters is the set of characters generated
+	// passwords are drawn from when the rotation policy does not
+	// specify one.
+	DefaultPasswordCharacters = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+
+	redfishAccountsPath = "/redfish/v1/AccountService/Accounts"
+	redfishSystemsPath  = "/redfish/v1/Systems"
+	redfishHTTPTimeout  = time.Second * 30
+)
+
+// redfishAccountServiceTypes lists the BMC types reached through
+// Redfish, which exposes the AccountService used to change passwords.
+var redfishAccountServiceTypes = map[string]struct{}{
+	"redfish":              {},
+	"ilo5-redfish":         {},
+	"idrac-redfish":        {},
+	"redfish-virtualmedia": {},
+	"ilo5-virtualmedia":    {},
+	"idrac-virtualmedia":   {},
+}
+
+// PasswordRotator changes the password of the account used to talk
+// to a BMC.
+type PasswordRotator interface {
+	// ChangePassword sets the password of the account named by
+	// creds.Username to newPassword, authenticating with creds.
+	ChangePassword(creds Credentials, newPassword string) error
+
+	// VerifyCredentials returns an error if the BMC does not accept
+	// the credentials.
+	VerifyCredentials(creds Credentials) error
+}
+
+// PasswordRotatorFactory describes a callable that returns a new
+// PasswordRotator for the BMC at the given address.
+type PasswordRotatorFactory func(address string, disableCertificateVerification bool) (PasswordRotator, error)
+
+// NewPasswordRotator returns a PasswordRotator talking to the
+// Redfish AccountService of the BMC at address.
+func NewPasswordRotator(address string, disableCertificateVerification bool) (PasswordRotator, error) {
+	parsedURL, err := getParsedURL(address)
+	if err != nil {
+		return nil, err
+	}
+
+	bmcType := strings.Split(parsedURL.Scheme, "+")[0]
+	if _, ok := redfishAccountServiceTypes[bmcType]; !ok {
+		return nil, &UnsupportedPasswordRotationError{address: address, bmcType: parsedURL.Scheme}
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if disableCertificateVerification {
+		transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: true} // #nosec
+	}
+
+	return &redfishPasswordRotator{
+		endpoint: getRedfishAddress(parsedURL.Scheme, parsedURL.Host),
+		client: &http.Client{
+			Transport: transport,
+			Timeout:   redfishHTTPTimeout,
+		},
+	}, nil
+}
+
+// GeneratePassword returns a random password of the given length
+// drawn from characters. Defaults are used for a zero length or an
+// empty set of characters.
+func GeneratePassword(length int, characters string) (string, error) {
+	if length <= 0 {
+		length = DefaultPasswordLength
+	}
+	if characters == "" {
+		characters = DefaultPasswordCharacters
+	}
+
+	chars := []rune(characters)
+	max := big.NewInt(int64(len(chars)))
+	password := make([]rune, length)
+	for i := range password {
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", errors.Wrap(err, "failed to generate password")
+		}
+		password[i] = chars[n.Int64()]
+	}
+	return string(password), nil
+}
+
+type redfishPasswordRotator struct {
+	endpoint string
+	client   *http.Client
+}
+
+type redfishCollection struct {
+	Members []struct {
+		ID string `json:"@odata.id"`
+	} `json:"Members"`
+}
+
+type redfishAccount struct {
+	UserName string `json:"UserName"`
+}
+
+func (r *redfishPasswordRotator) do(creds Credentials, method, path string, body interface{}, headers map[string]string) (*http.Response, error) {
+	var reader *bytes.Reader
+	if body != nil {
+		content, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		reader = bytes.NewReader(content)
+	} else {
+		reader = bytes.NewReader(nil)
+	}
+
+	req, err := http.NewRequest(method, r.endpoint+path, reader)
+	if err != nil {
+		return nil, err
+	}
+	req.SetBasicAuth(creds.Username, creds.Password)
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	return r.client.Do(req)
+}
+
+func (r *redfishPasswordRotator) get(creds Credentials, path string, out interface{}) (etag string, err error) {
+	resp, err := r.do(creds, http.MethodGet, path, nil, nil)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("GET %s returned %s", path, resp.Status)
+	}
+	if out != nil {
+		if err = json.NewDecoder(resp.Body).Decode(out); err != nil {
+			return "", errors.Wrapf(err, "failed to decode response from %s", path)
+		}
+	}
+	return resp.Header.Get("ETag"), nil
+}
+
+// findAccount returns the path and ETag of the account resource
+// matching the username.
+func (r *redfishPasswordRotator) findAccount(creds Credentials) (path, etag string, err error) {
+	accounts := redfishCollection{}
+	if _, err = r.get(creds, redfishAccountsPath, &accounts); err != nil {
+		return "", "", errors.Wrap(err, "failed to list BMC accounts")
+	}
+
+	for _, member := range accounts.Members {
+		account := redfishAccount{}
+		etag, err = r.get(creds, member.ID, &account)
+		if err != nil {
+			return "", "", errors.Wrap(err, "failed to read BMC account")
+		}
+		if account.UserName == creds.Username {
+			return member.ID, etag, nil
+		}
+	}
+	return "", "", fmt.Errorf("no BMC account found for user %q", creds.Username)
+}
+
+// ChangePassword sets the password of the account named by
+// creds.Username to newPassword.
+func (r *redfishPasswordRotator) ChangePassword(creds Credentials, newPassword string) error {
+	path, etag, err := r.findAccount(creds)
+	if err != nil {
+		return err
+	}
+
+	headers := map[string]string{}
+	if etag != "" {
+		headers["If-Match"] = etag
+	}
+	resp, err := r.do(creds, http.MethodPatch, path,
+		map[string]string{"Password": newPassword}, headers)
+	if err != nil {
+		return errors.Wrap(err, "failed to change BMC password")
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusAccepted, http.StatusNoContent:
+		return nil
+	default:
+		return fmt.Errorf("failed to change BMC password: PATCH %s returned %s", path, resp.Status)
+	}
+}
+
+// VerifyCredentials returns an error if the BMC does not accept the
+// credentials.
+func (r *redfishPasswordRotator) VerifyCredentials(creds Credentials) error {
+	if _, err := r.get(creds, redfishSystemsPath, nil); err != nil {
+		return errors.Wrap(err, "failed to verify BMC credentials")
+	}
+	return nil
+}
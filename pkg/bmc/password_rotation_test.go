@@ -0,0 +1,135 @@
+package bmc
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// fakeAccountService is a minimal Redfish AccountService where only
+// the second account can log in.
+type fakeAccountService struct {
+	username string
+	password string
+	etag     string
+}
+
+func (f *fakeAccountService) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	user, pass, ok := r.BasicAuth()
+	if !ok || user != f.username || pass != f.password {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	switch {
+	case r.URL.Path == "/redfish/v1/Systems":
+		json.NewEncoder(w).Encode(map[string]interface{}{"Members": []interface{}{}})
+	case r.URL.Path == "/redfish/v1/AccountService/Accounts":
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"Members": []map[string]string{
+				{"@odata.id": "/redfish/v1/AccountService/Accounts/1"},
+				{"@odata.id": "/redfish/v1/AccountService/Accounts/2"},
+			},
+		})
+	case r.URL.Path == "/redfish/v1/AccountService/Accounts/1":
+		json.NewEncoder(w).Encode(map[string]string{"UserName": "operator"})
+	case r.URL.Path == "/redfish/v1/AccountService/Accounts/2" && r.Method == http.MethodGet:
+		w.Header().Set("ETag", f.etag)
+		json.NewEncoder(w).Encode(map[string]string{"UserName": f.username})
+	case r.URL.Path == "/redfish/v1/AccountService/Accounts/2" && r.Method == http.MethodPatch:
+		if r.Header.Get("If-Match") != f.etag {
+			w.WriteHeader(http.StatusPreconditionFailed)
+			return
+		}
+		body := map[string]string{}
+		json.NewDecoder(r.Body).Decode(&body)
+		f.password = body["Password"]
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func TestPasswordRotatorChangePassword(t *testing.T) {
+	bmcServer := &fakeAccountService{username: "admin", password: "old", etag: `W/"1"`}
+	server := httptest.NewServer(bmcServer)
+	defer server.Close()
+
+	address := "redfish+" + server.URL + "/redfish/v1/Systems/1"
+	rotator, err := NewPasswordRotator(address, false)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	oldCreds := Credentials{Username: "admin", Password: "old"}
+	newCreds := Credentials{Username: "admin", Password: "new"}
+
+	if err = rotator.VerifyCredentials(oldCreds); err != nil {
+		t.Fatalf("old credentials rejected: %v", err)
+	}
+	if err = rotator.ChangePassword(oldCreds, "new"); err != nil {
+		t.Fatalf("unexpected error changing password: %v", err)
+	}
+	if bmcServer.password != "new" {
+		t.Errorf("password not changed on the BMC, got %q", bmcServer.password)
+	}
+	if err = rotator.VerifyCredentials(newCreds); err != nil {
+		t.Errorf("new credentials rejected: %v", err)
+	}
+	if err = rotator.VerifyCredentials(oldCreds); err == nil {
+		t.Error("old credentials still accepted")
+	}
+}
+
+func TestPasswordRotatorUnknownAccount(t *testing.T) {
+	server := httptest.NewServer(&fakeAccountService{username: "root", password: "pass"})
+	defer server.Close()
+
+	rotator, err := NewPasswordRotator("redfish+"+server.URL, false)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	err = rotator.ChangePassword(Credentials{Username: "admin", Password: "pass"}, "new")
+	if err == nil {
+		t.Fatal("expected an error for unknown credentials")
+	}
+}
+
+func TestPasswordRotatorUnsupportedType(t *testing.T) {
+	for _, address := range []string{
+		"ipmi://192.168.122.1:6233",
+		"libvirt://192.168.122.1",
+		"idrac://192.168.122.1",
+	} {
+		t.Run(address, func(t *testing.T) {
+			_, err := NewPasswordRotator(address, false)
+			if _, ok := err.(*UnsupportedPasswordRotationError); !ok {
+				t.Errorf("expected UnsupportedPasswordRotationError, got %v", err)
+			}
+		})
+	}
+}
+
+func TestGeneratePassword(t *testing.T) {
+	password, err := GeneratePassword(0, "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(password) != DefaultPasswordLength {
+		t.Errorf("expected default length %d, got %d", DefaultPasswordLength, len(password))
+	}
+
+	password, err = GeneratePassword(12, "ab")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(password) != 12 {
+		t.Errorf("expected length 12, got %d", len(password))
+	}
+	if strings.Trim(password, "ab") != "" {
+		t.Errorf("password %q contains unexpected characters", password)
+	}
+}
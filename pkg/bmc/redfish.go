@@ -121,6 +121,10 @@ func (a *redfishAccessDetails) VendorInterface() string {
 	return ""
 }
 
+func (a *redfishAccessDetails) OutOfBandInspectInterface() string {
+	return "redfish"
+}
+
 func (a *redfishAccessDetails) SupportsSecureBoot() bool {
 	return true
 }
@@ -158,6 +162,10 @@ func (a *redfishiDracAccessDetails) VendorInterface() string {
 	return "no-vendor"
 }
 
+func (a *redfishiDracAccessDetails) OutOfBandInspectInterface() string {
+	return "idrac-redfish"
+}
+
 func (a *redfishiDracAccessDetails) BuildBIOSSettings(firmwareConfig *metal3v1alpha1.FirmwareConfig) (settings []map[string]string, err error) {
 	if firmwareConfig != nil {
 		return nil, fmt.Errorf("firmware settings for %s are not supported", a.Driver())
@@ -89,6 +89,10 @@ func (a *redfishVirtualMediaAccessDetails) VendorInterface() string {
 	return ""
 }
 
+func (a *redfishVirtualMediaAccessDetails) OutOfBandInspectInterface() string {
+	return "redfish"
+}
+
 func (a *redfishVirtualMediaAccessDetails) SupportsSecureBoot() bool {
 	return true
 }
@@ -0,0 +1,162 @@
+package hardware
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	metal3v1alpha1 "github.com/shweta50/baremetal-operator/apis/metal3.io/v1alpha1"
+)
+
+// deviceFields holds the compared details of a device, by field name.
+type deviceFields map[string]string
+
+// Diff returns the changes between the hardware details reported by
+// two inspections. NICs are matched by MAC address, disks by serial
+// number (or name when the serial number is unknown) and DIMMs by slot,
+// so a replaced device is reported as removed and added.
+func Diff(before, after *metal3v1alpha1.HardwareDetails) []metal3v1alpha1.HardwareChange {
+	if before == nil || after == nil {
+		return nil
+	}
+
+	var changes []metal3v1alpha1.HardwareChange
+	changes = append(changes, diffDevices(metal3v1alpha1.HardwareComponentNIC,
+		nicDevices(before.NIC), nicDevices(after.NIC))...)
+	changes = append(changes, diffDevices(metal3v1alpha1.HardwareComponentStorage,
+		storageDevices(before.Storage), storageDevices(after.Storage))...)
+	changes = append(changes, diffFields(metal3v1alpha1.HardwareComponentRAM, "", deviceFields{
+		"ramMebibytes": fmt.Sprint(before.RAMMebibytes),
+	}, deviceFields{
+		"ramMebibytes": fmt.Sprint(after.RAMMebibytes),
+	})...)
+	changes = append(changes, diffDevices(metal3v1alpha1.HardwareComponentRAM,
+		dimmDevices(before.DIMMs), dimmDevices(after.DIMMs))...)
+	changes = append(changes, diffFields(metal3v1alpha1.HardwareComponentCPU, "",
+		cpuFields(before.CPU), cpuFields(after.CPU))...)
+	changes = append(changes, diffFields(metal3v1alpha1.HardwareComponentFirmware, "bios",
+		biosFields(before.Firmware.BIOS), biosFields(after.Firmware.BIOS))...)
+	return changes
+}
+
+// diffDevices compares two sets of devices, by identifier.
+func diffDevices(component metal3v1alpha1.HardwareComponent, before, after map[string]deviceFields) (changes []metal3v1alpha1.HardwareChange) {
+	names := make([]string, 0, len(before)+len(after))
+	for name := range before {
+		names = append(names, name)
+	}
+	for name := range after {
+		if _, found := before[name]; !found {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		oldFields, wasThere := before[name]
+		newFields, isThere := after[name]
+		switch {
+		case !isThere:
+			changes = append(changes, metal3v1alpha1.HardwareChange{
+				Component: component,
+				Type:      metal3v1alpha1.HardwareRemoved,
+				Name:      name,
+			})
+		case !wasThere:
+			changes = append(changes, metal3v1alpha1.HardwareChange{
+				Component: component,
+				Type:      metal3v1alpha1.HardwareAdded,
+				Name:      name,
+			})
+		default:
+			changes = append(changes, diffFields(component, name, oldFields, newFields)...)
+		}
+	}
+	return
+}
+
+// diffFields compares the details of a device found by both
+// inspections.
+func diffFields(component metal3v1alpha1.HardwareComponent, name string, before, after deviceFields) (changes []metal3v1alpha1.HardwareChange) {
+	fields := make([]string, 0, len(before))
+	for field := range before {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+
+	for _, field := range fields {
+		if before[field] == after[field] {
+			continue
+		}
+		changes = append(changes, metal3v1alpha1.HardwareChange{
+			Component: component,
+			Type:      metal3v1alpha1.HardwareChanged,
+			Name:      name,
+			Field:     field,
+			Old:       before[field],
+			New:       after[field],
+		})
+	}
+	return
+}
+
+func nicDevices(nics []metal3v1alpha1.NIC) map[string]deviceFields {
+	devices := map[string]deviceFields{}
+	for _, nic := range nics {
+		devices[strings.ToLower(nic.MAC)] = deviceFields{
+			"name":      nic.Name,
+			"model":     nic.Model,
+			"speedGbps": fmt.Sprint(nic.SpeedGbps),
+		}
+	}
+	return devices
+}
+
+func storageDevices(disks []metal3v1alpha1.Storage) map[string]deviceFields {
+	devices := map[string]deviceFields{}
+	for _, disk := range disks {
+		id := disk.SerialNumber
+		if id == "" {
+			id = disk.Name
+		}
+		health := ""
+		if disk.Health != nil {
+			health = string(disk.Health.Status)
+		}
+		devices[id] = deviceFields{
+			"name":      disk.Name,
+			"model":     disk.Model,
+			"sizeBytes": fmt.Sprint(int64(disk.SizeBytes)),
+			"health":    health,
+		}
+	}
+	return devices
+}
+
+func dimmDevices(dimms []metal3v1alpha1.DIMM) map[string]deviceFields {
+	devices := map[string]deviceFields{}
+	for _, dimm := range dimms {
+		devices[dimm.Slot] = deviceFields{
+			"sizeMebibytes":  fmt.Sprint(dimm.SizeMebibytes),
+			"speedMegahertz": fmt.Sprint(float64(dimm.SpeedMegahertz)),
+			"type":           dimm.Type,
+		}
+	}
+	return devices
+}
+
+func cpuFields(cpu metal3v1alpha1.CPU) deviceFields {
+	return deviceFields{
+		"arch":  cpu.Arch,
+		"model": cpu.Model,
+		"count": fmt.Sprint(cpu.Count),
+	}
+}
+
+func biosFields(bios metal3v1alpha1.BIOS) deviceFields {
+	return deviceFields{
+		"vendor":  bios.Vendor,
+		"version": bios.Version,
+		"date":    bios.Date,
+	}
+}
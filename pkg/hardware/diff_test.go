@@ -0,0 +1,51 @@
+package hardware
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	metal3v1alpha1 "github.com/shweta50/baremetal-operator/apis/metal3.io/v1alpha1"
+)
+
+func TestDiff(t *testing.T) {
+	before := &metal3v1alpha1.HardwareDetails{
+		Firmware:     metal3v1alpha1.Firmware{BIOS: metal3v1alpha1.BIOS{Vendor: "Dell", Version: "2.10.2"}},
+		RAMMebibytes: 65536,
+		NIC: []metal3v1alpha1.NIC{
+			{Name: "eno1", MAC: "3C:FD:FE:A0:00:01", SpeedGbps: 25},
+			{Name: "eno2", MAC: "3c:fd:fe:a0:00:02", SpeedGbps: 25},
+		},
+		Storage: []metal3v1alpha1.Storage{
+			{Name: "/dev/sda", SerialNumber: "S1", SizeBytes: 480 * metal3v1alpha1.GigaByte},
+			{Name: "/dev/sdb", SerialNumber: "S2", SizeBytes: 480 * metal3v1alpha1.GigaByte},
+		},
+		CPU: metal3v1alpha1.CPU{Arch: "x86_64", Model: "Xeon", Count: 32},
+		DIMMs: []metal3v1alpha1.DIMM{
+			{Slot: "A1", SizeMebibytes: 32768, Type: "DDR4"},
+			{Slot: "B1", SizeMebibytes: 32768, Type: "DDR4"},
+		},
+	}
+
+	assert.Empty(t, Diff(before, before.DeepCopy()))
+	assert.Empty(t, Diff(nil, before))
+
+	after := before.DeepCopy()
+	after.Firmware.BIOS.Version = "2.12.0"
+	after.RAMMebibytes = 98304
+	after.NIC[0].MAC = "3c:fd:fe:a0:00:01"
+	after.NIC[1].SpeedGbps = 10
+	after.Storage[1] = metal3v1alpha1.Storage{Name: "/dev/sdb", SerialNumber: "S3", SizeBytes: 960 * metal3v1alpha1.GigaByte}
+	after.DIMMs[1].SizeMebibytes = 65536
+	after.CPU.Count = 16
+
+	assert.Equal(t, []metal3v1alpha1.HardwareChange{
+		{Component: "nic", Type: "changed", Name: "3c:fd:fe:a0:00:02", Field: "speedGbps", Old: "25", New: "10"},
+		{Component: "storage", Type: "removed", Name: "S2"},
+		{Component: "storage", Type: "added", Name: "S3"},
+		{Component: "ram", Type: "changed", Field: "ramMebibytes", Old: "65536", New: "98304"},
+		{Component: "ram", Type: "changed", Name: "B1", Field: "sizeMebibytes", Old: "32768", New: "65536"},
+		{Component: "cpu", Type: "changed", Field: "count", Old: "32", New: "16"},
+		{Component: "firmware", Type: "changed", Name: "bios", Field: "version", Old: "2.10.2", New: "2.12.0"},
+	}, Diff(before, after))
+}
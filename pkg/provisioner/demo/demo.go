@@ -79,10 +79,14 @@ func (d Demo) NewProvisioner(hostData provisioner.HostData, publisher provisione
 	return p, nil
 }
 
-func (p *demoProvisioner) HasCapacity() (result bool, err error) {
+func (p *demoProvisioner) HasCapacity(data provisioner.CapacityData) (result bool, err error) {
 	return true, nil
 }
 
+func (p *demoProvisioner) LastHostState() *provisioner.HostState {
+	return nil
+}
+
 // ValidateManagementAccess tests the connection information for the
 // host to verify that the location and credentials work.
 func (p *demoProvisioner) ValidateManagementAccess(data provisioner.ManagementAccessData, credentialsChanged, force bool) (result provisioner.Result, provID string, err error) {
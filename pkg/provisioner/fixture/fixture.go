@@ -91,10 +91,14 @@ func (f *Fixture) SetValidateError(message string) {
 	f.validateError = message
 }
 
-func (p *fixtureProvisioner) HasCapacity() (result bool, err error) {
+func (p *fixtureProvisioner) HasCapacity(data provisioner.CapacityData) (result bool, err error) {
 	return true, nil
 }
 
+func (p *fixtureProvisioner) LastHostState() *provisioner.HostState {
+	return nil
+}
+
 // ValidateManagementAccess tests the connection information for the
 // host to verify that the location and credentials work.
 func (p *fixtureProvisioner) ValidateManagementAccess(data provisioner.ManagementAccessData, credentialsChanged, force bool) (result provisioner.Result, provID string, err error) {
@@ -0,0 +1,276 @@
+package ironic
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+
+	"github.com/gophercloud/gophercloud"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/yaml"
+
+	metal3v1alpha1 "github.com/shweta50/baremetal-operator/apis/metal3.io/v1alpha1"
+	"github.com/shweta50/baremetal-operator/pkg/provisioner"
+	"github.com/shweta50/baremetal-operator/pkg/provisioner/ironic/clients"
+)
+
+// backendsFile describes the Ironic backends available to the
+// operator, as read from the file named by IRONIC_BACKENDS_FILE.
+type backendsFile struct {
+	// DefaultBackend is the backend used for hosts without the
+	// IronicBackendLabel. When empty, those hosts can not be managed.
+	DefaultBackend string        `json:"defaultBackend,omitempty"`
+	Backends       []backendSpec `json:"backends"`
+}
+
+// backendSpec holds the settings of one Ironic backend. Settings left
+// empty take the value of the matching environment variable.
+type backendSpec struct {
+	Name              string `json:"name"`
+	IronicEndpoint    string `json:"ironicEndpoint,omitempty"`
+	InspectorEndpoint string `json:"inspectorEndpoint,omitempty"`
+
+	// AuthRootDir holds the "ironic" and "ironic-inspector" auth
+	// directories, laid out as under METAL3_AUTH_ROOT_DIR.
+	AuthRootDir string `json:"authRootDir,omitempty"`
+
+	CACertFile           string `json:"caCertFile,omitempty"`
+	ClientCertFile       string `json:"clientCertFile,omitempty"`
+	ClientPrivateKeyFile string `json:"clientPrivateKeyFile,omitempty"`
+	Insecure             bool   `json:"insecure,omitempty"`
+	SkipClientSANVerify  bool   `json:"skipClientSANVerify,omitempty"`
+
+	ProvisioningLimit int `json:"provisioningLimit,omitempty"`
+	// OperationLimits holds the limits of each class of operation,
+	// "inspection", "provisioning", "cleaning" and "deleting".
+	OperationLimits map[provisioner.Operation]int `json:"operationLimits,omitempty"`
+
+	DeployKernelURL  string `json:"deployKernelURL,omitempty"`
+	DeployRamdiskURL string `json:"deployRamdiskURL,omitempty"`
+	DeployISOURL     string `json:"deployISOURL,omitempty"`
+}
+
+// ironicBackend is one Ironic and Inspector pair with its own
+// settings. Each provisioner uses the clients and limits of the backend
+// of its host, so readiness and capacity are tracked per backend.
+type ironicBackend struct {
+	name            string
+	config          ironicConfig
+	clientIronic    *gophercloud.ServiceClient
+	clientInspector *gophercloud.ServiceClient
+	capacity        *capacityCache
+}
+
+// UnknownBackendError is returned when a host asks for an Ironic
+// backend that is not configured.
+type UnknownBackendError struct {
+	Name string
+}
+
+func (e UnknownBackendError) Error() string {
+	if e.Name == "" {
+		return "no default Ironic backend is configured, set the " +
+			metal3v1alpha1.IronicBackendLabel + " label"
+	}
+	return fmt.Sprintf("unknown Ironic backend %s", e.Name)
+}
+
+func validOperation(operation provisioner.Operation) bool {
+	for _, o := range provisioner.Operations {
+		if o == operation {
+			return true
+		}
+	}
+	return false
+}
+
+func loadBackendsFile(filename string) (backends backendsFile, err error) {
+	content, err := ioutil.ReadFile(filepath.Clean(filename))
+	if err != nil {
+		return
+	}
+	if err = yaml.UnmarshalStrict(content, &backends); err != nil {
+		err = fmt.Errorf("could not parse %s: %w", filename, err)
+		return
+	}
+
+	if len(backends.Backends) == 0 {
+		err = fmt.Errorf("no Ironic backends defined in %s", filename)
+		return
+	}
+	names := map[string]bool{}
+	for _, b := range backends.Backends {
+		if b.Name == "" {
+			err = fmt.Errorf("Ironic backend without a name in %s", filename)
+			return
+		}
+		if names[b.Name] {
+			err = fmt.Errorf("Ironic backend %s defined twice in %s", b.Name, filename)
+			return
+		}
+		names[b.Name] = true
+	}
+	if backends.DefaultBackend != "" && !names[backends.DefaultBackend] {
+		err = fmt.Errorf("default Ironic backend %s not defined in %s", backends.DefaultBackend, filename)
+	}
+	return
+}
+
+// settings merges the backend settings with the ones coming from the
+// environment.
+func (b backendSpec) settings(envConfig ironicConfig, envTLS clients.TLSConfig) (config ironicConfig, tlsConf clients.TLSConfig, err error) {
+	config = envConfig
+	if b.DeployKernelURL != "" || b.DeployRamdiskURL != "" || b.DeployISOURL != "" {
+		config.deployKernelURL = b.DeployKernelURL
+		config.deployRamdiskURL = b.DeployRamdiskURL
+		config.deployISOURL = b.DeployISOURL
+	}
+	if b.ProvisioningLimit < 0 {
+		err = fmt.Errorf("Invalid provisioningLimit %d for Ironic backend %s", b.ProvisioningLimit, b.Name)
+		return
+	}
+	if b.ProvisioningLimit != 0 {
+		config.maxBusyHosts = b.ProvisioningLimit
+	}
+	if len(b.OperationLimits) != 0 {
+		config.maxBusyHostsPerOperation = map[provisioner.Operation]int{}
+		for operation, limit := range envConfig.maxBusyHostsPerOperation {
+			config.maxBusyHostsPerOperation[operation] = limit
+		}
+		for operation, limit := range b.OperationLimits {
+			if !validOperation(operation) || limit < 0 {
+				err = fmt.Errorf("Invalid operation limit %s=%d for Ironic backend %s", operation, limit, b.Name)
+				return
+			}
+			config.maxBusyHostsPerOperation[operation] = limit
+		}
+	}
+	if err = config.validate(); err != nil {
+		err = fmt.Errorf("Ironic backend %s: %w", b.Name, err)
+		return
+	}
+
+	tlsConf = envTLS
+	if b.CACertFile != "" {
+		tlsConf.TrustedCAFile = b.CACertFile
+	}
+	if b.ClientCertFile != "" {
+		tlsConf.ClientCertificateFile = b.ClientCertFile
+	}
+	if b.ClientPrivateKeyFile != "" {
+		tlsConf.ClientPrivateKeyFile = b.ClientPrivateKeyFile
+	}
+	tlsConf.InsecureSkipVerify = tlsConf.InsecureSkipVerify || b.Insecure
+	tlsConf.SkipClientSANVerify = tlsConf.SkipClientSANVerify || b.SkipClientSANVerify
+	return
+}
+
+// initBackends sets up the clients of every backend described in the
+// file. The default backend, if any, is also stored in the factory's
+// own fields.
+func (f *ironicProvisionerFactory) initBackends(filename string) error {
+	file, err := loadBackendsFile(filename)
+	if err != nil {
+		return err
+	}
+	envConfig, err := readConfigFromEnv()
+	if err != nil {
+		return err
+	}
+	envTLS := loadTLSConfigFromEnv()
+
+	f.backends = map[string]ironicBackend{}
+	for _, spec := range file.Backends {
+		backend, err := f.newBackend(spec, envConfig, envTLS)
+		if err != nil {
+			return err
+		}
+		f.backends[spec.Name] = backend
+	}
+
+	if file.DefaultBackend != "" {
+		def := f.backends[file.DefaultBackend]
+		f.config = def.config
+		f.clientIronic = def.clientIronic
+		f.clientInspector = def.clientInspector
+		f.capacity = def.capacity
+	}
+	return nil
+}
+
+func (f *ironicProvisionerFactory) newBackend(spec backendSpec, envConfig ironicConfig, envTLS clients.TLSConfig) (backend ironicBackend, err error) {
+	backend.name = spec.Name
+	var tlsConf clients.TLSConfig
+	backend.config, tlsConf, err = spec.settings(envConfig, envTLS)
+	if err != nil {
+		return
+	}
+
+	var ironicAuth, inspectorAuth clients.AuthConfig
+	if spec.AuthRootDir != "" {
+		ironicAuth, inspectorAuth, err = clients.LoadAuthFromDir(spec.AuthRootDir)
+	} else {
+		ironicAuth, inspectorAuth, err = clients.LoadAuth()
+	}
+	if err != nil {
+		err = fmt.Errorf("Ironic backend %s: %w", spec.Name, err)
+		return
+	}
+	if (spec.IronicEndpoint == "" && ironicAuth.Type != clients.KeystoneAuth) ||
+		(spec.InspectorEndpoint == "" && inspectorAuth.Type != clients.KeystoneAuth) {
+		err = fmt.Errorf("Ironic backend %s requires ironicEndpoint and inspectorEndpoint", spec.Name)
+		return
+	}
+
+	f.log.Info("ironic backend settings",
+		"backend", spec.Name,
+		"endpoint", spec.IronicEndpoint,
+		"ironicAuthType", ironicAuth.Type,
+		"inspectorEndpoint", spec.InspectorEndpoint,
+		"inspectorAuthType", inspectorAuth.Type,
+		"deployKernelURL", backend.config.deployKernelURL,
+		"deployRamdiskURL", backend.config.deployRamdiskURL,
+		"deployISOURL", backend.config.deployISOURL,
+		"provisioningLimit", backend.config.maxBusyHosts,
+		"CACertFile", tlsConf.TrustedCAFile,
+		"ClientCertFile", tlsConf.ClientCertificateFile,
+		"ClientPrivKeyFile", tlsConf.ClientPrivateKeyFile,
+		"TLSInsecure", tlsConf.InsecureSkipVerify,
+		"SkipClientSANVerify", tlsConf.SkipClientSANVerify,
+	)
+
+	backend.clientIronic, err = clients.IronicClient(spec.IronicEndpoint, ironicAuth, tlsConf)
+	if err != nil {
+		return
+	}
+	backend.clientInspector, err = clients.InspectorClient(spec.InspectorEndpoint, inspectorAuth, tlsConf)
+	if err != nil {
+		return
+	}
+
+	backend.capacity = newCapacityCache(backend.clientIronic, backend.config.capacityRefreshInterval)
+	capacityMetrics.register(spec.Name, backend.capacity)
+	return
+}
+
+// backendFor returns the backend managing the host, chosen with the
+// IronicBackendLabel.
+func (f ironicProvisionerFactory) backendFor(objectMeta metav1.ObjectMeta) (ironicBackend, error) {
+	name := objectMeta.Labels[metal3v1alpha1.IronicBackendLabel]
+	if name == "" {
+		if f.backends != nil && f.clientIronic == nil {
+			return ironicBackend{}, UnknownBackendError{}
+		}
+		return ironicBackend{
+			config:          f.config,
+			clientIronic:    f.clientIronic,
+			clientInspector: f.clientInspector,
+			capacity:        f.capacity,
+		}, nil
+	}
+	backend, ok := f.backends[name]
+	if !ok {
+		return ironicBackend{}, UnknownBackendError{Name: name}
+	}
+	return backend, nil
+}
@@ -0,0 +1,179 @@
+package ironic
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	metal3v1alpha1 "github.com/shweta50/baremetal-operator/apis/metal3.io/v1alpha1"
+	"github.com/shweta50/baremetal-operator/pkg/bmc"
+	"github.com/shweta50/baremetal-operator/pkg/provisioner"
+	"github.com/shweta50/baremetal-operator/pkg/provisioner/ironic/testserver"
+)
+
+func writeBackendsFile(t *testing.T, content string) string {
+	filename := filepath.Join(t.TempDir(), "backends.yaml")
+	if err := ioutil.WriteFile(filename, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return filename
+}
+
+func TestLoadBackendsFile(t *testing.T) {
+	testCases := []struct {
+		Scenario    string
+		Content     string
+		ExpectedErr string
+	}{
+		{
+			Scenario: "valid",
+			Content: `
+defaultBackend: site-a
+backends:
+  - name: site-a
+    ironicEndpoint: http://site-a:6385/v1/
+  - name: site-b
+    ironicEndpoint: http://site-b:6385/v1/
+`,
+		},
+		{
+			Scenario:    "empty",
+			Content:     "backends: []",
+			ExpectedErr: "no Ironic backends",
+		},
+		{
+			Scenario:    "unknown field",
+			Content:     "backends: [{name: a, endpoint: http://a}]",
+			ExpectedErr: "could not parse",
+		},
+		{
+			Scenario:    "duplicate",
+			Content:     "backends: [{name: a}, {name: a}]",
+			ExpectedErr: "defined twice",
+		},
+		{
+			Scenario:    "unnamed",
+			Content:     "backends: [{ironicEndpoint: http://a}]",
+			ExpectedErr: "without a name",
+		},
+		{
+			Scenario:    "unknown default",
+			Content:     "{defaultBackend: b, backends: [{name: a}]}",
+			ExpectedErr: "default Ironic backend b not defined",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Scenario, func(t *testing.T) {
+			_, err := loadBackendsFile(writeBackendsFile(t, tc.Content))
+			if tc.ExpectedErr != "" {
+				if assert.Error(t, err) {
+					assert.Contains(t, err.Error(), tc.ExpectedErr)
+				}
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestBackendSettings(t *testing.T) {
+	envConfig := ironicConfig{
+		deployKernelURL:  "http://env/kernel",
+		deployRamdiskURL: "http://env/ramdisk",
+		maxBusyHosts:     20,
+	}
+
+	config, tlsConf, err := backendSpec{Name: "a"}.settings(envConfig, loadTLSConfigFromEnv())
+	assert.NoError(t, err)
+	assert.Equal(t, envConfig, config)
+	assert.Equal(t, loadTLSConfigFromEnv(), tlsConf)
+
+	config, tlsConf, err = backendSpec{
+		Name:              "b",
+		DeployISOURL:      "http://b/deploy.iso",
+		ProvisioningLimit: 3,
+		CACertFile:        "/b/ca.crt",
+		Insecure:          true,
+	}.settings(envConfig, loadTLSConfigFromEnv())
+	assert.NoError(t, err)
+	assert.Equal(t, ironicConfig{deployISOURL: "http://b/deploy.iso", maxBusyHosts: 3}, config)
+	assert.Equal(t, "/b/ca.crt", tlsConf.TrustedCAFile)
+	assert.True(t, tlsConf.InsecureSkipVerify)
+
+	_, _, err = backendSpec{Name: "c", DeployKernelURL: "http://c/kernel"}.settings(envConfig, loadTLSConfigFromEnv())
+	assert.Error(t, err)
+	_, _, err = backendSpec{Name: "d", ProvisioningLimit: -1}.settings(envConfig, loadTLSConfigFromEnv())
+	assert.Error(t, err)
+}
+
+func TestBackendSelection(t *testing.T) {
+	siteA := testserver.NewSimulator(t).Start()
+	defer siteA.Stop()
+	siteB := testserver.NewSimulator(t).Start()
+	defer siteB.Stop()
+
+	env := EnvFixture{kernelURL: "http://deploy/kernel", ramdiskURL: "http://deploy/ramdisk"}
+	env.SetUp()
+	defer env.TearDown()
+	env.replace("METAL3_AUTH_ROOT_DIR", t.TempDir())
+
+	filename := writeBackendsFile(t, fmt.Sprintf(`
+defaultBackend: site-a
+backends:
+  - name: site-a
+    ironicEndpoint: %s
+    inspectorEndpoint: %s
+  - name: site-b
+    ironicEndpoint: %s
+    inspectorEndpoint: %s
+    provisioningLimit: 2
+`, siteA.Endpoint(), siteA.InspectorEndpoint(), siteB.Endpoint(), siteB.InspectorEndpoint()))
+
+	factory := newTestProvisionerFactory()
+	if err := factory.initBackends(filename); err != nil {
+		t.Fatal(err)
+	}
+
+	hostData := func(backend string) provisioner.HostData {
+		host := makeHost()
+		if backend != "" {
+			host.Labels = map[string]string{metal3v1alpha1.IronicBackendLabel: backend}
+		}
+		return provisioner.BuildHostData(host, bmc.Credentials{})
+	}
+
+	prov, err := factory.ironicProvisioner(hostData(""), nullEventPublisher)
+	if assert.NoError(t, err) {
+		assert.Equal(t, siteA.Endpoint(), prov.client.Endpoint)
+		assert.Equal(t, 20, prov.config.maxBusyHosts)
+	}
+
+	prov, err = factory.ironicProvisioner(hostData("site-b"), nullEventPublisher)
+	if assert.NoError(t, err) {
+		assert.Equal(t, siteB.Endpoint(), prov.client.Endpoint)
+		assert.Equal(t, siteB.InspectorEndpoint(), prov.inspector.Endpoint)
+		assert.Equal(t, 2, prov.config.maxBusyHosts)
+	}
+
+	_, err = factory.ironicProvisioner(hostData("site-c"), nullEventPublisher)
+	assert.Equal(t, provisioner.HostConfigError{Err: UnknownBackendError{Name: "site-c"}}, err)
+
+	factory.clientIronic = nil
+	_, err = factory.ironicProvisioner(hostData(""), nullEventPublisher)
+	assert.Equal(t, provisioner.HostConfigError{Err: UnknownBackendError{}}, err)
+}
+
+func TestNoBackendsFile(t *testing.T) {
+	factory := newTestProvisionerFactory()
+	_, err := factory.backendFor(metav1.ObjectMeta{})
+	assert.NoError(t, err)
+	_, err = factory.backendFor(metav1.ObjectMeta{
+		Labels: map[string]string{metal3v1alpha1.IronicBackendLabel: "site-a"},
+	})
+	assert.Equal(t, UnknownBackendError{Name: "site-a"}, err)
+}
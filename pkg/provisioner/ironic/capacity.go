@@ -0,0 +1,302 @@
+package ironic
+
+import (
+	"sync"
+	"time"
+
+	"github.com/gophercloud/gophercloud"
+	"github.com/gophercloud/gophercloud/openstack/baremetal/v1/nodes"
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	"github.com/shweta50/baremetal-operator/pkg/provisioner"
+)
+
+const (
+	// capacityReservationTimeout is how long a slot given to a host
+	// is kept when Ironic does not report the host as busy.
+	capacityReservationTimeout = time.Minute
+
+	// capacityWaiterTimeout is how long a host denied a slot is
+	// considered to still be waiting for one, unless it asks again.
+	capacityWaiterTimeout = 2 * time.Minute
+
+	labelBackend   = "backend"
+	labelOperation = "operation"
+	// defaultBackendName labels the metrics of the Ironic configured
+	// with IRONIC_ENDPOINT.
+	defaultBackendName = "default"
+	// allOperations labels the metrics of the overall limit.
+	allOperations = "all"
+)
+
+// capacityLimits holds the maximum number of busy hosts, overall and
+// for each class of operation. A zero operation limit means only the
+// overall limit applies.
+type capacityLimits struct {
+	total        int
+	perOperation map[provisioner.Operation]int
+}
+
+type reservation struct {
+	operation provisioner.Operation
+	at        time.Time
+}
+
+type waiter struct {
+	operation provisioner.Operation
+	priority  int
+	at        time.Time
+}
+
+// capacityCache tracks the hosts that are busy in one Ironic, so that
+// checking the capacity for a host does not list every node. The busy
+// hosts are loaded again when they are older than maxAge. Hosts given a
+// slot are counted as reserved until Ironic reports them busy, so that
+// concurrent reconciles do not exceed the limits in between. Hosts
+// denied a slot are remembered with their priority, so that a free slot
+// goes to the waiting host with the highest priority.
+type capacityCache struct {
+	client *gophercloud.ServiceClient
+	maxAge time.Duration
+
+	mu        sync.Mutex
+	busy      map[string]provisioner.Operation
+	reserved  map[string]reservation
+	waiting   map[string]waiter
+	refreshed time.Time
+	limits    capacityLimits
+}
+
+func newCapacityCache(client *gophercloud.ServiceClient, maxAge time.Duration) *capacityCache {
+	return &capacityCache{
+		client:   client,
+		maxAge:   maxAge,
+		reserved: map[string]reservation{},
+		waiting:  map[string]waiter{},
+	}
+}
+
+// reserve returns whether the host can start an operation without
+// going over the limits, and keeps a slot for it if so.
+func (c *capacityCache) reserve(hostName string, data provisioner.CapacityData, limits capacityLimits) (bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.limits = limits
+	now := time.Now()
+	if c.refreshed.IsZero() || now.Sub(c.refreshed) >= c.maxAge {
+		if err := c.refresh(now); err != nil {
+			return false, err
+		}
+	}
+
+	// If the current host is already under processing then let's skip the test
+	if _, ok := c.busy[hostName]; ok {
+		return true, nil
+	}
+	if _, ok := c.reserved[hostName]; ok {
+		return true, nil
+	}
+
+	if !c.hasFreeSlot(hostName, data) {
+		c.waiting[hostName] = waiter{operation: data.Operation, priority: data.Priority, at: now}
+		return false, nil
+	}
+	delete(c.waiting, hostName)
+	c.reserved[hostName] = reservation{operation: data.Operation, at: now}
+	return true, nil
+}
+
+func (c *capacityCache) hasFreeSlot(hostName string, data provisioner.CapacityData) bool {
+	used, usedByOperation := c.usage()
+	if used >= c.limits.total || c.operationFull(data.Operation, usedByOperation) {
+		return false
+	}
+
+	// Leave the free slots to the hosts waiting with a higher
+	// priority, unless the limit of their own operation stops them
+	// from taking one.
+	ahead := 0
+	for name, w := range c.waiting {
+		if name != hostName && w.priority > data.Priority &&
+			!c.operationFull(w.operation, usedByOperation) {
+			ahead++
+		}
+	}
+	return used+ahead < c.limits.total
+}
+
+func (c *capacityCache) operationFull(operation provisioner.Operation, usedByOperation map[provisioner.Operation]int) bool {
+	limit := c.limits.perOperation[operation]
+	return limit > 0 && usedByOperation[operation] >= limit
+}
+
+func (c *capacityCache) usage() (used int, usedByOperation map[provisioner.Operation]int) {
+	usedByOperation = map[provisioner.Operation]int{}
+	for _, operation := range c.busy {
+		usedByOperation[operation]++
+	}
+	for _, r := range c.reserved {
+		usedByOperation[r.operation]++
+	}
+	return len(c.busy) + len(c.reserved), usedByOperation
+}
+
+// refresh loads the busy hosts from Ironic and drops the reservations
+// and waiting hosts that are no longer needed. The lock must be held.
+func (c *capacityCache) refresh(now time.Time) error {
+	hosts, err := loadBusyHosts(c.client)
+	if err != nil {
+		return err
+	}
+
+	for name, r := range c.reserved {
+		if _, busy := hosts[name]; busy || now.Sub(r.at) > capacityReservationTimeout {
+			delete(c.reserved, name)
+		}
+	}
+	for name, w := range c.waiting {
+		if _, busy := hosts[name]; busy || now.Sub(w.at) > capacityWaiterTimeout {
+			delete(c.waiting, name)
+		}
+	}
+	c.busy = hosts
+	c.refreshed = now
+	return nil
+}
+
+type capacityStats struct {
+	refreshed time.Time
+	limits    capacityLimits
+	busy      map[provisioner.Operation]int
+	reserved  map[provisioner.Operation]int
+}
+
+func (c *capacityCache) stats() (stats capacityStats) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	stats.refreshed = c.refreshed
+	stats.limits = c.limits
+	stats.busy = map[provisioner.Operation]int{}
+	stats.reserved = map[provisioner.Operation]int{}
+	for _, operation := range c.busy {
+		stats.busy[operation]++
+	}
+	for _, r := range c.reserved {
+		stats.reserved[r.operation]++
+	}
+	return
+}
+
+// operationForProvisionState returns the class of operation of a node
+// busy in the given state.
+func operationForProvisionState(state nodes.ProvisionState) (provisioner.Operation, bool) {
+	switch state {
+	case nodes.Inspecting, nodes.InspectWait:
+		return provisioner.OperationInspection, true
+	case nodes.Deploying, nodes.DeployWait:
+		return provisioner.OperationProvisioning, true
+	case nodes.Cleaning, nodes.CleanWait:
+		return provisioner.OperationCleaning, true
+	case nodes.Deleting:
+		return provisioner.OperationDeleting, true
+	}
+	return "", false
+}
+
+func loadBusyHosts(client *gophercloud.ServiceClient) (hosts map[string]provisioner.Operation, err error) {
+
+	hosts = make(map[string]provisioner.Operation)
+	pager := nodes.List(client, nodes.ListOpts{
+		Fields: []string{"uuid,name,provision_state,driver_internal_info,target_provision_state"},
+	})
+
+	page, err := pager.AllPages()
+	if err != nil {
+		return nil, err
+	}
+
+	allNodes, err := nodes.ExtractNodes(page)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, node := range allNodes {
+		if operation, busy := operationForProvisionState(nodes.ProvisionState(node.ProvisionState)); busy {
+			hosts[node.Name] = operation
+		}
+	}
+
+	return hosts, nil
+}
+
+var (
+	capacityCacheAgeDesc = prometheus.NewDesc(
+		"metal3_provisioner_capacity_cache_age_seconds",
+		"Time since the busy hosts were last loaded from Ironic",
+		[]string{labelBackend}, nil)
+	busySlotsDesc = prometheus.NewDesc(
+		"metal3_provisioner_busy_slots",
+		"Number of provisioning slots in use, including the reserved ones",
+		[]string{labelBackend, labelOperation}, nil)
+	reservedSlotsDesc = prometheus.NewDesc(
+		"metal3_provisioner_reserved_slots",
+		"Number of provisioning slots given to hosts not yet busy in Ironic",
+		[]string{labelBackend, labelOperation}, nil)
+	slotsLimitDesc = prometheus.NewDesc(
+		"metal3_provisioner_slots_limit",
+		"Maximum number of provisioning slots, overall or for an operation",
+		[]string{labelBackend, labelOperation}, nil)
+)
+
+// capacityCollector reports the state of the capacity cache of each
+// backend.
+type capacityCollector struct {
+	mu     sync.Mutex
+	caches map[string]*capacityCache
+}
+
+var capacityMetrics = &capacityCollector{caches: map[string]*capacityCache{}}
+
+func init() {
+	metrics.Registry.MustRegister(capacityMetrics)
+}
+
+func (cc *capacityCollector) register(backend string, c *capacityCache) {
+	cc.mu.Lock()
+	defer cc.mu.Unlock()
+	cc.caches[backend] = c
+}
+
+func (cc *capacityCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- capacityCacheAgeDesc
+	ch <- busySlotsDesc
+	ch <- reservedSlotsDesc
+	ch <- slotsLimitDesc
+}
+
+func (cc *capacityCollector) Collect(ch chan<- prometheus.Metric) {
+	cc.mu.Lock()
+	defer cc.mu.Unlock()
+	for backend, c := range cc.caches {
+		stats := c.stats()
+		if stats.refreshed.IsZero() {
+			continue
+		}
+		ch <- prometheus.MustNewConstMetric(capacityCacheAgeDesc, prometheus.GaugeValue,
+			time.Since(stats.refreshed).Seconds(), backend)
+		ch <- prometheus.MustNewConstMetric(slotsLimitDesc, prometheus.GaugeValue,
+			float64(stats.limits.total), backend, allOperations)
+		for _, operation := range provisioner.Operations {
+			ch <- prometheus.MustNewConstMetric(busySlotsDesc, prometheus.GaugeValue,
+				float64(stats.busy[operation]+stats.reserved[operation]), backend, string(operation))
+			ch <- prometheus.MustNewConstMetric(reservedSlotsDesc, prometheus.GaugeValue,
+				float64(stats.reserved[operation]), backend, string(operation))
+			if limit := stats.limits.perOperation[operation]; limit > 0 {
+				ch <- prometheus.MustNewConstMetric(slotsLimitDesc, prometheus.GaugeValue,
+					float64(limit), backend, string(operation))
+			}
+		}
+	}
+}
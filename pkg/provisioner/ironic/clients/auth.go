@@ -18,6 +18,8 @@ const (
 	NoAuth AuthType = "noauth"
 	// HTTPBasicAuth uses HTTP Basic Authentication
 	HTTPBasicAuth AuthType = "http_basic"
+	// KeystoneAuth uses tokens issued by Keystone
+	KeystoneAuth AuthType = "keystone"
 )
 
 // AuthConfig contains data needed to configure authentication in the client
@@ -25,6 +27,9 @@ type AuthConfig struct {
 	Type     AuthType
 	Username string
 	Password string
+
+	// Keystone holds the settings used with KeystoneAuth.
+	Keystone KeystoneConfig
 }
 
 func authRoot() string {
@@ -41,7 +46,11 @@ func readAuthFile(filename string) (string, error) {
 }
 
 func load(clientType string) (auth AuthConfig, err error) {
-	authPath := path.Join(authRoot(), clientType)
+	return loadFromDir(authRoot(), clientType)
+}
+
+func loadFromDir(root, clientType string) (auth AuthConfig, err error) {
+	authPath := path.Join(root, clientType)
 
 	if _, err := os.Stat(authPath); err != nil {
 		if os.IsNotExist(err) {
@@ -50,6 +59,14 @@ func load(clientType string) (auth AuthConfig, err error) {
 		}
 		return auth, err
 	}
+
+	if _, err := os.Stat(path.Join(authPath, cloudsFile)); err == nil {
+		return loadCloudsYAML(path.Join(authPath, cloudsFile))
+	}
+	if _, err := os.Stat(path.Join(authPath, "application-credential-id")); err == nil {
+		return loadApplicationCredential(authPath)
+	}
+
 	auth.Type = HTTPBasicAuth
 
 	auth.Username, err = readAuthFile(path.Join(authPath, "username"))
@@ -72,11 +89,17 @@ func load(clientType string) (auth AuthConfig, err error) {
 
 // LoadAuth loads the Ironic and Inspector configuration from the environment
 func LoadAuth() (ironicAuth, inspectorAuth AuthConfig, err error) {
-	ironicAuth, err = load("ironic")
+	return LoadAuthFromDir(authRoot())
+}
+
+// LoadAuthFromDir loads the Ironic and Inspector configuration from
+// the "ironic" and "ironic-inspector" subdirectories of root.
+func LoadAuthFromDir(root string) (ironicAuth, inspectorAuth AuthConfig, err error) {
+	ironicAuth, err = loadFromDir(root, "ironic")
 	if err != nil {
 		return
 	}
-	inspectorAuth, err = load("ironic-inspector")
+	inspectorAuth, err = loadFromDir(root, "ironic-inspector")
 	return
 }
 
@@ -16,6 +16,11 @@ import (
 
 var tlsConnectionTimeout = time.Second * 30
 
+// ironicMicroversion is high enough to get the features we need.
+// Update docs/configuration.md when updating the version. Version 1.69
+// introduces deploySteps argument to provisioning.
+const ironicMicroversion = "1.69"
+
 // TLSConfig contains the TLS configuration for the Ironic connection.
 // Using Go default values for this will result in no additional trusted
 // CA certificates and a secure connection.
@@ -29,7 +34,7 @@ type TLSConfig struct {
 	SkipClientSANVerify   bool
 }
 
-func updateHTTPClient(client *gophercloud.ServiceClient, tlsConf TLSConfig) (*gophercloud.ServiceClient, error) {
+func newHTTPClient(tlsConf TLSConfig) (c http.Client, err error) {
 	tlsInfo := transport.TLSInfo{
 		TrustedCAFile:       tlsConf.TrustedCAFile,
 		CertFile:            tlsConf.ClientCertificateFile,
@@ -41,21 +46,21 @@ func updateHTTPClient(client *gophercloud.ServiceClient, tlsConf TLSConfig) (*go
 		if os.IsNotExist(err) {
 			tlsInfo.TrustedCAFile = ""
 		} else {
-			return client, err
+			return c, err
 		}
 	}
 	if _, err := os.Stat(tlsConf.ClientCertificateFile); err != nil {
 		if os.IsNotExist(err) {
 			tlsInfo.CertFile = ""
 		} else {
-			return client, err
+			return c, err
 		}
 	}
 	if _, err := os.Stat(tlsConf.ClientPrivateKeyFile); err != nil {
 		if os.IsNotExist(err) {
 			tlsInfo.KeyFile = ""
 		} else {
-			return client, err
+			return c, err
 		}
 	}
 	if tlsInfo.CertFile != "" && tlsInfo.KeyFile != "" {
@@ -64,16 +69,24 @@ func updateHTTPClient(client *gophercloud.ServiceClient, tlsConf TLSConfig) (*go
 
 	tlsTransport, err := transport.NewTransport(tlsInfo, tlsConnectionTimeout)
 	if err != nil {
-		return client, err
+		return c, err
 	}
-	c := http.Client{
-		Transport: tlsTransport,
+	c.Transport = tlsTransport
+	return c, nil
+}
+
+func updateHTTPClient(client *gophercloud.ServiceClient, tlsConf TLSConfig) (*gophercloud.ServiceClient, error) {
+	c, err := newHTTPClient(tlsConf)
+	if err != nil {
+		return client, err
 	}
 	client.HTTPClient = c
 	return client, nil
 }
 
-// IronicClient creates a client for Ironic
+// IronicClient creates a client for Ironic. With Keystone
+// authentication the endpoint may be empty, in which case it is looked
+// up in the service catalog.
 func IronicClient(ironicEndpoint string, auth AuthConfig, tls TLSConfig) (client *gophercloud.ServiceClient, err error) {
 	switch auth.Type {
 	case NoAuth:
@@ -86,6 +99,16 @@ func IronicClient(ironicEndpoint string, auth AuthConfig, tls TLSConfig) (client
 			IronicUser:         auth.Username,
 			IronicUserPassword: auth.Password,
 		})
+	case KeystoneAuth:
+		var httpClient http.Client
+		if httpClient, err = newHTTPClient(tls); err != nil {
+			return
+		}
+		if client, err = keystoneClient(ironicServiceType, ironicEndpoint, auth.Keystone, httpClient); err != nil {
+			return
+		}
+		client.Microversion = ironicMicroversion
+		return
 	default:
 		err = fmt.Errorf("Unknown auth type %s", auth.Type)
 	}
@@ -93,15 +116,14 @@ func IronicClient(ironicEndpoint string, auth AuthConfig, tls TLSConfig) (client
 		return
 	}
 
-	// Ensure we have a microversion high enough to get the features
-	// we need. Update docs/configuration.md when updating the version.
-	// Version 1.69 introduces deploySteps argument to provisioning.
-	client.Microversion = "1.69"
+	client.Microversion = ironicMicroversion
 
 	return updateHTTPClient(client, tls)
 }
 
-// InspectorClient creates a client for Ironic Inspector
+// InspectorClient creates a client for Ironic Inspector. With
+// Keystone authentication the endpoint may be empty, in which case it
+// is looked up in the service catalog.
 func InspectorClient(inspectorEndpoint string, auth AuthConfig, tls TLSConfig) (client *gophercloud.ServiceClient, err error) {
 	switch auth.Type {
 	case NoAuth:
@@ -115,6 +137,12 @@ func InspectorClient(inspectorEndpoint string, auth AuthConfig, tls TLSConfig) (
 			IronicInspectorUser:         auth.Username,
 			IronicInspectorUserPassword: auth.Password,
 		})
+	case KeystoneAuth:
+		var httpClient http.Client
+		if httpClient, err = newHTTPClient(tls); err != nil {
+			return
+		}
+		return keystoneClient(inspectorServiceType, inspectorEndpoint, auth.Keystone, httpClient)
 	default:
 		err = fmt.Errorf("Unknown auth type %s", auth.Type)
 	}
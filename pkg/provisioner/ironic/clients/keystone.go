@@ -0,0 +1,239 @@
+package clients
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/gophercloud/gophercloud"
+	"github.com/gophercloud/gophercloud/openstack"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// cloudsFile is the name of the clouds.yaml file looked for in the
+	// auth directory of a client.
+	cloudsFile = "clouds.yaml"
+
+	ironicServiceType    = "baremetal"
+	inspectorServiceType = "baremetal-introspection"
+)
+
+// KeystoneConfig holds the settings used to get tokens from Keystone,
+// using either an application credential or a username and password.
+type KeystoneConfig struct {
+	AuthURL string
+
+	ApplicationCredentialID     string
+	ApplicationCredentialName   string
+	ApplicationCredentialSecret string
+
+	Username          string
+	UserID            string
+	Password          string
+	UserDomainName    string
+	UserDomainID      string
+	ProjectName       string
+	ProjectID         string
+	ProjectDomainName string
+	ProjectDomainID   string
+
+	// RegionName and Interface select the endpoints used from the
+	// service catalog.
+	RegionName string
+	Interface  string
+}
+
+// cloudsYAML is the subset of the clouds.yaml format understood by
+// the operator.
+type cloudsYAML struct {
+	Clouds map[string]struct {
+		Auth struct {
+			AuthURL                     string `json:"auth_url"`
+			ApplicationCredentialID     string `json:"application_credential_id"`
+			ApplicationCredentialName   string `json:"application_credential_name"`
+			ApplicationCredentialSecret string `json:"application_credential_secret"`
+			Username                    string `json:"username"`
+			UserID                      string `json:"user_id"`
+			Password                    string `json:"password"`
+			UserDomainName              string `json:"user_domain_name"`
+			UserDomainID                string `json:"user_domain_id"`
+			ProjectName                 string `json:"project_name"`
+			ProjectID                   string `json:"project_id"`
+			ProjectDomainName           string `json:"project_domain_name"`
+			ProjectDomainID             string `json:"project_domain_id"`
+		} `json:"auth"`
+		RegionName string `json:"region_name"`
+		Interface  string `json:"interface"`
+	} `json:"clouds"`
+}
+
+// loadCloudsYAML reads the Keystone settings of a cloud from a
+// clouds.yaml file. The cloud is the one named by OS_CLOUD, or the
+// only one in the file.
+func loadCloudsYAML(filename string) (auth AuthConfig, err error) {
+	content, err := ioutil.ReadFile(filepath.Clean(filename))
+	if err != nil {
+		return
+	}
+	var clouds cloudsYAML
+	if err = yaml.Unmarshal(content, &clouds); err != nil {
+		err = fmt.Errorf("could not parse %s: %w", filename, err)
+		return
+	}
+
+	name := os.Getenv("OS_CLOUD")
+	if name == "" {
+		if len(clouds.Clouds) != 1 {
+			names := []string{}
+			for n := range clouds.Clouds {
+				names = append(names, n)
+			}
+			sort.Strings(names)
+			err = fmt.Errorf("%s defines clouds %v, set OS_CLOUD to choose one", filename, names)
+			return
+		}
+		for n := range clouds.Clouds {
+			name = n
+		}
+	}
+	cloud, ok := clouds.Clouds[name]
+	if !ok {
+		err = fmt.Errorf("cloud %s not found in %s", name, filename)
+		return
+	}
+
+	auth.Type = KeystoneAuth
+	auth.Keystone = KeystoneConfig{
+		AuthURL:                     cloud.Auth.AuthURL,
+		ApplicationCredentialID:     cloud.Auth.ApplicationCredentialID,
+		ApplicationCredentialName:   cloud.Auth.ApplicationCredentialName,
+		ApplicationCredentialSecret: cloud.Auth.ApplicationCredentialSecret,
+		Username:                    cloud.Auth.Username,
+		UserID:                      cloud.Auth.UserID,
+		Password:                    cloud.Auth.Password,
+		UserDomainName:              cloud.Auth.UserDomainName,
+		UserDomainID:                cloud.Auth.UserDomainID,
+		ProjectName:                 cloud.Auth.ProjectName,
+		ProjectID:                   cloud.Auth.ProjectID,
+		ProjectDomainName:           cloud.Auth.ProjectDomainName,
+		ProjectDomainID:             cloud.Auth.ProjectDomainID,
+		RegionName:                  cloud.RegionName,
+		Interface:                   cloud.Interface,
+	}
+	err = auth.Keystone.validate()
+	return
+}
+
+// loadApplicationCredential reads the Keystone settings from one file
+// per setting in the auth directory, as mounted from a Secret.
+func loadApplicationCredential(authPath string) (auth AuthConfig, err error) {
+	auth.Type = KeystoneAuth
+	files := map[string]*string{
+		"auth-url":                      &auth.Keystone.AuthURL,
+		"application-credential-id":     &auth.Keystone.ApplicationCredentialID,
+		"application-credential-secret": &auth.Keystone.ApplicationCredentialSecret,
+		"region-name":                   &auth.Keystone.RegionName,
+		"interface":                     &auth.Keystone.Interface,
+	}
+	for name, value := range files {
+		*value, err = readAuthFile(path.Join(authPath, name))
+		if err != nil && !os.IsNotExist(err) {
+			return
+		}
+	}
+	err = auth.Keystone.validate()
+	return
+}
+
+func (c KeystoneConfig) validate() error {
+	switch {
+	case c.AuthURL == "":
+		return fmt.Errorf("Empty Keystone auth URL")
+	case c.ApplicationCredentialID != "" || c.ApplicationCredentialName != "":
+		if c.ApplicationCredentialSecret == "" {
+			return fmt.Errorf("Empty Keystone application credential secret")
+		}
+	case c.Username == "" && c.UserID == "":
+		return fmt.Errorf("Keystone requires either an application credential or a username")
+	case c.Password == "":
+		return fmt.Errorf("Empty Keystone password")
+	}
+	switch c.Interface {
+	case "", "public", "internal", "admin":
+	default:
+		return fmt.Errorf("Invalid Keystone interface %s", c.Interface)
+	}
+	return nil
+}
+
+func (c KeystoneConfig) authOptions() gophercloud.AuthOptions {
+	opts := gophercloud.AuthOptions{
+		IdentityEndpoint:            c.AuthURL,
+		ApplicationCredentialID:     c.ApplicationCredentialID,
+		ApplicationCredentialName:   c.ApplicationCredentialName,
+		ApplicationCredentialSecret: c.ApplicationCredentialSecret,
+		Username:                    c.Username,
+		UserID:                      c.UserID,
+		Password:                    c.Password,
+		DomainName:                  c.UserDomainName,
+		DomainID:                    c.UserDomainID,
+		// Tokens expire, so let the client get a new one when a
+		// request is rejected with a 401 and retry it.
+		AllowReauth: true,
+	}
+	if c.ApplicationCredentialID == "" && c.ApplicationCredentialName == "" &&
+		(c.ProjectName != "" || c.ProjectID != "") {
+		opts.Scope = &gophercloud.AuthScope{
+			ProjectName: c.ProjectName,
+			ProjectID:   c.ProjectID,
+			DomainName:  c.ProjectDomainName,
+			DomainID:    c.ProjectDomainID,
+		}
+	}
+	return opts
+}
+
+// keystoneClient authenticates with Keystone and returns a client for
+// the given service. The endpoint is taken from the service catalog
+// unless one is given.
+func keystoneClient(serviceType, endpoint string, c KeystoneConfig, httpClient http.Client) (*gophercloud.ServiceClient, error) {
+	provider, err := openstack.NewClient(c.AuthURL)
+	if err != nil {
+		return nil, err
+	}
+	provider.HTTPClient = httpClient
+	if err = openstack.Authenticate(provider, c.authOptions()); err != nil {
+		return nil, fmt.Errorf("failed to authenticate with Keystone: %w", err)
+	}
+
+	if endpoint == "" {
+		availability := gophercloud.AvailabilityPublic
+		if c.Interface != "" {
+			availability = gophercloud.Availability(c.Interface)
+		}
+		endpoint, err = provider.EndpointLocator(gophercloud.EndpointOpts{
+			Type:         serviceType,
+			Region:       c.RegionName,
+			Availability: availability,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to find the %s endpoint in the service catalog: %w", serviceType, err)
+		}
+		// The catalog usually holds the unversioned endpoint.
+		if !strings.HasSuffix(strings.TrimSuffix(endpoint, "/"), "/v1") {
+			endpoint = gophercloud.NormalizeURL(endpoint) + "v1/"
+		}
+	}
+
+	return &gophercloud.ServiceClient{
+		ProviderClient: provider,
+		Endpoint:       gophercloud.NormalizeURL(endpoint),
+		Type:           serviceType,
+	}, nil
+}
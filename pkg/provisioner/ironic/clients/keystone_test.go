@@ -0,0 +1,293 @@
+package clients
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/gophercloud/gophercloud/openstack/baremetal/v1/nodes"
+	"github.com/gophercloud/gophercloud/openstack/baremetalintrospection/v1/introspection"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeKeystone issues numbered tokens, and the fake services behind it
+// only accept the latest one.
+type fakeKeystone struct {
+	mu       sync.Mutex
+	server   *httptest.Server
+	tokens   int
+	services *httptest.Server
+	authBody map[string]interface{}
+}
+
+func newFakeKeystone(t *testing.T) *fakeKeystone {
+	k := &fakeKeystone{}
+	k.services = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		k.mu.Lock()
+		valid := r.Header.Get("X-Auth-Token") == fmt.Sprintf("token-%d", k.tokens)
+		k.mu.Unlock()
+		if !valid {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/baremetal/v1/nodes/node-1":
+			fmt.Fprint(w, `{"uuid": "node-1"}`)
+		case "/inspector/v1/introspection/node-1":
+			fmt.Fprint(w, `{"uuid": "node-1", "finished": true}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	k.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v3/auth/tokens" || r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		k.mu.Lock()
+		k.tokens++
+		token := fmt.Sprintf("token-%d", k.tokens)
+		json.NewDecoder(r.Body).Decode(&k.authBody)
+		k.mu.Unlock()
+
+		endpoint := func(path string) []map[string]string {
+			return []map[string]string{
+				{"interface": "public", "region": "RegionOne", "region_id": "RegionOne", "url": k.services.URL + path},
+				{"interface": "public", "region": "RegionTwo", "region_id": "RegionTwo", "url": "http://other.test" + path},
+			}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("X-Subject-Token", token)
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"token": map[string]interface{}{
+				"expires_at": "2099-01-01T00:00:00.000000Z",
+				"catalog": []map[string]interface{}{
+					{"type": "baremetal", "name": "ironic", "endpoints": endpoint("/baremetal")},
+					{"type": "baremetal-introspection", "name": "ironic-inspector", "endpoints": endpoint("/inspector/")},
+				},
+			},
+		})
+	}))
+	t.Cleanup(k.server.Close)
+	t.Cleanup(k.services.Close)
+	return k
+}
+
+// expireToken makes the services reject the current token.
+func (k *fakeKeystone) expireToken() {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.tokens++
+}
+
+func TestKeystoneClients(t *testing.T) {
+	keystone := newFakeKeystone(t)
+	auth := AuthConfig{
+		Type: KeystoneAuth,
+		Keystone: KeystoneConfig{
+			AuthURL:                     keystone.server.URL + "/v3",
+			ApplicationCredentialID:     "app-cred-id",
+			ApplicationCredentialSecret: "app-cred-secret",
+			RegionName:                  "RegionOne",
+		},
+	}
+
+	ironic, err := IronicClient("", auth, TLSConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, keystone.services.URL+"/baremetal/v1/", ironic.Endpoint)
+	assert.Equal(t, "1.69", ironic.Microversion)
+	inspector, err := InspectorClient("", auth, TLSConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, keystone.services.URL+"/inspector/v1/", inspector.Endpoint)
+
+	identity := keystone.authBody["auth"].(map[string]interface{})["identity"].(map[string]interface{})
+	assert.Equal(t, []interface{}{"application_credential"}, identity["methods"])
+
+	_, err = nodes.Get(ironic, "node-1").Extract()
+	assert.NoError(t, err)
+
+	keystone.expireToken()
+	_, err = nodes.Get(ironic, "node-1").Extract()
+	assert.NoError(t, err, "expected the token to be refreshed")
+	keystone.expireToken()
+	_, err = introspection.GetIntrospectionStatus(inspector, "node-1").Extract()
+	assert.NoError(t, err, "expected the token to be refreshed")
+}
+
+func TestKeystoneExplicitEndpoint(t *testing.T) {
+	keystone := newFakeKeystone(t)
+	auth := AuthConfig{
+		Type: KeystoneAuth,
+		Keystone: KeystoneConfig{
+			AuthURL:        keystone.server.URL + "/v3",
+			Username:       "metal3",
+			Password:       "secret",
+			UserDomainName: "Default",
+		},
+	}
+	ironic, err := IronicClient("http://ironic.test:6385/v1", auth, TLSConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "http://ironic.test:6385/v1/", ironic.Endpoint)
+
+	auth.Keystone.RegionName = "RegionThree"
+	_, err = InspectorClient("", auth, TLSConfig{})
+	assert.Error(t, err)
+}
+
+func writeAuthFiles(t *testing.T, files map[string]string) string {
+	root := t.TempDir()
+	for name, content := range files {
+		filename := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(filename), 0700); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(filename, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return root
+}
+
+func TestLoadKeystoneAuth(t *testing.T) {
+	clouds := `
+clouds:
+  shared:
+    auth:
+      auth_url: https://keystone.test/v3
+      application_credential_id: app-cred-id
+      application_credential_secret: app-cred-secret
+    region_name: RegionOne
+    interface: internal
+`
+	testCases := []struct {
+		Scenario     string
+		Files        map[string]string
+		Cloud        string
+		ExpectedAuth AuthConfig
+		ExpectedErr  string
+	}{
+		{
+			Scenario: "clouds.yaml",
+			Files:    map[string]string{"ironic/clouds.yaml": clouds},
+			ExpectedAuth: AuthConfig{
+				Type: KeystoneAuth,
+				Keystone: KeystoneConfig{
+					AuthURL:                     "https://keystone.test/v3",
+					ApplicationCredentialID:     "app-cred-id",
+					ApplicationCredentialSecret: "app-cred-secret",
+					RegionName:                  "RegionOne",
+					Interface:                   "internal",
+				},
+			},
+		},
+		{
+			Scenario: "clouds.yaml with password",
+			Files: map[string]string{"ironic/clouds.yaml": `
+clouds:
+  one:
+    auth:
+      auth_url: https://one.test/v3
+  two:
+    auth:
+      auth_url: https://two.test/v3
+      username: metal3
+      password: secret
+      project_name: baremetal
+      user_domain_name: Default
+      project_domain_name: Default
+`},
+			Cloud: "two",
+			ExpectedAuth: AuthConfig{
+				Type: KeystoneAuth,
+				Keystone: KeystoneConfig{
+					AuthURL:           "https://two.test/v3",
+					Username:          "metal3",
+					Password:          "secret",
+					ProjectName:       "baremetal",
+					UserDomainName:    "Default",
+					ProjectDomainName: "Default",
+				},
+			},
+		},
+		{
+			Scenario:    "unknown cloud",
+			Files:       map[string]string{"ironic/clouds.yaml": clouds},
+			Cloud:       "other",
+			ExpectedErr: "cloud other not found",
+		},
+		{
+			Scenario: "several clouds",
+			Files: map[string]string{"ironic/clouds.yaml": `
+clouds:
+  one: {}
+  two: {}
+`},
+			ExpectedErr: "set OS_CLOUD",
+		},
+		{
+			Scenario: "application credential files",
+			Files: map[string]string{
+				"ironic/auth-url":                      "https://keystone.test/v3\n",
+				"ironic/application-credential-id":     "app-cred-id\n",
+				"ironic/application-credential-secret": "app-cred-secret\n",
+			},
+			ExpectedAuth: AuthConfig{
+				Type: KeystoneAuth,
+				Keystone: KeystoneConfig{
+					AuthURL:                     "https://keystone.test/v3",
+					ApplicationCredentialID:     "app-cred-id",
+					ApplicationCredentialSecret: "app-cred-secret",
+				},
+			},
+		},
+		{
+			Scenario: "missing secret",
+			Files: map[string]string{
+				"ironic/auth-url":                  "https://keystone.test/v3",
+				"ironic/application-credential-id": "app-cred-id",
+			},
+			ExpectedErr: "Empty Keystone application credential secret",
+		},
+		{
+			Scenario: "basic auth",
+			Files: map[string]string{
+				"ironic/username": "user",
+				"ironic/password": "pass",
+			},
+			ExpectedAuth: AuthConfig{Type: HTTPBasicAuth, Username: "user", Password: "pass"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Scenario, func(t *testing.T) {
+			defer os.Setenv("METAL3_AUTH_ROOT_DIR", os.Getenv("METAL3_AUTH_ROOT_DIR"))
+			defer os.Setenv("OS_CLOUD", os.Getenv("OS_CLOUD"))
+			os.Setenv("METAL3_AUTH_ROOT_DIR", writeAuthFiles(t, tc.Files))
+			os.Setenv("OS_CLOUD", tc.Cloud)
+
+			auth, err := load("ironic")
+			if tc.ExpectedErr != "" {
+				if assert.Error(t, err) {
+					assert.Contains(t, err.Error(), tc.ExpectedErr)
+				}
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.ExpectedAuth, auth)
+		})
+	}
+}
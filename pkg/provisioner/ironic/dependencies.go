@@ -15,16 +15,26 @@ const (
 )
 
 type ironicDependenciesChecker struct {
-	client    *gophercloud.ServiceClient
-	inspector *gophercloud.ServiceClient
-	log       logr.Logger
+	client         *gophercloud.ServiceClient
+	inspector      *gophercloud.ServiceClient
+	conductorGroup string
+	log            logr.Logger
 }
 
-func newIronicDependenciesChecker(client *gophercloud.ServiceClient, inspector *gophercloud.ServiceClient, log logr.Logger) *ironicDependenciesChecker {
+// conductor is an entry of the Ironic conductors API, which
+// gophercloud does not support yet.
+type conductor struct {
+	Hostname       string `json:"hostname"`
+	ConductorGroup string `json:"conductor_group"`
+	Alive          bool   `json:"alive"`
+}
+
+func newIronicDependenciesChecker(client *gophercloud.ServiceClient, inspector *gophercloud.ServiceClient, conductorGroup string, log logr.Logger) *ironicDependenciesChecker {
 	return &ironicDependenciesChecker{
-		client:    client,
-		inspector: inspector,
-		log:       log,
+		client:         client,
+		inspector:      inspector,
+		conductorGroup: conductorGroup,
+		log:            log,
 	}
 }
 
@@ -84,9 +94,33 @@ func (i *ironicDependenciesChecker) checkIronicConductor() (ready bool, err erro
 	// If we have any drivers, conductor is up.
 	ready = driverCount > 0
 
+	if ready && i.conductorGroup != "" {
+		ready, err = i.checkConductorGroup()
+	}
 	return ready, err
 }
 
+// checkConductorGroup looks for an alive conductor in the conductor
+// group of the host, as nodes in a group without one are never
+// managed.
+func (i *ironicDependenciesChecker) checkConductorGroup() (ready bool, err error) {
+	var body struct {
+		Conductors []conductor `json:"conductors"`
+	}
+	_, err = i.client.Get(i.client.ServiceURL("conductors"), &body, nil)
+	if err != nil {
+		return false, err
+	}
+
+	for _, c := range body.Conductors {
+		if c.Alive && strings.EqualFold(c.ConductorGroup, i.conductorGroup) {
+			return true, nil
+		}
+	}
+	i.log.Info("no alive conductor in conductor group", "conductorGroup", i.conductorGroup)
+	return false, nil
+}
+
 func (i *ironicDependenciesChecker) checkIronicInspector() (ready bool) {
 	return i.checkEndpoint(i.inspector)
 }
@@ -2,6 +2,7 @@ package devicehints
 
 import (
 	"fmt"
+	"strings"
 
 	metal3v1alpha1 "github.com/shweta50/baremetal-operator/apis/metal3.io/v1alpha1"
 )
@@ -18,6 +19,9 @@ func MakeHintMap(source *metal3v1alpha1.RootDeviceHints) map[string]string {
 	if source.DeviceName != "" {
 		hints["name"] = fmt.Sprintf("s== %s", source.DeviceName)
 	}
+	if source.ByPath != "" {
+		hints["by_path"] = fmt.Sprintf("s== %s", source.ByPath)
+	}
 	if source.HCTL != "" {
 		hints["hctl"] = fmt.Sprintf("s== %s", source.HCTL)
 	}
@@ -30,8 +34,13 @@ func MakeHintMap(source *metal3v1alpha1.RootDeviceHints) map[string]string {
 	if source.SerialNumber != "" {
 		hints["serial"] = fmt.Sprintf("s== %s", source.SerialNumber)
 	}
-	if source.MinSizeGigabytes != 0 {
+	switch {
+	case source.MinSizeGigabytes != 0 && source.MaxSizeGigabytes != 0:
+		hints["size"] = fmt.Sprintf("<range-in> [ %d %d ]", source.MinSizeGigabytes, source.MaxSizeGigabytes)
+	case source.MinSizeGigabytes != 0:
 		hints["size"] = fmt.Sprintf(">= %d", source.MinSizeGigabytes)
+	case source.MaxSizeGigabytes != 0:
+		hints["size"] = fmt.Sprintf("<= %d", source.MaxSizeGigabytes)
 	}
 	if source.WWN != "" {
 		hints["wwn"] = fmt.Sprintf("s== %s", source.WWN)
@@ -50,5 +59,60 @@ func MakeHintMap(source *metal3v1alpha1.RootDeviceHints) map[string]string {
 		hints["rotational"] = "false"
 	}
 
+	for _, expression := range source.Expressions {
+		hints[hintNames[expression.Field]] = makeExpression(expression)
+	}
+
 	return hints
 }
+
+// hintNames are the names used by ironic for the fields of root
+// device hint expressions.
+var hintNames = map[metal3v1alpha1.RootDeviceHintField]string{
+	metal3v1alpha1.RootDeviceHintFieldDeviceName:         "name",
+	metal3v1alpha1.RootDeviceHintFieldByPath:             "by_path",
+	metal3v1alpha1.RootDeviceHintFieldHCTL:               "hctl",
+	metal3v1alpha1.RootDeviceHintFieldModel:              "model",
+	metal3v1alpha1.RootDeviceHintFieldVendor:             "vendor",
+	metal3v1alpha1.RootDeviceHintFieldSerialNumber:       "serial",
+	metal3v1alpha1.RootDeviceHintFieldSizeGigabytes:      "size",
+	metal3v1alpha1.RootDeviceHintFieldWWN:                "wwn",
+	metal3v1alpha1.RootDeviceHintFieldWWNWithExtension:   "wwn_with_extension",
+	metal3v1alpha1.RootDeviceHintFieldWWNVendorExtension: "wwn_vendor_extension",
+}
+
+// hintOperators are the ironic operators for each operator of root
+// device hint expressions, when comparing strings and numbers.
+var hintOperators = map[metal3v1alpha1.RootDeviceHintOperator][2]string{
+	metal3v1alpha1.RootDeviceHintEqual:          {"s==", "=="},
+	metal3v1alpha1.RootDeviceHintNotEqual:       {"s!=", "!="},
+	metal3v1alpha1.RootDeviceHintLess:           {"s<", "<"},
+	metal3v1alpha1.RootDeviceHintLessOrEqual:    {"s<=", "<="},
+	metal3v1alpha1.RootDeviceHintGreater:        {"s>", ">"},
+	metal3v1alpha1.RootDeviceHintGreaterOrEqual: {"s>=", ">="},
+	metal3v1alpha1.RootDeviceHintContains:       {"<in>"},
+}
+
+// makeExpression converts a root device hint expression into the
+// operator syntax of ironic. The expression is expected to have been
+// validated by the webhook.
+func makeExpression(expression metal3v1alpha1.RootDeviceHintExpression) string {
+	if expression.Op == metal3v1alpha1.RootDeviceHintIn {
+		alternatives := make([]string, len(expression.Values))
+		for i, value := range expression.Values {
+			alternatives[i] = fmt.Sprintf("%s %s", orOperator, value)
+		}
+		return strings.Join(alternatives, " ")
+	}
+
+	operators := hintOperators[expression.Op]
+	op := operators[0]
+	if expression.Field == metal3v1alpha1.RootDeviceHintFieldSizeGigabytes {
+		op = operators[1]
+	}
+	value := ""
+	if len(expression.Values) > 0 {
+		value = expression.Values[0]
+	}
+	return fmt.Sprintf("%s %s", op, value)
+}
@@ -71,6 +71,67 @@ func TestMakeHintMap(t *testing.T) {
 				"size": ">= 40",
 			},
 		},
+		{
+			Scenario: "max-size",
+			Hints: metal3v1alpha1.RootDeviceHints{
+				MaxSizeGigabytes: 1000,
+			},
+			Expected: map[string]string{
+				"size": "<= 1000",
+			},
+		},
+		{
+			Scenario: "size-range",
+			Hints: metal3v1alpha1.RootDeviceHints{
+				MinSizeGigabytes: 400,
+				MaxSizeGigabytes: 1000,
+			},
+			Expected: map[string]string{
+				"size": "<range-in> [ 400 1000 ]",
+			},
+		},
+		{
+			Scenario: "by-path",
+			Hints: metal3v1alpha1.RootDeviceHints{
+				ByPath: "/dev/disk/by-path/pci-0000:00:1f.2-ata-1",
+			},
+			Expected: map[string]string{
+				"by_path": "s== /dev/disk/by-path/pci-0000:00:1f.2-ata-1",
+			},
+		},
+		{
+			Scenario: "expressions",
+			Hints: metal3v1alpha1.RootDeviceHints{
+				Expressions: []metal3v1alpha1.RootDeviceHintExpression{
+					{
+						Field:  metal3v1alpha1.RootDeviceHintFieldDeviceName,
+						Op:     metal3v1alpha1.RootDeviceHintContains,
+						Values: []string{"nvme"},
+					},
+					{
+						Field:  metal3v1alpha1.RootDeviceHintFieldSizeGigabytes,
+						Op:     metal3v1alpha1.RootDeviceHintGreaterOrEqual,
+						Values: []string{"400"},
+					},
+					{
+						Field:  metal3v1alpha1.RootDeviceHintFieldModel,
+						Op:     metal3v1alpha1.RootDeviceHintIn,
+						Values: []string{"Micron 5200", "Micron 5300"},
+					},
+					{
+						Field:  metal3v1alpha1.RootDeviceHintFieldSerialNumber,
+						Op:     metal3v1alpha1.RootDeviceHintNotEqual,
+						Values: []string{"ABC123"},
+					},
+				},
+			},
+			Expected: map[string]string{
+				"name":   "<in> nvme",
+				"size":   ">= 400",
+				"model":  "<or> Micron 5200 <or> Micron 5300",
+				"serial": "s!= ABC123",
+			},
+		},
 		{
 			Scenario: "wwn",
 			Hints: metal3v1alpha1.RootDeviceHints{
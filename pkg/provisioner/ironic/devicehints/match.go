@@ -0,0 +1,254 @@
+package devicehints
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	metal3v1alpha1 "github.com/shweta50/baremetal-operator/apis/metal3.io/v1alpha1"
+)
+
+// numericOperators compare the value of a hint as a number. Like in
+// Ironic, "=" means "at least".
+var numericOperators = map[string]func(value, hint float64) bool{
+	"=":  func(value, hint float64) bool { return value >= hint },
+	"==": func(value, hint float64) bool { return value == hint },
+	"!=": func(value, hint float64) bool { return value != hint },
+	">=": func(value, hint float64) bool { return value >= hint },
+	"<=": func(value, hint float64) bool { return value <= hint },
+	">":  func(value, hint float64) bool { return value > hint },
+	"<":  func(value, hint float64) bool { return value < hint },
+}
+
+// stringOperators compare the value of a hint as a string.
+var stringOperators = map[string]func(value, hint string) bool{
+	"s==":  func(value, hint string) bool { return value == hint },
+	"s!=":  func(value, hint string) bool { return value != hint },
+	"s>=":  func(value, hint string) bool { return value >= hint },
+	"s<=":  func(value, hint string) bool { return value <= hint },
+	"s>":   func(value, hint string) bool { return value > hint },
+	"s<":   func(value, hint string) bool { return value < hint },
+	"<in>": strings.Contains,
+}
+
+const (
+	orOperator    = "<or>"
+	rangeOperator = "<range-in>"
+)
+
+func isOperator(word string) bool {
+	_, numeric := numericOperators[word]
+	_, str := stringOperators[word]
+	return numeric || str || word == orOperator || word == rangeOperator
+}
+
+// diskValue returns the value of a disk compared to a hint, and
+// whether the hint is numeric.
+func diskValue(disk metal3v1alpha1.Storage, hint string) (value string, numeric bool, err error) {
+	switch hint {
+	case "name":
+		return disk.Name, false, nil
+	case "by_path":
+		return disk.ByPath, false, nil
+	case "hctl":
+		return disk.HCTL, false, nil
+	case "model":
+		return disk.Model, false, nil
+	case "vendor":
+		return disk.Vendor, false, nil
+	case "serial":
+		return disk.SerialNumber, false, nil
+	case "wwn":
+		return disk.WWN, false, nil
+	case "wwn_with_extension":
+		return disk.WWNWithExtension, false, nil
+	case "wwn_vendor_extension":
+		return disk.WWNVendorExtension, false, nil
+	case "rotational":
+		return strconv.FormatBool(disk.Rotational), false, nil
+	case "size":
+		// Like Ironic, sizes are in whole GiB, rounded down
+		return strconv.FormatInt(int64(disk.SizeBytes/metal3v1alpha1.GibiByte), 10), true, nil
+	}
+	return "", false, fmt.Errorf("unknown root device hint %s", hint)
+}
+
+// matchExpression evaluates a hint expression, such as "<= 100",
+// "s== /dev/sda", "<or> a <or> b" or "<range-in> [ 10 20 )", against
+// the value of a disk. An expression without operator is an exact match.
+func matchExpression(value string, numeric bool, expression string) (bool, error) {
+	words := strings.Fields(expression)
+	if len(words) == 0 {
+		return false, fmt.Errorf("empty expression")
+	}
+
+	op, operand := "", strings.Join(words, " ")
+	if isOperator(words[0]) {
+		op, operand = words[0], strings.Join(words[1:], " ")
+	}
+	if op == "" {
+		op = "s=="
+		if numeric {
+			op = "=="
+		}
+	}
+
+	if op == orOperator {
+		for _, alternative := range strings.Split(operand, orOperator) {
+			if strings.TrimSpace(alternative) == value {
+				return true, nil
+			}
+		}
+		return false, nil
+	}
+
+	if compare, found := stringOperators[op]; found {
+		return compare(value, operand), nil
+	}
+
+	number, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return false, fmt.Errorf("%q can not be compared as a number", value)
+	}
+	if op == rangeOperator {
+		return matchRange(number, words[1:])
+	}
+
+	hint, err := strconv.ParseFloat(operand, 64)
+	if err != nil {
+		return false, fmt.Errorf("%q is not a number", operand)
+	}
+	return numericOperators[op](number, hint), nil
+}
+
+// matchRange checks a number is within a range like "[ 10 20 )", where
+// square brackets include the bound and parentheses exclude it.
+func matchRange(number float64, bounds []string) (bool, error) {
+	if len(bounds) != 4 || (bounds[0] != "[" && bounds[0] != "(") || (bounds[3] != "]" && bounds[3] != ")") {
+		return false, fmt.Errorf("%q is not a range", strings.Join(bounds, " "))
+	}
+	low, err := strconv.ParseFloat(bounds[1], 64)
+	if err != nil {
+		return false, fmt.Errorf("%q is not a number", bounds[1])
+	}
+	high, err := strconv.ParseFloat(bounds[2], 64)
+	if err != nil {
+		return false, fmt.Errorf("%q is not a number", bounds[2])
+	}
+
+	aboveLow := number > low || (bounds[0] == "[" && number == low)
+	belowHigh := number < high || (bounds[3] == "]" && number == high)
+	return aboveLow && belowHigh, nil
+}
+
+// normalizeHint adjusts the value of a hint the way Ironic does before
+// comparing it to the disks.
+func normalizeHint(hint, expression string) string {
+	switch hint {
+	case "name":
+		words := strings.Fields(expression)
+		for i, word := range words {
+			if !isOperator(word) && !strings.HasPrefix(word, "/dev/") {
+				words[i] = "/dev/" + word
+			}
+		}
+		return strings.Join(words, " ")
+	case "rotational":
+		if rotational, err := strconv.ParseBool(strings.TrimSpace(expression)); err == nil {
+			return strconv.FormatBool(rotational)
+		}
+	}
+	return expression
+}
+
+// MatchHints returns the disks matching all the hints, in the form
+// built by MakeHintMap.
+func MatchHints(hints map[string]string, disks []metal3v1alpha1.Storage) ([]metal3v1alpha1.Storage, error) {
+	var matches []metal3v1alpha1.Storage
+	for _, disk := range disks {
+		matched := true
+		for hint, expression := range hints {
+			value, numeric, err := diskValue(disk, hint)
+			if err != nil {
+				return nil, err
+			}
+			ok, err := matchExpression(value, numeric, normalizeHint(hint, expression))
+			if err != nil {
+				return nil, fmt.Errorf("invalid root device hint %s: %s", hint, err)
+			}
+			if !ok {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			matches = append(matches, disk)
+		}
+	}
+	return matches, nil
+}
+
+// inspectedHints returns the hints that at least one of the disks
+// reports a value for. Inspection data does not always include every
+// field, and hints on the missing ones can not be checked.
+func inspectedHints(hints map[string]string, disks []metal3v1alpha1.Storage) (map[string]string, error) {
+	result := map[string]string{}
+	for hint, expression := range hints {
+		for _, disk := range disks {
+			value, numeric, err := diskValue(disk, hint)
+			if err != nil {
+				return nil, err
+			}
+			if (numeric && value != "0") || (!numeric && value != "") {
+				result[hint] = expression
+				break
+			}
+		}
+	}
+	return result, nil
+}
+
+// describeHints formats hints for error messages.
+func describeHints(hints map[string]string) string {
+	parts := make([]string, 0, len(hints))
+	for hint, expression := range hints {
+		parts = append(parts, fmt.Sprintf("%s %q", hint, expression))
+	}
+	sort.Strings(parts)
+	return strings.Join(parts, ", ")
+}
+
+// SelectRootDevice returns the disk the root device hints select among
+// the disks found by inspection. Ironic uses the first disk matching
+// the hints, so several matches are refused as well as none, rather
+// than leaving the choice to the order the disks are listed in. Hints
+// on fields no disk reports are ignored, and no disk is returned when
+// there are no hints left.
+func SelectRootDevice(source *metal3v1alpha1.RootDeviceHints, disks []metal3v1alpha1.Storage) (*metal3v1alpha1.Storage, error) {
+	hints, err := inspectedHints(MakeHintMap(source), disks)
+	if err != nil {
+		return nil, err
+	}
+	if len(hints) == 0 {
+		return nil, nil
+	}
+
+	matches, err := MatchHints(hints, disks)
+	if err != nil {
+		return nil, err
+	}
+	switch len(matches) {
+	case 0:
+		return nil, fmt.Errorf("no disk matches the root device hints %s", describeHints(hints))
+	case 1:
+		return &matches[0], nil
+	}
+
+	names := make([]string, len(matches))
+	for i, disk := range matches {
+		names[i] = disk.Name
+	}
+	return nil, fmt.Errorf("root device hints %s match several disks: %s",
+		describeHints(hints), strings.Join(names, ", "))
+}
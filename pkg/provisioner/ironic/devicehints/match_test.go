@@ -0,0 +1,253 @@
+package devicehints
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	metal3v1alpha1 "github.com/shweta50/baremetal-operator/apis/metal3.io/v1alpha1"
+)
+
+var testDisks = []metal3v1alpha1.Storage{
+	{
+		Name:         "/dev/sda",
+		Rotational:   true,
+		SizeBytes:    2000 * metal3v1alpha1.GibiByte,
+		Vendor:       "Seagate",
+		Model:        "ST2000NM0055",
+		SerialNumber: "ZC20AAAA",
+		HCTL:         "0:0:0:0",
+	},
+	{
+		Name:         "/dev/sdb",
+		SizeBytes:    480 * metal3v1alpha1.GibiByte,
+		Vendor:       "Micron",
+		Model:        "Micron 5200 MTFD",
+		SerialNumber: "18211D8C0001",
+		HCTL:         "0:0:1:0",
+		ByPath:       "/dev/disk/by-path/pci-0000:00:1f.2-ata-2",
+	},
+	{
+		Name:         "/dev/nvme0n1",
+		SizeBytes:    800 * metal3v1alpha1.GibiByte,
+		Model:        "Dell Express Flash NVMe",
+		SerialNumber: "PHLN0001",
+		WWN:          "eui.0100000001",
+	},
+}
+
+func matchNames(disks []metal3v1alpha1.Storage) []string {
+	names := []string{}
+	for _, disk := range disks {
+		names = append(names, disk.Name)
+	}
+	return names
+}
+
+func TestMatchHints(t *testing.T) {
+	for _, tc := range []struct {
+		Scenario      string
+		Hints         map[string]string
+		Expected      []string
+		ExpectedError string
+	}{
+		{
+			Scenario: "no hints",
+			Hints:    map[string]string{},
+			Expected: []string{"/dev/sda", "/dev/sdb", "/dev/nvme0n1"},
+		},
+		{
+			Scenario: "exact name",
+			Hints:    map[string]string{"name": "s== /dev/sdb"},
+			Expected: []string{"/dev/sdb"},
+		},
+		{
+			Scenario: "name without /dev",
+			Hints:    map[string]string{"name": "s== nvme0n1"},
+			Expected: []string{"/dev/nvme0n1"},
+		},
+		{
+			Scenario: "name without operator",
+			Hints:    map[string]string{"name": "/dev/sda"},
+			Expected: []string{"/dev/sda"},
+		},
+		{
+			Scenario: "substring with spaces",
+			Hints:    map[string]string{"model": "<in> 5200 MTFD"},
+			Expected: []string{"/dev/sdb"},
+		},
+		{
+			Scenario: "alternatives",
+			Hints:    map[string]string{"serial": "<or> ZC20AAAA <or> PHLN0001"},
+			Expected: []string{"/dev/sda", "/dev/nvme0n1"},
+		},
+		{
+			Scenario: "string inequality",
+			Hints:    map[string]string{"hctl": "s!= 0:0:0:0"},
+			Expected: []string{"/dev/sdb", "/dev/nvme0n1"},
+		},
+		{
+			Scenario: "size at most",
+			Hints:    map[string]string{"size": "<= 1000", "rotational": "false"},
+			Expected: []string{"/dev/sdb", "/dev/nvme0n1"},
+		},
+		{
+			Scenario: "size at least",
+			Hints:    map[string]string{"size": ">= 500", "rotational": "false"},
+			Expected: []string{"/dev/nvme0n1"},
+		},
+		{
+			Scenario: "size equals means at least",
+			Hints:    map[string]string{"size": "= 800"},
+			Expected: []string{"/dev/sda", "/dev/nvme0n1"},
+		},
+		{
+			Scenario: "size without operator",
+			Hints:    map[string]string{"size": "480"},
+			Expected: []string{"/dev/sdb"},
+		},
+		{
+			Scenario: "size range inclusive",
+			Hints:    map[string]string{"size": "<range-in> [ 480 800 ]"},
+			Expected: []string{"/dev/sdb", "/dev/nvme0n1"},
+		},
+		{
+			Scenario: "size range exclusive",
+			Hints:    map[string]string{"size": "<range-in> ( 480 800 ]"},
+			Expected: []string{"/dev/nvme0n1"},
+		},
+		{
+			Scenario: "by path",
+			Hints:    map[string]string{"by_path": "s== /dev/disk/by-path/pci-0000:00:1f.2-ata-2"},
+			Expected: []string{"/dev/sdb"},
+		},
+		{
+			Scenario: "name alternatives without /dev",
+			Hints:    map[string]string{"name": "<or> sda <or> nvme0n1"},
+			Expected: []string{"/dev/sda", "/dev/nvme0n1"},
+		},
+		{
+			Scenario: "rotational",
+			Hints:    map[string]string{"rotational": "True"},
+			Expected: []string{"/dev/sda"},
+		},
+		{
+			Scenario: "no match",
+			Hints:    map[string]string{"wwn": "s== eui.0200000001"},
+			Expected: []string{},
+		},
+		{
+			Scenario:      "invalid size",
+			Hints:         map[string]string{"size": ">= large"},
+			ExpectedError: "invalid root device hint size: \"large\" is not a number",
+		},
+		{
+			Scenario:      "invalid range",
+			Hints:         map[string]string{"size": "<range-in> 10 20"},
+			ExpectedError: "invalid root device hint size: \"10 20\" is not a range",
+		},
+		{
+			Scenario:      "unknown hint",
+			Hints:         map[string]string{"color": "s== red"},
+			ExpectedError: "unknown root device hint color",
+		},
+	} {
+		t.Run(tc.Scenario, func(t *testing.T) {
+			actual, err := MatchHints(tc.Hints, testDisks)
+			if tc.ExpectedError != "" {
+				assert.EqualError(t, err, tc.ExpectedError)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.Expected, matchNames(actual))
+		})
+	}
+}
+
+func TestMatchHintsSizeRoundedDown(t *testing.T) {
+	disks := []metal3v1alpha1.Storage{
+		{Name: "/dev/sda", SizeBytes: 100*metal3v1alpha1.GibiByte + metal3v1alpha1.GibiByte/2},
+	}
+
+	for _, tc := range []struct {
+		Hint     string
+		Expected []string
+	}{
+		{Hint: "== 100", Expected: []string{"/dev/sda"}},
+		{Hint: "<= 100", Expected: []string{"/dev/sda"}},
+		{Hint: "> 100", Expected: []string{}},
+		{Hint: "<range-in> ( 99 100 ]", Expected: []string{"/dev/sda"}},
+	} {
+		t.Run(tc.Hint, func(t *testing.T) {
+			actual, err := MatchHints(map[string]string{"size": tc.Hint}, disks)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.Expected, matchNames(actual))
+		})
+	}
+}
+
+func TestSelectRootDevice(t *testing.T) {
+	rotational := false
+
+	for _, tc := range []struct {
+		Scenario      string
+		Hints         *metal3v1alpha1.RootDeviceHints
+		Expected      string
+		ExpectedError string
+	}{
+		{
+			Scenario: "no hints",
+		},
+		{
+			Scenario: "single match",
+			Hints:    &metal3v1alpha1.RootDeviceHints{Vendor: "Micron"},
+			Expected: "/dev/sdb",
+		},
+		{
+			Scenario:      "no match",
+			Hints:         &metal3v1alpha1.RootDeviceHints{DeviceName: "/dev/vda"},
+			ExpectedError: "no disk matches the root device hints name \"s== /dev/vda\"",
+		},
+		{
+			Scenario: "non-rotational NVMe of at least 400 GB",
+			Hints: &metal3v1alpha1.RootDeviceHints{
+				MinSizeGigabytes: 400,
+				Rotational:       &rotational,
+				Expressions: []metal3v1alpha1.RootDeviceHintExpression{{
+					Field:  metal3v1alpha1.RootDeviceHintFieldDeviceName,
+					Op:     metal3v1alpha1.RootDeviceHintContains,
+					Values: []string{"nvme"},
+				}},
+			},
+			Expected: "/dev/nvme0n1",
+		},
+		{
+			Scenario:      "several matches",
+			Hints:         &metal3v1alpha1.RootDeviceHints{MinSizeGigabytes: 400, Rotational: &rotational},
+			ExpectedError: "root device hints rotational \"false\", size \">= 400\" match several disks: /dev/sdb, /dev/nvme0n1",
+		},
+		{
+			Scenario: "hints on fields not inspected are ignored",
+			Hints:    &metal3v1alpha1.RootDeviceHints{Vendor: "Micron", WWNVendorExtension: "0x1"},
+			Expected: "/dev/sdb",
+		},
+		{
+			Scenario: "only hints on fields not inspected",
+			Hints:    &metal3v1alpha1.RootDeviceHints{WWNWithExtension: "0x1"},
+		},
+	} {
+		t.Run(tc.Scenario, func(t *testing.T) {
+			actual, err := SelectRootDevice(tc.Hints, testDisks)
+			if tc.ExpectedError != "" {
+				assert.EqualError(t, err, tc.ExpectedError)
+				return
+			}
+			assert.NoError(t, err)
+			if tc.Expected == "" {
+				assert.Nil(t, actual)
+			} else if assert.NotNil(t, actual) {
+				assert.Equal(t, tc.Expected, actual.Name)
+			}
+		})
+	}
+}
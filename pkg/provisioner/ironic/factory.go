@@ -6,6 +6,7 @@ import (
 	"os"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/go-logr/logr"
 	"github.com/gophercloud/gophercloud"
@@ -24,6 +25,14 @@ type ironicProvisionerFactory struct {
 	// reconcilers.
 	clientIronic    *gophercloud.ServiceClient
 	clientInspector *gophercloud.ServiceClient
+	// capacity is shared by the provisioners so the busy hosts are
+	// not listed for each of them.
+	capacity *capacityCache
+
+	// backends holds the named backends read from
+	// IRONIC_BACKENDS_FILE. The fields above are then those of the
+	// default backend, if there is one.
+	backends map[string]ironicBackend
 }
 
 func NewProvisionerFactory() provisioner.Factory {
@@ -40,6 +49,10 @@ func NewProvisionerFactory() provisioner.Factory {
 }
 
 func (f *ironicProvisionerFactory) init() error {
+	if backendsFile := os.Getenv("IRONIC_BACKENDS_FILE"); backendsFile != "" {
+		return f.initBackends(backendsFile)
+	}
+
 	ironicAuth, inspectorAuth, err := clients.LoadAuth()
 	if err != nil {
 		return err
@@ -52,7 +65,12 @@ func (f *ironicProvisionerFactory) init() error {
 
 	ironicEndpoint, inspectorEndpoint, err := loadEndpointsFromEnv()
 	if err != nil {
-		return err
+		// With Keystone the endpoints can be found in the service
+		// catalog instead.
+		if (ironicEndpoint == "" && ironicAuth.Type != clients.KeystoneAuth) ||
+			(inspectorEndpoint == "" && inspectorAuth.Type != clients.KeystoneAuth) {
+			return err
+		}
 	}
 
 	tlsConf := loadTLSConfigFromEnv()
@@ -84,50 +102,81 @@ func (f *ironicProvisionerFactory) init() error {
 		return err
 	}
 
+	f.capacity = newCapacityCache(f.clientIronic, f.config.capacityRefreshInterval)
+	capacityMetrics.register(defaultBackendName, f.capacity)
+
 	return nil
 }
 
 func (f ironicProvisionerFactory) ironicProvisioner(hostData provisioner.HostData, publisher provisioner.EventPublisher) (*ironicProvisioner, error) {
+	backend, err := f.backendFor(hostData.ObjectMeta)
+	if err != nil {
+		return nil, provisioner.HostConfigError{Err: err}
+	}
+
 	provisionerLogger := f.log.WithValues("host", ironicNodeName(hostData.ObjectMeta))
+	if backend.name != "" {
+		provisionerLogger = provisionerLogger.WithValues("backend", backend.name)
+	}
 
 	p := &ironicProvisioner{
-		config:                  f.config,
+		config:                  backend.config,
 		objectMeta:              hostData.ObjectMeta,
 		nodeID:                  hostData.ProvisionerID,
 		bmcCreds:                hostData.BMCCredentials,
 		bmcAddress:              hostData.BMCAddress,
 		disableCertVerification: hostData.DisableCertificateVerification,
 		bootMACAddress:          hostData.BootMACAddress,
-		client:                  f.clientIronic,
-		inspector:               f.clientInspector,
+		conductorGroup:          strings.ToLower(hostData.ConductorGroup),
+		client:                  backend.clientIronic,
+		inspector:               backend.clientInspector,
+		capacity:                backend.capacity,
 		log:                     provisionerLogger,
 		debugLog:                provisionerLogger.V(1),
 		publisher:               publisher,
 	}
 
+	if p.capacity == nil {
+		p.capacity = newCapacityCache(p.client, 0)
+	}
+
 	return p, nil
 }
 
-// NewProvisioner returns a new Ironic Provisioner using the global
-// configuration for finding the Ironic services.
+// NewProvisioner returns a new Ironic Provisioner using the
+// configuration of the Ironic backend chosen for the host.
 func (f ironicProvisionerFactory) NewProvisioner(hostData provisioner.HostData, publisher provisioner.EventPublisher) (provisioner.Provisioner, error) {
 	return f.ironicProvisioner(hostData, publisher)
 }
 
 func loadConfigFromEnv() (ironicConfig, error) {
-	c := ironicConfig{}
+	c, err := readConfigFromEnv()
+	if err != nil {
+		return c, err
+	}
+	return c, c.validate()
+}
 
-	c.deployKernelURL = os.Getenv("DEPLOY_KERNEL_URL")
-	c.deployRamdiskURL = os.Getenv("DEPLOY_RAMDISK_URL")
-	c.deployISOURL = os.Getenv("DEPLOY_ISO_URL")
+func (c ironicConfig) validate() error {
 	if c.deployISOURL == "" &&
 		(c.deployKernelURL == "" || c.deployRamdiskURL == "") {
-		return c, errors.New("Either DEPLOY_KERNEL_URL and DEPLOY_RAMDISK_URL or DEPLOY_ISO_URL must be set")
+		return errors.New("Either DEPLOY_KERNEL_URL and DEPLOY_RAMDISK_URL or DEPLOY_ISO_URL must be set")
 	}
 	if (c.deployKernelURL == "" && c.deployRamdiskURL != "") ||
 		(c.deployKernelURL != "" && c.deployRamdiskURL == "") {
-		return c, errors.New("DEPLOY_KERNEL_URL and DEPLOY_RAMDISK_URL can only be set together")
+		return errors.New("DEPLOY_KERNEL_URL and DEPLOY_RAMDISK_URL can only be set together")
 	}
+	return nil
+}
+
+// readConfigFromEnv reads the settings without validating them, as
+// the backends can override them.
+func readConfigFromEnv() (ironicConfig, error) {
+	c := ironicConfig{}
+
+	c.deployKernelURL = os.Getenv("DEPLOY_KERNEL_URL")
+	c.deployRamdiskURL = os.Getenv("DEPLOY_RAMDISK_URL")
+	c.deployISOURL = os.Getenv("DEPLOY_ISO_URL")
 
 	c.maxBusyHosts = 20
 	if maxHostsStr := os.Getenv("PROVISIONING_LIMIT"); maxHostsStr != "" {
@@ -138,6 +187,29 @@ func loadConfigFromEnv() (ironicConfig, error) {
 		c.maxBusyHosts = value
 	}
 
+	for _, operation := range provisioner.Operations {
+		name := "PROVISIONING_LIMIT_" + strings.ToUpper(string(operation))
+		if limitStr := os.Getenv(name); limitStr != "" {
+			value, err := strconv.Atoi(limitStr)
+			if err != nil || value < 0 {
+				return c, fmt.Errorf("Invalid value set for variable %s=%s", name, limitStr)
+			}
+			if c.maxBusyHostsPerOperation == nil {
+				c.maxBusyHostsPerOperation = map[provisioner.Operation]int{}
+			}
+			c.maxBusyHostsPerOperation[operation] = value
+		}
+	}
+
+	c.capacityRefreshInterval = 10 * time.Second
+	if intervalStr := os.Getenv("PROVISIONING_LIMIT_REFRESH_INTERVAL"); intervalStr != "" {
+		value, err := time.ParseDuration(intervalStr)
+		if err != nil || value < 0 {
+			return c, fmt.Errorf("Invalid value set for variable PROVISIONING_LIMIT_REFRESH_INTERVAL=%s", intervalStr)
+		}
+		c.capacityRefreshInterval = value
+	}
+
 	return c, nil
 }
 
@@ -3,24 +3,81 @@ package hardwaredetails
 import (
 	"fmt"
 	"math"
+	"path/filepath"
 	"sort"
 	"strings"
 
+	"github.com/gophercloud/gophercloud/openstack/baremetal/v1/ports"
 	"github.com/gophercloud/gophercloud/openstack/baremetalintrospection/v1/introspection"
 
 	metal3v1alpha1 "github.com/shweta50/baremetal-operator/apis/metal3.io/v1alpha1"
 )
 
+// Data is the Ironic introspection data, with the fields not decoded by
+// gophercloud.
+type Data struct {
+	introspection.Data
+	PCIDevices []PCIDeviceType `json:"pci_devices"`
+}
+
+// PCIDeviceType describes one PCI device found by the pci-devices
+// inspection collector. Only the IDs are always reported.
+type PCIDeviceType struct {
+	VendorID    string `json:"vendor_id"`
+	ProductID   string `json:"product_id"`
+	Class       string `json:"class"`
+	Bus         string `json:"bus"`
+	NUMANode    *int   `json:"numa_node"`
+	VendorName  string `json:"vendor_name"`
+	ProductName string `json:"product_name"`
+}
+
+// ExtractData interprets an introspection data response as Data.
+func ExtractData(r introspection.DataResult) (*Data, error) {
+	var data Data
+	err := r.ExtractInto(&data)
+	return &data, err
+}
+
 // GetHardwareDetails converts Ironic introspection data into BareMetalHost HardwareDetails.
-func GetHardwareDetails(data *introspection.Data) *metal3v1alpha1.HardwareDetails {
+func GetHardwareDetails(data *Data) *metal3v1alpha1.HardwareDetails {
 	details := new(metal3v1alpha1.HardwareDetails)
 	details.Firmware = getFirmwareDetails(data.Extra.Firmware)
 	details.SystemVendor = getSystemVendorDetails(data.Inventory.SystemVendor)
 	details.RAMMebibytes = data.MemoryMB
 	details.NIC = getNICDetails(data.Inventory.Interfaces, data.AllInterfaces, data.Extra.Network)
-	details.Storage = getStorageDetails(data.Inventory.Disks)
-	details.CPU = getCPUDetails(&data.Inventory.CPU)
+	details.Storage = getStorageDetails(data.Inventory.Disks, data.Extra.Disk)
+	details.CPU = getCPUDetails(&data.Inventory.CPU, data.Extra.CPU)
 	details.Hostname = data.Inventory.Hostname
+	details.PCIDevices = getPCIDetails(data.PCIDevices)
+	details.NUMANodes = getNUMADetails(data.NUMATopology, data.Inventory.Disks, data.PCIDevices)
+	details.DIMMs = getDIMMDetails(data.Extra.Memory)
+	details.StorageFailing = len(details.FailingStorage()) > 0
+	return details
+}
+
+// GetHardwareDetailsFromNode converts the node properties and ports set
+// by out-of-band inspection into BareMetalHost HardwareDetails. The BMC
+// reports much less than the inspection agent: there is no storage,
+// firmware or vendor information and the NICs have no names.
+func GetHardwareDetailsFromNode(properties map[string]interface{}, nodePorts []ports.Port) *metal3v1alpha1.HardwareDetails {
+	details := new(metal3v1alpha1.HardwareDetails)
+	details.CPU.Arch, _ = properties["cpu_arch"].(string)
+	if cpus, ok := getExtraInt(properties, "cpus"); ok {
+		details.CPU.Count = int(cpus)
+	}
+	if memory, ok := getExtraInt(properties, "memory_mb"); ok {
+		details.RAMMebibytes = int(memory)
+	}
+	for _, port := range nodePorts {
+		details.NIC = append(details.NIC, metal3v1alpha1.NIC{
+			MAC: port.Address,
+			PXE: port.PXEEnabled,
+		})
+	}
+	sort.Slice(details.NIC, func(i, j int) bool {
+		return details.NIC[i].MAC < details.NIC[j].MAC
+	})
 	return details
 }
 
@@ -47,6 +104,20 @@ func getVLANs(intf introspection.BaseInterfaceType) (vlans []metal3v1alpha1.VLAN
 	return
 }
 
+func getLLDP(intf introspection.BaseInterfaceType) *metal3v1alpha1.LLDP {
+	if intf.LLDPProcessed == nil {
+		return nil
+	}
+	var lldp metal3v1alpha1.LLDP
+	lldp.SwitchID, _ = intf.LLDPProcessed["switch_chassis_id"].(string)
+	lldp.PortID, _ = intf.LLDPProcessed["switch_port_id"].(string)
+	lldp.SwitchSystemName, _ = intf.LLDPProcessed["switch_system_name"].(string)
+	if lldp == (metal3v1alpha1.LLDP{}) {
+		return nil
+	}
+	return &lldp
+}
+
 func getNICSpeedGbps(intfExtradata introspection.ExtraHardwareData) (speedGbps int) {
 	if speed, ok := intfExtradata["speed"].(string); ok {
 		if strings.HasSuffix(speed, "Gbps") {
@@ -63,6 +134,7 @@ func getNICDetails(ifdata []introspection.InterfaceType,
 	for _, intf := range ifdata {
 		baseIntf := basedata[intf.Name]
 		vlans, vlanid := getVLANs(baseIntf)
+		lldp := getLLDP(baseIntf)
 		// We still store one nic even if both ips are unset
 		// if both are set, we store two nics with each ip
 		if intf.IPV4Address != "" || intf.IPV6Address == "" {
@@ -76,6 +148,7 @@ func getNICDetails(ifdata []introspection.InterfaceType,
 				VLANID:    vlanid,
 				SpeedGbps: getNICSpeedGbps(extradata[intf.Name]),
 				PXE:       baseIntf.PXE,
+				LLDP:      lldp,
 			})
 		}
 		if intf.IPV6Address != "" {
@@ -89,6 +162,7 @@ func getNICDetails(ifdata []introspection.InterfaceType,
 				VLANID:    vlanid,
 				SpeedGbps: getNICSpeedGbps(extradata[intf.Name]),
 				PXE:       baseIntf.PXE,
+				LLDP:      lldp.DeepCopy(),
 			})
 		}
 	}
@@ -107,7 +181,69 @@ func getDiskType(diskdata introspection.RootDiskType) metal3v1alpha1.DiskType {
 	return metal3v1alpha1.SSD
 }
 
-func getStorageDetails(diskdata []introspection.RootDiskType) []metal3v1alpha1.Storage {
+// getStorageHealth reads the SMART data collected for a disk by the
+// extra-hardware collector. ATA and SCSI devices report an overall
+// health, NVMe devices a critical warning bitmap.
+func getStorageHealth(diskExtradata introspection.ExtraHardwareData) *metal3v1alpha1.StorageHealth {
+	var health metal3v1alpha1.StorageHealth
+	found := false
+
+	// smartctl reports "PASSED" or "FAILED!" for ATA devices and "OK"
+	// for SCSI ones. Other values, such as those of devices not
+	// supporting the self-assessment, say nothing about the health of
+	// the device.
+	if overall, ok := diskExtradata["SMART/overall_health"].(string); ok {
+		switch strings.TrimRight(strings.ToUpper(overall), "!") {
+		case "PASSED", "OK":
+			found = true
+			health.Status = metal3v1alpha1.StorageHealthOK
+		case "FAILED":
+			found = true
+			health.Status = metal3v1alpha1.StorageHealthFailing
+		}
+	}
+	if warning, ok := getExtraInt(diskExtradata, "SMART/critical_warning"); ok {
+		found = true
+		if warning != 0 {
+			health.Status = metal3v1alpha1.StorageHealthFailing
+		} else if health.Status == "" {
+			health.Status = metal3v1alpha1.StorageHealthOK
+		}
+	}
+	if sectors, ok := getExtraInt(diskExtradata, "SMART/Reallocated_Sector_Ct(5)/raw"); ok {
+		found = true
+		health.ReallocatedSectors = &sectors
+	}
+	if used, ok := getExtraInt(diskExtradata, "SMART/percentage_used"); ok {
+		found = true
+		wear := int(used)
+		health.WearLevelPercent = &wear
+	}
+	if mediaErrors, ok := getExtraInt(diskExtradata, "SMART/media_errors"); ok {
+		found = true
+		health.MediaErrors = &mediaErrors
+	}
+
+	if !found {
+		return nil
+	}
+	return &health
+}
+
+// getNVMeNamespace parses the name of an NVMe namespace device such as
+// "/dev/nvme0n1".
+func getNVMeNamespace(name string) *metal3v1alpha1.NVMeNamespace {
+	var controller, namespaceID int
+	if _, err := fmt.Sscanf(strings.TrimPrefix(name, "/dev/"), "nvme%dn%d", &controller, &namespaceID); err != nil {
+		return nil
+	}
+	return &metal3v1alpha1.NVMeNamespace{
+		Controller:  fmt.Sprintf("nvme%d", controller),
+		NamespaceID: namespaceID,
+	}
+}
+
+func getStorageDetails(diskdata []introspection.RootDiskType, extradata introspection.ExtraHardwareDataSection) []metal3v1alpha1.Storage {
 	storage := make([]metal3v1alpha1.Storage, len(diskdata))
 	for i, disk := range diskdata {
 		storage[i] = metal3v1alpha1.Storage{
@@ -122,6 +258,11 @@ func getStorageDetails(diskdata []introspection.RootDiskType) []metal3v1alpha1.S
 			WWNVendorExtension: disk.WwnVendorExtension,
 			WWNWithExtension:   disk.WwnWithExtension,
 			HCTL:               disk.Hctl,
+			ByPath:             disk.ByPath,
+			Health:             getStorageHealth(extradata[strings.TrimPrefix(disk.Name, "/dev/")]),
+		}
+		if storage[i].Type == metal3v1alpha1.NVME {
+			storage[i].NVMe = getNVMeNamespace(disk.Name)
 		}
 	}
 	return storage
@@ -135,7 +276,7 @@ func getSystemVendorDetails(vendor introspection.SystemVendorType) metal3v1alpha
 	}
 }
 
-func getCPUDetails(cpudata *introspection.CPUType) metal3v1alpha1.CPU {
+func getCPUDetails(cpudata *introspection.CPUType, extradata introspection.ExtraHardwareDataSection) metal3v1alpha1.CPU {
 	var freq float64
 	fmt.Sscanf(cpudata.Frequency, "%f", &freq)
 	freq = math.Round(freq) // Ensure freq has no fractional part
@@ -146,11 +287,197 @@ func getCPUDetails(cpudata *introspection.CPUType) metal3v1alpha1.CPU {
 		ClockMegahertz: metal3v1alpha1.ClockSpeed(freq) * metal3v1alpha1.MegaHertz,
 		Count:          cpudata.Count,
 		Flags:          cpudata.Flags,
+		Sockets:        getCPUSockets(extradata),
 	}
 
 	return cpu
 }
 
+// getExtraInt returns an integer of the extra hardware data, which may
+// be reported as a number or as a string.
+func getExtraInt(data introspection.ExtraHardwareData, key string) (value int64, ok bool) {
+	switch v := data[key].(type) {
+	case float64:
+		return int64(v), true
+	case int:
+		return int64(v), true
+	case int64:
+		return v, true
+	case string:
+		_, err := fmt.Sscanf(v, "%d", &value)
+		return value, err == nil
+	}
+	return 0, false
+}
+
+// getExtraIndex returns the index of an extra hardware data entry
+// named prefix followed by a number, such as "physical_0".
+func getExtraIndex(name, prefix string) (index int, ok bool) {
+	if !strings.HasPrefix(name, prefix) {
+		return 0, false
+	}
+	_, err := fmt.Sscanf(name[len(prefix):], "%d", &index)
+	return index, err == nil
+}
+
+func getCPUSockets(extradata introspection.ExtraHardwareDataSection) []metal3v1alpha1.CPUSocket {
+	var sockets []metal3v1alpha1.CPUSocket
+	for name, data := range extradata {
+		id, ok := getExtraIndex(name, "physical_")
+		if !ok {
+			continue
+		}
+		socket := metal3v1alpha1.CPUSocket{ID: id}
+		socket.Model, _ = data["product"].(string)
+		if cores, ok := getExtraInt(data, "cores"); ok {
+			socket.Cores = int(cores)
+		}
+		if threads, ok := getExtraInt(data, "threads"); ok {
+			socket.Threads = int(threads)
+		}
+		sockets = append(sockets, socket)
+	}
+	sort.Slice(sockets, func(i, j int) bool {
+		return sockets[i].ID < sockets[j].ID
+	})
+	return sockets
+}
+
+// diskPCIAddress returns the PCI address of the controller of a disk,
+// from a by-path link such as /dev/disk/by-path/pci-0000:3b:00.0-nvme-1.
+func diskPCIAddress(byPath string) string {
+	name := strings.TrimPrefix(filepath.Base(byPath), "pci-")
+	if name == filepath.Base(byPath) {
+		return ""
+	}
+	return strings.SplitN(name, "-", 2)[0]
+}
+
+// getNUMADetails groups the resources of the host by NUMA node. Disks
+// are not part of the NUMA topology reported by Ironic, they are placed
+// with the NUMA node of their PCI controller when it is known.
+func getNUMADetails(topology introspection.NUMATopology, disks []introspection.RootDiskType, pciDevices []PCIDeviceType) []metal3v1alpha1.NUMANode {
+	nodesByID := map[int]*metal3v1alpha1.NUMANode{}
+	numaNode := func(id int) *metal3v1alpha1.NUMANode {
+		if _, ok := nodesByID[id]; !ok {
+			nodesByID[id] = &metal3v1alpha1.NUMANode{ID: id}
+		}
+		return nodesByID[id]
+	}
+
+	for _, cpu := range topology.CPUs {
+		node := numaNode(cpu.NUMANode)
+		if len(cpu.ThreadSiblings) == 0 {
+			node.CPUs = append(node.CPUs, cpu.CPU)
+		} else {
+			node.CPUs = append(node.CPUs, cpu.ThreadSiblings...)
+		}
+	}
+	for _, ram := range topology.RAM {
+		numaNode(ram.NUMANode).MemoryMebibytes += ram.SizeKB / 1024
+	}
+	for _, nic := range topology.NICs {
+		node := numaNode(nic.NUMANode)
+		node.NICs = append(node.NICs, nic.Name)
+	}
+
+	pciNUMANodes := map[string]int{}
+	for _, dev := range pciDevices {
+		if dev.NUMANode != nil {
+			pciNUMANodes[dev.Bus] = *dev.NUMANode
+		}
+	}
+	for _, disk := range disks {
+		id, ok := pciNUMANodes[diskPCIAddress(disk.ByPath)]
+		if !ok {
+			continue
+		}
+		if node, ok := nodesByID[id]; ok {
+			node.Disks = append(node.Disks, disk.Name)
+		}
+	}
+
+	var numaNodes []metal3v1alpha1.NUMANode
+	for _, node := range nodesByID {
+		sort.Ints(node.CPUs)
+		sort.Strings(node.NICs)
+		sort.Strings(node.Disks)
+		numaNodes = append(numaNodes, *node)
+	}
+	sort.Slice(numaNodes, func(i, j int) bool {
+		return numaNodes[i].ID < numaNodes[j].ID
+	})
+	return numaNodes
+}
+
+// getDIMMType finds the memory type in a description such as
+// "DIMM DDR4 Synchronous 2666 MHz (0.4 ns)".
+func getDIMMType(description string) string {
+	for _, word := range strings.Fields(description) {
+		if strings.Contains(word, "DDR") {
+			return word
+		}
+	}
+	return ""
+}
+
+func getDIMMDetails(extradata introspection.ExtraHardwareDataSection) []metal3v1alpha1.DIMM {
+	type bank struct {
+		index int
+		dimm  metal3v1alpha1.DIMM
+	}
+	var banks []bank
+	for name, data := range extradata {
+		index, ok := getExtraIndex(name, "bank:")
+		if !ok {
+			continue
+		}
+		size, _ := getExtraInt(data, "size")
+		if size <= 0 {
+			// Empty slot
+			continue
+		}
+		dimm := metal3v1alpha1.DIMM{SizeMebibytes: int(size / (1024 * 1024))}
+		dimm.Slot, _ = data["slot"].(string)
+		if clock, ok := getExtraInt(data, "clock"); ok {
+			dimm.SpeedMegahertz = metal3v1alpha1.ClockSpeed(clock/1000000) * metal3v1alpha1.MegaHertz
+		}
+		description, _ := data["description"].(string)
+		dimm.Type = getDIMMType(description)
+		banks = append(banks, bank{index: index, dimm: dimm})
+	}
+	sort.Slice(banks, func(i, j int) bool {
+		return banks[i].index < banks[j].index
+	})
+
+	var dimms []metal3v1alpha1.DIMM
+	for _, b := range banks {
+		dimms = append(dimms, b.dimm)
+	}
+	return dimms
+}
+
+func getPCIDetails(pcidata []PCIDeviceType) []metal3v1alpha1.PCIDevice {
+	if len(pcidata) == 0 {
+		return nil
+	}
+	devices := make([]metal3v1alpha1.PCIDevice, len(pcidata))
+	for i, dev := range pcidata {
+		devices[i] = metal3v1alpha1.PCIDevice{
+			Address:  dev.Bus,
+			VendorID: strings.TrimPrefix(dev.VendorID, "0x"),
+			DeviceID: strings.TrimPrefix(dev.ProductID, "0x"),
+			Class:    strings.TrimPrefix(dev.Class, "0x"),
+			Model:    strings.TrimSpace(fmt.Sprintf("%s %s", dev.VendorName, dev.ProductName)),
+			NUMANode: dev.NUMANode,
+		}
+	}
+	sort.SliceStable(devices, func(i, j int) bool {
+		return devices[i].Address < devices[j].Address
+	})
+	return devices
+}
+
 func getFirmwareDetails(firmwaredata introspection.ExtraHardwareDataSection) metal3v1alpha1.Firmware {
 
 	// handle bios optionally
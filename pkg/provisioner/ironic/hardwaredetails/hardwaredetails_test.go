@@ -1,9 +1,12 @@
 package hardwaredetails
 
 import (
+	"encoding/json"
 	"reflect"
 	"testing"
 
+	"github.com/gophercloud/gophercloud"
+	"github.com/gophercloud/gophercloud/openstack/baremetal/v1/ports"
 	"github.com/gophercloud/gophercloud/openstack/baremetalintrospection/v1/introspection"
 
 	metal3v1alpha1 "github.com/shweta50/baremetal-operator/apis/metal3.io/v1alpha1"
@@ -178,6 +181,14 @@ func TestGetNICDetails(t *testing.T) {
 						},
 					},
 					"switch_port_untagged_vlan_id": 1,
+					"switch_chassis_id":            "00:1c:73:aa:bb:cc",
+					"switch_port_id":               "Ethernet12",
+					"switch_system_name":           "leaf-1",
+				},
+			},
+			"eth1": {
+				LLDPProcessed: map[string]interface{}{
+					"switch_port_description": "uplink",
 				},
 			},
 		},
@@ -200,6 +211,11 @@ func TestGetNICDetails(t *testing.T) {
 			{ID: 1},
 		},
 		VLANID: 1,
+		LLDP: &metal3v1alpha1.LLDP{
+			SwitchID:         "00:1c:73:aa:bb:cc",
+			PortID:           "Ethernet12",
+			SwitchSystemName: "leaf-1",
+		},
 	})) {
 		t.Errorf("Unexpected NIC data")
 	}
@@ -304,3 +320,265 @@ func TestGetFirmwareDetails(t *testing.T) {
 	}
 
 }
+
+func TestGetPCIDetails(t *testing.T) {
+	numaNode := 1
+	devices := getPCIDetails([]PCIDeviceType{
+		{
+			VendorID:    "0x10de",
+			ProductID:   "0x20b0",
+			Class:       "0x0302",
+			Bus:         "0000:af:00.0",
+			NUMANode:    &numaNode,
+			VendorName:  "NVIDIA Corporation",
+			ProductName: "GA100 [A100 SXM4 40GB]",
+		},
+		{
+			VendorID:  "8086",
+			ProductID: "1572",
+		},
+	})
+
+	expected := []metal3v1alpha1.PCIDevice{
+		{
+			VendorID: "8086",
+			DeviceID: "1572",
+		},
+		{
+			Address:  "0000:af:00.0",
+			VendorID: "10de",
+			DeviceID: "20b0",
+			Class:    "0302",
+			Model:    "NVIDIA Corporation GA100 [A100 SXM4 40GB]",
+			NUMANode: &numaNode,
+		},
+	}
+	if !reflect.DeepEqual(expected, devices) {
+		t.Errorf("Unexpected PCI devices %+v", devices)
+	}
+
+	if devices = getPCIDetails(nil); devices != nil {
+		t.Errorf("Expected no PCI devices but got: %+v", devices)
+	}
+}
+
+func TestExtractData(t *testing.T) {
+	var body interface{}
+	err := json.Unmarshal([]byte(`{
+		"memory_mb": 8192,
+		"inventory": {"hostname": "node-1"},
+		"pci_devices": [{"vendor_id": "10de", "product_id": "20b0", "bus": "0000:af:00.0", "numa_node": 1}]
+	}`), &body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := ExtractData(introspection.DataResult{Result: gophercloud.Result{Body: body}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	details := GetHardwareDetails(data)
+	if details.RAMMebibytes != 8192 || details.Hostname != "node-1" {
+		t.Errorf("Unexpected details %+v", details)
+	}
+	if len(details.PCIDevices) != 1 || details.PCIDevices[0].DeviceID != "20b0" ||
+		details.PCIDevices[0].NUMANode == nil || *details.PCIDevices[0].NUMANode != 1 {
+		t.Errorf("Unexpected PCI devices %+v", details.PCIDevices)
+	}
+}
+
+func TestGetCPUSockets(t *testing.T) {
+	cpu := getCPUDetails(&introspection.CPUType{Count: 80}, introspection.ExtraHardwareDataSection{
+		"logical":  {"number": 80},
+		"physical": {"number": 2},
+		"physical_1": {
+			"product": "Intel(R) Xeon(R) Gold 6230 CPU @ 2.10GHz",
+			"cores":   "20",
+			"threads": "40",
+		},
+		"physical_0": {
+			"product": "Intel(R) Xeon(R) Gold 6230 CPU @ 2.10GHz",
+			"cores":   float64(20),
+			"threads": float64(40),
+		},
+	})
+
+	expected := []metal3v1alpha1.CPUSocket{
+		{ID: 0, Model: "Intel(R) Xeon(R) Gold 6230 CPU @ 2.10GHz", Cores: 20, Threads: 40},
+		{ID: 1, Model: "Intel(R) Xeon(R) Gold 6230 CPU @ 2.10GHz", Cores: 20, Threads: 40},
+	}
+	if !reflect.DeepEqual(expected, cpu.Sockets) {
+		t.Errorf("Unexpected CPU sockets %+v", cpu.Sockets)
+	}
+
+	cpu = getCPUDetails(&introspection.CPUType{Count: 4}, nil)
+	if cpu.Sockets != nil {
+		t.Errorf("Expected no CPU sockets but got: %+v", cpu.Sockets)
+	}
+}
+
+func TestGetNUMADetails(t *testing.T) {
+	numaNode0, numaNode1 := 0, 1
+	numaNodes := getNUMADetails(introspection.NUMATopology{
+		CPUs: []introspection.NUMACPU{
+			{CPU: 1, NUMANode: 1, ThreadSiblings: []int{3, 1}},
+			{CPU: 0, NUMANode: 0, ThreadSiblings: []int{0, 2}},
+		},
+		RAM: []introspection.NUMARAM{
+			{NUMANode: 0, SizeKB: 16 * 1024 * 1024},
+			{NUMANode: 1, SizeKB: 8 * 1024 * 1024},
+		},
+		NICs: []introspection.NUMANIC{
+			{Name: "eth1", NUMANode: 1},
+			{Name: "eth0", NUMANode: 1},
+		},
+	}, []introspection.RootDiskType{
+		{Name: "/dev/nvme0n1", ByPath: "/dev/disk/by-path/pci-0000:af:00.0-nvme-1"},
+		{Name: "/dev/sda", ByPath: "/dev/disk/by-path/pci-0000:00:17.0-ata-1"},
+		{Name: "/dev/sdb", ByPath: "/dev/disk/by-path/pci-0000:00:17.0-ata-2"},
+		{Name: "/dev/sdc", ByPath: "/dev/disk/by-path/pci-0000:00:14.0-usb-0:1:1.0-scsi-0:0:0:0"},
+		{Name: "/dev/vda"},
+	}, []PCIDeviceType{
+		{Bus: "0000:00:17.0", NUMANode: &numaNode0},
+		{Bus: "0000:00:14.0"},
+		{Bus: "0000:af:00.0", NUMANode: &numaNode1},
+	})
+
+	expected := []metal3v1alpha1.NUMANode{
+		{ID: 0, CPUs: []int{0, 2}, MemoryMebibytes: 16384, Disks: []string{"/dev/sda", "/dev/sdb"}},
+		{ID: 1, CPUs: []int{1, 3}, MemoryMebibytes: 8192, NICs: []string{"eth0", "eth1"}, Disks: []string{"/dev/nvme0n1"}},
+	}
+	if !reflect.DeepEqual(expected, numaNodes) {
+		t.Errorf("Unexpected NUMA nodes %+v", numaNodes)
+	}
+
+	if numaNodes = getNUMADetails(introspection.NUMATopology{}, nil, nil); numaNodes != nil {
+		t.Errorf("Expected no NUMA nodes but got: %+v", numaNodes)
+	}
+}
+
+func TestGetDIMMDetails(t *testing.T) {
+	dimms := getDIMMDetails(introspection.ExtraHardwareDataSection{
+		"total": {"size": float64(32 * 1024 * 1024 * 1024)},
+		"bank:10": {
+			"size":        "17179869184",
+			"slot":        "DIMM_B1",
+			"clock":       "2666000000",
+			"description": "DIMM DDR4 Synchronous Registered (Buffered) 2666 MHz (0.4 ns)",
+		},
+		"bank:2": {
+			"size":        float64(17179869184),
+			"slot":        "DIMM_A1",
+			"clock":       float64(2666000000),
+			"description": "DIMM DDR4 Synchronous Registered (Buffered) 2666 MHz (0.4 ns)",
+		},
+		"bank:3": {
+			"slot":        "DIMM_A2",
+			"description": "[empty]",
+		},
+	})
+
+	expected := []metal3v1alpha1.DIMM{
+		{Slot: "DIMM_A1", SizeMebibytes: 16384, SpeedMegahertz: 2666, Type: "DDR4"},
+		{Slot: "DIMM_B1", SizeMebibytes: 16384, SpeedMegahertz: 2666, Type: "DDR4"},
+	}
+	if !reflect.DeepEqual(expected, dimms) {
+		t.Errorf("Unexpected DIMMs %+v", dimms)
+	}
+}
+
+func TestGetStorageDetails(t *testing.T) {
+	storage := getStorageDetails(
+		[]introspection.RootDiskType{
+			{Name: "/dev/sda", Rotational: true},
+			{Name: "/dev/sdb"},
+			{Name: "/dev/nvme1n2"},
+			{Name: "/dev/sdc"},
+			{Name: "/dev/sdd"},
+		},
+		introspection.ExtraHardwareDataSection{
+			"sda": {
+				"SMART/overall_health":               "PASSED",
+				"SMART/Reallocated_Sector_Ct(5)/raw": "8",
+			},
+			"sdb": {
+				"SMART/overall_health": "FAILED!",
+			},
+			"sdd": {
+				"SMART/overall_health": "UNKNOWN!",
+			},
+			"nvme1n2": {
+				"SMART/critical_warning": float64(0),
+				"SMART/percentage_used":  float64(12),
+				"SMART/media_errors":     float64(0),
+			},
+		})
+
+	reallocated, wear, mediaErrors := int64(8), 12, int64(0)
+	expected := []metal3v1alpha1.Storage{
+		{
+			Name:       "/dev/sda",
+			Rotational: true,
+			Type:       metal3v1alpha1.HDD,
+			Health: &metal3v1alpha1.StorageHealth{
+				Status:             metal3v1alpha1.StorageHealthOK,
+				ReallocatedSectors: &reallocated,
+			},
+		},
+		{
+			Name:   "/dev/sdb",
+			Type:   metal3v1alpha1.SSD,
+			Health: &metal3v1alpha1.StorageHealth{Status: metal3v1alpha1.StorageHealthFailing},
+		},
+		{
+			Name: "/dev/nvme1n2",
+			Type: metal3v1alpha1.NVME,
+			Health: &metal3v1alpha1.StorageHealth{
+				Status:           metal3v1alpha1.StorageHealthOK,
+				WearLevelPercent: &wear,
+				MediaErrors:      &mediaErrors,
+			},
+			NVMe: &metal3v1alpha1.NVMeNamespace{Controller: "nvme1", NamespaceID: 2},
+		},
+		{
+			Name: "/dev/sdc",
+			Type: metal3v1alpha1.SSD,
+		},
+		{
+			Name: "/dev/sdd",
+			Type: metal3v1alpha1.SSD,
+		},
+	}
+	if !reflect.DeepEqual(expected, storage) {
+		t.Errorf("Unexpected storage %+v", storage)
+	}
+
+	details := metal3v1alpha1.HardwareDetails{Storage: storage}
+	if failing := details.FailingStorage(); !reflect.DeepEqual([]string{"/dev/sdb"}, failing) {
+		t.Errorf("Unexpected failing storage %v", failing)
+	}
+}
+
+func TestGetHardwareDetailsFromNode(t *testing.T) {
+	details := GetHardwareDetailsFromNode(map[string]interface{}{
+		"cpu_arch":  "x86_64",
+		"cpus":      "32",
+		"memory_mb": float64(131072),
+		"local_gb":  float64(446),
+	}, []ports.Port{
+		{Address: "3c:fd:fe:a0:00:02"},
+		{Address: "3c:fd:fe:a0:00:01", PXEEnabled: true},
+	})
+
+	expected := &metal3v1alpha1.HardwareDetails{
+		CPU:          metal3v1alpha1.CPU{Arch: "x86_64", Count: 32},
+		RAMMebibytes: 131072,
+		NIC: []metal3v1alpha1.NIC{
+			{MAC: "3c:fd:fe:a0:00:01", PXE: true},
+			{MAC: "3c:fd:fe:a0:00:02"},
+		},
+	}
+	if !reflect.DeepEqual(expected, details) {
+		t.Errorf("Unexpected hardware details %+v", details)
+	}
+}
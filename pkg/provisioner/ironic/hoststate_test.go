@@ -0,0 +1,74 @@
+package ironic
+
+import (
+	"testing"
+
+	"github.com/gophercloud/gophercloud/openstack/baremetal/v1/nodes"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/shweta50/baremetal-operator/pkg/bmc"
+	"github.com/shweta50/baremetal-operator/pkg/provisioner"
+	"github.com/shweta50/baremetal-operator/pkg/provisioner/ironic/clients"
+	"github.com/shweta50/baremetal-operator/pkg/provisioner/ironic/testserver"
+)
+
+func TestLastHostState(t *testing.T) {
+	nodeUUID := "33ce8659-7400-4c68-9535-d10766f07a58"
+	cases := []struct {
+		name     string
+		node     nodes.Node
+		expected provisioner.HostState
+	}{
+		{
+			name: "deploy step",
+			node: nodes.Node{
+				UUID:                 nodeUUID,
+				ProvisionState:       string(nodes.DeployWait),
+				TargetProvisionState: string(nodes.TargetActive),
+				DeployStep:           map[string]interface{}{"interface": "deploy", "step": "write_image", "priority": 80},
+				CleanStep:            map[string]interface{}{},
+			},
+			expected: provisioner.HostState{State: "wait call-back", Target: "active", Step: "deploy.write_image"},
+		},
+		{
+			name: "clean step failed",
+			node: nodes.Node{
+				UUID:           nodeUUID,
+				ProvisionState: string(nodes.CleanFail),
+				CleanStep:      map[string]interface{}{"interface": "raid", "step": "create_configuration"},
+				LastError:      "RAID controller not found",
+			},
+			expected: provisioner.HostState{
+				State:     "clean failed",
+				Step:      "raid.create_configuration",
+				LastError: "RAID controller not found",
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			ironic := testserver.NewIronic(t).Node(tc.node)
+			ironic.Start()
+			defer ironic.Stop()
+
+			inspector := testserver.NewInspector(t).Ready().Start()
+			defer inspector.Stop()
+
+			host := makeHost()
+			host.Status.Provisioning.ID = nodeUUID
+			auth := clients.AuthConfig{Type: clients.NoAuth}
+			prov, err := newProvisionerWithSettings(host, bmc.Credentials{}, nullEventPublisher,
+				ironic.Endpoint(), auth, inspector.Endpoint(), auth)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			assert.Nil(t, prov.LastHostState())
+			if _, err = prov.getNode(); err != nil {
+				t.Fatal(err)
+			}
+			assert.Equal(t, &tc.expected, prov.LastHostState())
+		})
+	}
+}
@@ -13,6 +13,7 @@ import (
 	metal3v1alpha1 "github.com/shweta50/baremetal-operator/apis/metal3.io/v1alpha1"
 
 	"github.com/gophercloud/gophercloud/openstack/baremetal/v1/nodes"
+	"github.com/gophercloud/gophercloud/openstack/baremetal/v1/ports"
 	"github.com/gophercloud/gophercloud/openstack/baremetalintrospection/v1/introspection"
 	"github.com/stretchr/testify/assert"
 )
@@ -245,3 +246,110 @@ func TestInspectHardware(t *testing.T) {
 		})
 	}
 }
+
+func TestInspectHardwareOutOfBand(t *testing.T) {
+	sim := testserver.NewSimulator(t).Start()
+	defer sim.Stop()
+	sim.IntrospectionData = func(bootMAC string) introspection.Data {
+		data := testserver.DefaultIntrospectionData(bootMAC)
+		data.AllInterfaces["eth1"] = introspection.BaseInterfaceType{MAC: "11:22:33:44:55:77"}
+		return data
+	}
+
+	host := makeHost()
+	host.Spec.BMC.Address = "redfish://test.bmc/redfish/v1/Systems/1"
+	auth := clients.AuthConfig{Type: clients.NoAuth}
+	prov, err := newProvisionerWithSettings(host, bmc.Credentials{}, nullEventPublisher,
+		sim.Endpoint(), auth, sim.InspectorEndpoint(), auth)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	node, err := nodes.Create(prov.client, nodes.CreateOpts{
+		Name:             "myns~myhost",
+		Driver:           "redfish",
+		InspectInterface: "inspector",
+	}).Extract()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = ports.Create(prov.client, ports.CreateOpts{NodeUUID: node.UUID, Address: "11:22:33:44:55:66"}).Extract(); err != nil {
+		t.Fatal(err)
+	}
+	if err = nodes.ChangeProvisionState(prov.client, node.UUID, nodes.ProvisionStateOpts{Target: nodes.TargetManage}).ExtractErr(); err != nil {
+		t.Fatal(err)
+	}
+	sim.Tick()
+
+	host.Status.Provisioning.ID = node.UUID
+	prov, err = newProvisionerWithSettings(host, bmc.Credentials{}, nullEventPublisher,
+		sim.Endpoint(), auth, sim.InspectorEndpoint(), auth)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := provisioner.InspectData{
+		BootMode: metal3v1alpha1.DefaultBootMode,
+		Mode:     metal3v1alpha1.InspectionModeOutOfBand,
+	}
+
+	result, started, details, err := prov.InspectHardware(data, false, false)
+	assert.NoError(t, err)
+	assert.True(t, started)
+	assert.True(t, result.Dirty)
+	assert.Nil(t, details)
+	inspected, _ := sim.Node(node.UUID)
+	assert.Equal(t, "redfish", inspected.InspectInterface)
+	assert.Equal(t, string(nodes.Inspecting), inspected.ProvisionState)
+
+	result, started, details, err = prov.InspectHardware(data, false, false)
+	assert.NoError(t, err)
+	assert.False(t, started)
+	assert.True(t, result.Dirty)
+	assert.Nil(t, details)
+
+	sim.Tick()
+	result, started, details, err = prov.InspectHardware(data, false, false)
+	assert.NoError(t, err)
+	assert.False(t, started)
+	assert.False(t, result.Dirty)
+	assert.Equal(t, &metal3v1alpha1.HardwareDetails{
+		CPU:          metal3v1alpha1.CPU{Arch: "x86_64", Count: 4},
+		RAMMebibytes: 8192,
+		NIC: []metal3v1alpha1.NIC{
+			{MAC: "11:22:33:44:55:66", PXE: true},
+			{MAC: "11:22:33:44:55:77"},
+		},
+	}, details)
+
+	// Switching back to in-band inspection restores the inspector
+	data.Mode = metal3v1alpha1.InspectionModeInBand
+	_, started, _, err = prov.InspectHardware(data, false, true)
+	assert.NoError(t, err)
+	assert.True(t, started)
+	inspected, _ = sim.Node(node.UUID)
+	assert.Equal(t, "inspector", inspected.InspectInterface)
+}
+
+func TestInspectHardwareOutOfBandUnsupported(t *testing.T) {
+	nodeUUID := "33ce8659-7400-4c68-9535-d10766f07a58"
+	ironic := testserver.NewIronic(t).Ready().Node(nodes.Node{
+		UUID:           nodeUUID,
+		ProvisionState: string(nodes.Manageable),
+	})
+	ironic.Start()
+	defer ironic.Stop()
+
+	host := makeHost()
+	host.Status.Provisioning.ID = nodeUUID
+	auth := clients.AuthConfig{Type: clients.NoAuth}
+	prov, err := newProvisionerWithSettings(host, bmc.Credentials{}, nullEventPublisher,
+		ironic.Endpoint(), auth, "http://inspector.test/v1/", auth)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result, started, _, err := prov.InspectHardware(provisioner.InspectData{Mode: metal3v1alpha1.InspectionModeOutOfBand}, false, false)
+	assert.NoError(t, err)
+	assert.False(t, started)
+	assert.Equal(t, "BMC driver test does not support out-of-band inspection", result.ErrorMessage)
+}
@@ -0,0 +1,98 @@
+package ironic
+
+import (
+	"fmt"
+
+	"github.com/gophercloud/gophercloud/openstack/baremetal/v1/nodes"
+	"github.com/pkg/errors"
+
+	metal3v1alpha1 "github.com/shweta50/baremetal-operator/apis/metal3.io/v1alpha1"
+	"github.com/shweta50/baremetal-operator/pkg/provisioner"
+	"github.com/shweta50/baremetal-operator/pkg/provisioner/ironic/hardwaredetails"
+)
+
+// inBandInspectInterface is the inspect interface booting the
+// inspection agent on the host.
+const inBandInspectInterface = "inspector"
+
+// inspectOutOfBand inspects the host through its BMC. Ironic stores the
+// results in the node properties and creates a port for each NIC found,
+// instead of reporting introspection data.
+func (p *ironicProvisioner) inspectOutOfBand(ironicNode *nodes.Node, data provisioner.InspectData, force, refresh bool) (result provisioner.Result, started bool, details *metal3v1alpha1.HardwareDetails, err error) {
+	bmcAccess, err := p.bmcAccess()
+	if err != nil {
+		result, err = operationFailed(err.Error())
+		return
+	}
+	inspectInterface := bmcAccess.OutOfBandInspectInterface()
+	if inspectInterface == "" {
+		result, err = operationFailed(fmt.Sprintf("BMC driver %s does not support out-of-band inspection", bmcAccess.Type()))
+		return
+	}
+
+	switch nodes.ProvisionState(ironicNode.ProvisionState) {
+	case nodes.Available:
+		result, err = p.changeNodeProvisionState(
+			ironicNode,
+			nodes.ProvisionStateOpts{Target: nodes.TargetManage},
+		)
+		return
+	case nodes.Inspecting, nodes.InspectWait:
+		p.log.Info("inspection in progress")
+		result, err = operationContinuing(introspectionRequeueDelay)
+		return
+	case nodes.InspectFail:
+		if !force {
+			failure := ironicNode.LastError
+			if failure == "" {
+				failure = "Inspection failed"
+			}
+			p.log.Info("inspection failed", "error", failure)
+			result, err = operationFailed(failure)
+			return
+		}
+	case nodes.Manageable:
+		if refresh || ironicNode.InspectInterface != inspectInterface {
+			break
+		}
+		var finished bool
+		finished, err = p.inspectionFinished(ironicNode.UUID)
+		if err != nil {
+			result, err = transientError(err)
+			return
+		}
+		if finished {
+			return p.getOutOfBandDetails(ironicNode)
+		}
+	}
+
+	started, result, err = p.startInspection(ironicNode, data, inspectInterface)
+	return
+}
+
+// inspectionFinished returns whether the last inspection of the node
+// completed. Ironic clears the time it finished when an inspection
+// starts, but gophercloud does not decode it.
+func (p *ironicProvisioner) inspectionFinished(nodeUUID string) (bool, error) {
+	var node struct {
+		InspectionFinishedAt *string `json:"inspection_finished_at"`
+	}
+	if err := nodes.Get(p.client, nodeUUID).ExtractInto(&node); err != nil {
+		return false, errors.Wrap(err, "failed to get inspection status")
+	}
+	return node.InspectionFinishedAt != nil, nil
+}
+
+func (p *ironicProvisioner) getOutOfBandDetails(ironicNode *nodes.Node) (result provisioner.Result, started bool, details *metal3v1alpha1.HardwareDetails, err error) {
+	p.log.Info("getting hardware details from out-of-band inspection")
+	nodePorts, err := p.listNodePorts(ironicNode.UUID)
+	if err != nil {
+		result, err = transientError(err)
+		return
+	}
+
+	details = hardwaredetails.GetHardwareDetailsFromNode(ironicNode.Properties, nodePorts)
+	p.publisher("InspectionComplete", "Hardware inspection completed")
+	result, err = operationComplete()
+	return
+}
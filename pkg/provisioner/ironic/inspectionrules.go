@@ -0,0 +1,139 @@
+package ironic
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+
+	"github.com/gophercloud/gophercloud"
+	"github.com/pkg/errors"
+
+	"github.com/shweta50/baremetal-operator/pkg/provisioner"
+)
+
+// inspectorRule is an introspection rule as accepted by the rules API
+// of Ironic Inspector.
+type inspectorRule struct {
+	UUID        string                   `json:"uuid"`
+	Description string                   `json:"description,omitempty"`
+	Conditions  []map[string]interface{} `json:"conditions"`
+	Actions     []map[string]interface{} `json:"actions"`
+}
+
+// buildInspectorRule converts an InspectionRule into an Ironic
+// Inspector rule. The arguments of an action are set next to its name.
+// Inspector rules apply to every node, so a first condition limits the
+// rule to the nodes of hosts in the namespace of the InspectionRule.
+func buildInspectorRule(rule provisioner.InspectionRuleData) (inspectorRule, error) {
+	result := inspectorRule{
+		UUID:        rule.ID,
+		Description: rule.Spec.Description,
+		Conditions: []map[string]interface{}{
+			{
+				"op":    "matches",
+				"field": "node://name",
+				"value": regexp.QuoteMeta(rule.ObjectMeta.Namespace+nameSeparator) + ".*",
+			},
+		},
+		Actions: []map[string]interface{}{},
+	}
+	if result.Description == "" {
+		result.Description = fmt.Sprintf("%s/%s", rule.ObjectMeta.Namespace, rule.ObjectMeta.Name)
+	}
+
+	for _, cond := range rule.Spec.Conditions {
+		condition := map[string]interface{}{
+			"op":    cond.Op,
+			"field": cond.Field,
+		}
+		if cond.Value != nil {
+			condition["value"] = cond.Value
+		}
+		if cond.Invert {
+			condition["invert"] = true
+		}
+		if cond.Multiple != "" {
+			condition["multiple"] = cond.Multiple
+		}
+		result.Conditions = append(result.Conditions, condition)
+	}
+
+	for _, act := range rule.Spec.Actions {
+		action := map[string]interface{}{}
+		if act.Args != nil && len(act.Args.Raw) > 0 {
+			if err := json.Unmarshal(act.Args.Raw, &action); err != nil {
+				return result, errors.Wrap(err, fmt.Sprintf("invalid arguments of action %s", act.Action))
+			}
+		}
+		if _, found := action["action"]; found {
+			return result, fmt.Errorf("the arguments of action %s can not set the action", act.Action)
+		}
+		action["action"] = act.Action
+		result.Actions = append(result.Actions, action)
+	}
+	return result, nil
+}
+
+func (f ironicProvisionerFactory) inspectorFor(rule provisioner.InspectionRuleData) (*gophercloud.ServiceClient, error) {
+	backend, err := f.backendFor(rule.ObjectMeta)
+	if err != nil {
+		return nil, err
+	}
+	return backend.clientInspector, nil
+}
+
+// ApplyInspectionRule creates the rule in the Ironic Inspector of the
+// backend chosen with the IronicBackendLabel of the rule. Inspector
+// can not update rules, so they are replaced by deleting them first.
+func (f ironicProvisionerFactory) ApplyInspectionRule(rule provisioner.InspectionRuleData, replace bool) error {
+	body, err := buildInspectorRule(rule)
+	if err != nil {
+		return err
+	}
+	client, err := f.inspectorFor(rule)
+	if err != nil {
+		return err
+	}
+
+	_, err = client.Get(client.ServiceURL("rules", rule.ID), nil, nil)
+	switch err.(type) {
+	case nil:
+		if !replace {
+			return nil
+		}
+		if err = f.RemoveInspectionRule(rule); err != nil {
+			return err
+		}
+	case gophercloud.ErrDefault404:
+	default:
+		return errors.Wrap(err, "failed to get inspection rule")
+	}
+
+	f.log.Info("creating inspection rule", "rule", rule.ID, "description", body.Description)
+	_, err = client.Post(client.ServiceURL("rules"), body, nil,
+		&gophercloud.RequestOpts{OkCodes: []int{200, 201}})
+	if err != nil {
+		return errors.Wrap(err, "failed to create inspection rule")
+	}
+	return nil
+}
+
+// RemoveInspectionRule deletes the rule from Ironic Inspector. A
+// backend that is not configured can not hold the rule, so there is
+// nothing to delete.
+func (f ironicProvisionerFactory) RemoveInspectionRule(rule provisioner.InspectionRuleData) error {
+	client, err := f.inspectorFor(rule)
+	if _, unknown := err.(UnknownBackendError); unknown {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	f.log.Info("deleting inspection rule", "rule", rule.ID)
+	_, err = client.Delete(client.ServiceURL("rules", rule.ID), nil)
+	if _, isNotFound := err.(gophercloud.ErrDefault404); err != nil && !isNotFound {
+		return errors.Wrap(err, "failed to delete inspection rule")
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+package ironic
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	metal3v1alpha1 "github.com/shweta50/baremetal-operator/apis/metal3.io/v1alpha1"
+	"github.com/shweta50/baremetal-operator/pkg/provisioner"
+	"github.com/shweta50/baremetal-operator/pkg/provisioner/ironic/clients"
+	"github.com/shweta50/baremetal-operator/pkg/provisioner/ironic/testserver"
+)
+
+func TestInspectionRules(t *testing.T) {
+	inspector := testserver.NewInspector(t).Ready().WithRules()
+	inspector.Start()
+	defer inspector.Stop()
+
+	factory := newTestProvisionerFactory()
+	var err error
+	factory.clientInspector, err = clients.InspectorClient(inspector.Endpoint(), clients.AuthConfig{Type: clients.NoAuth}, clients.TLSConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	value := intstr.FromInt(262144)
+	rule := provisioner.InspectionRuleData{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "myns", Name: "large-memory"},
+		ID:         "8c1b1cf8-f2e6-4a51-9f4e-1c3a4be5e5f5",
+		Spec: metal3v1alpha1.InspectionRuleSpec{
+			Conditions: []metal3v1alpha1.InspectionRuleCondition{
+				{Op: "ge", Field: "data://memory_mb", Value: &value},
+			},
+			Actions: []metal3v1alpha1.InspectionRuleAction{
+				{Action: "set-capability", Args: &runtime.RawExtension{Raw: []byte(`{"name": "memory", "value": "large"}`)}},
+			},
+		},
+	}
+
+	assert.NoError(t, factory.ApplyInspectionRule(rule, false))
+	assert.Equal(t, []map[string]interface{}{
+		{
+			"uuid":        rule.ID,
+			"description": "myns/large-memory",
+			"conditions": []interface{}{
+				map[string]interface{}{"op": "matches", "field": "node://name", "value": "myns~.*"},
+				map[string]interface{}{"op": "ge", "field": "data://memory_mb", "value": float64(262144)},
+			},
+			"actions": []interface{}{
+				map[string]interface{}{"action": "set-capability", "name": "memory", "value": "large"},
+			},
+		},
+	}, inspector.Rules())
+
+	// An existing rule is only replaced when asked to
+	rule.Spec.Description = "Large memory hosts"
+	assert.NoError(t, factory.ApplyInspectionRule(rule, false))
+	assert.Equal(t, "myns/large-memory", inspector.Rules()[0]["description"])
+	assert.NoError(t, factory.ApplyInspectionRule(rule, true))
+	assert.Equal(t, "Large memory hosts", inspector.Rules()[0]["description"])
+
+	assert.NoError(t, factory.RemoveInspectionRule(rule))
+	assert.Empty(t, inspector.Rules())
+	assert.NoError(t, factory.RemoveInspectionRule(rule), "already removed")
+
+	moved := rule
+	moved.ObjectMeta.Labels = map[string]string{metal3v1alpha1.IronicBackendLabel: "unknown"}
+	assert.NoError(t, factory.RemoveInspectionRule(moved), "unknown backend")
+	assert.Error(t, factory.ApplyInspectionRule(moved, true), "unknown backend")
+
+	rule.Spec.Actions[0].Args = &runtime.RawExtension{Raw: []byte(`{"action": "fail"}`)}
+	assert.Error(t, factory.ApplyInspectionRule(rule, true))
+	rule.Spec.Actions = nil
+	assert.Error(t, factory.ApplyInspectionRule(rule, true), "rejected by inspector")
+}
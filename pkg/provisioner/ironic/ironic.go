@@ -1,6 +1,7 @@
 package ironic
 
 import (
+	"encoding/json"
 	"fmt"
 	"strings"
 	"time"
@@ -65,6 +66,10 @@ type ironicConfig struct {
 	deployRamdiskURL string
 	deployISOURL     string
 	maxBusyHosts     int
+	// the limits of each class of operation, within maxBusyHosts
+	maxBusyHostsPerOperation map[provisioner.Operation]int
+	// how long the busy hosts are cached when checking capacity
+	capacityRefreshInterval time.Duration
 }
 
 // Provisioner implements the provisioning.Provisioner interface
@@ -84,16 +89,22 @@ type ironicProvisioner struct {
 	bmcCreds bmc.Credentials
 	// the MAC address of the PXE boot interface
 	bootMACAddress string
+	// the Ironic conductor group of the node, lower case
+	conductorGroup string
 	// a client for talking to ironic
 	client *gophercloud.ServiceClient
 	// a client for talking to ironic-inspector
 	inspector *gophercloud.ServiceClient
+	// the busy hosts of the ironic, shared between provisioners
+	capacity *capacityCache
 	// a logger configured for this host
 	log logr.Logger
 	// a debug logger configured for this host
 	debugLog logr.Logger
 	// an event publisher for recording significant events
 	publisher provisioner.EventPublisher
+	// the node as last read from ironic
+	lastNode *nodes.Node
 }
 
 func (p *ironicProvisioner) bmcAccess() (bmc.AccessDetails, error) {
@@ -161,6 +172,7 @@ func (p *ironicProvisioner) getNode() (*nodes.Node, error) {
 	switch err.(type) {
 	case nil:
 		p.debugLog.Info("found existing node by ID")
+		p.lastNode = ironicNode
 		return ironicNode, nil
 	case gophercloud.ErrDefault404:
 		// Look by ID failed, trying to lookup by hostname in case it was
@@ -236,6 +248,7 @@ func (p *ironicProvisioner) findExistingHost(bootMACAddress string) (ironicNode
 		switch err.(type) {
 		case nil:
 			p.debugLog.Info("found existing node by name")
+			p.lastNode = ironicNode
 			return ironicNode, nil
 		case gophercloud.ErrDefault404:
 			p.log.Info(
@@ -369,9 +382,10 @@ func (p *ironicProvisioner) ValidateManagementAccess(data provisioner.Management
 				Driver:              bmcAccess.Driver(),
 				BootInterface:       bmcAccess.BootInterface(),
 				Name:                p.objectMeta.Name,
+				ConductorGroup:      p.conductorGroup,
 				DriverInfo:          driverInfo,
 				DeployInterface:     p.deployInterface(data.CurrentImage),
-				InspectInterface:    "inspector",
+				InspectInterface:    inBandInspectInterface,
 				ManagementInterface: bmcAccess.ManagementInterface(),
 				PowerInterface:      bmcAccess.PowerInterface(),
 				RAIDInterface:       bmcAccess.RAIDInterface(),
@@ -408,6 +422,7 @@ func (p *ironicProvisioner) ValidateManagementAccess(data provisioner.Management
 		provID = ironicNode.UUID
 
 		updater.SetTopLevelOpt("name", ironicNodeName(p.objectMeta), ironicNode.Name)
+		updater.SetTopLevelOpt("conductor_group", p.conductorGroup, ironicNode.ConductorGroup)
 
 		// When node exists but has no assigned port to it by Ironic and actuall address (MAC) is present
 		// in host config and is not allocated to different node lets try to create port for this node.
@@ -570,6 +585,32 @@ func (p *ironicProvisioner) changeNodeProvisionState(ironicNode *nodes.Node, opt
 	return
 }
 
+// startInspection switches the node to the given inspect interface
+// and starts inspecting it.
+func (p *ironicProvisioner) startInspection(ironicNode *nodes.Node, data provisioner.InspectData, inspectInterface string) (started bool, result provisioner.Result, err error) {
+	started, result, err = p.tryUpdateNode(
+		ironicNode,
+		updateOptsBuilder(p.debugLog).
+			SetPropertiesOpts(optionsData{
+				"capabilities": buildCapabilitiesValue(ironicNode, data.BootMode),
+			}, ironicNode).
+			SetTopLevelOpt("inspect_interface", inspectInterface, ironicNode.InspectInterface),
+	)
+	if !started {
+		return
+	}
+
+	p.log.Info("starting new hardware inspection", "interface", inspectInterface)
+	started, result, err = p.tryChangeNodeProvisionState(
+		ironicNode,
+		nodes.ProvisionStateOpts{Target: nodes.TargetInspect},
+	)
+	if started {
+		p.publisher("InspectionStarted", "Hardware inspection started")
+	}
+	return
+}
+
 // InspectHardware updates the HardwareDetails field of the host with
 // details of devices discovered on the hardware. It may be called
 // multiple times, and should return true for its dirty flag until the
@@ -583,6 +624,10 @@ func (p *ironicProvisioner) InspectHardware(data provisioner.InspectData, force,
 		return
 	}
 
+	if data.Mode == metal3v1alpha1.InspectionModeOutOfBand {
+		return p.inspectOutOfBand(ironicNode, data, force, refresh)
+	}
+
 	status, err := introspection.GetIntrospectionStatus(p.inspector, ironicNode.UUID).Extract()
 	if err != nil || refresh {
 		if _, isNotFound := err.(gophercloud.ErrDefault404); isNotFound || refresh {
@@ -609,25 +654,7 @@ func (p *ironicProvisioner) InspectHardware(data provisioner.InspectData, force,
 				}
 				fallthrough
 			default:
-				started, result, err = p.tryUpdateNode(
-					ironicNode,
-					updateOptsBuilder(p.debugLog).
-						SetPropertiesOpts(optionsData{
-							"capabilities": buildCapabilitiesValue(ironicNode, data.BootMode),
-						}, ironicNode),
-				)
-				if !started {
-					return
-				}
-
-				p.log.Info("starting new hardware inspection")
-				started, result, err = p.tryChangeNodeProvisionState(
-					ironicNode,
-					nodes.ProvisionStateOpts{Target: nodes.TargetInspect},
-				)
-				if started {
-					p.publisher("InspectionStarted", "Hardware inspection started")
-				}
+				started, result, err = p.startInspection(ironicNode, data, inBandInspectInterface)
 				return
 			}
 		}
@@ -648,7 +675,7 @@ func (p *ironicProvisioner) InspectHardware(data provisioner.InspectData, force,
 	// Introspection is done
 	p.log.Info("getting hardware details from inspection")
 	response := introspection.GetIntrospectionData(p.inspector, ironicNode.UUID)
-	introData, err := response.Extract()
+	introData, err := hardwaredetails.ExtractData(response)
 	if err != nil {
 		result, err = transientError(errors.Wrap(err, "failed to retrieve hardware introspection data"))
 		return
@@ -809,6 +836,7 @@ func (p *ironicProvisioner) getUpdateOptsForNode(ironicNode *nodes.Node, data pr
 
 	hasCustomDeploy := data.CustomDeploy != nil && data.CustomDeploy.Method != ""
 	p.getImageUpdateOptsForNode(ironicNode, &data.Image, data.BootMode, hasCustomDeploy, updater)
+	updater.SetInstanceInfoOpts(optionsData{"traits": instanceTraits(data.Traits)}, ironicNode)
 
 	opts := optionsData{
 		"root_device": devicehints.MakeHintMap(data.RootDeviceHints),
@@ -861,6 +889,13 @@ func (p *ironicProvisioner) setUpForProvisioning(ironicNode *nodes.Node, data pr
 
 	p.log.Info("starting provisioning", "node properties", ironicNode.Properties)
 
+	if err = p.removeNodeTraits(ironicNode, data.PreviousTraits, data.Traits); err != nil {
+		return transientError(err)
+	}
+	if err = p.addNodeTraits(ironicNode, data.Traits); err != nil {
+		return transientError(err)
+	}
+
 	success, result, err := p.tryUpdateNode(ironicNode,
 		p.getUpdateOptsForNode(ironicNode, data))
 	if !success {
@@ -1065,8 +1100,15 @@ func (p *ironicProvisioner) Prepare(data provisioner.PrepareData, unprepared boo
 				result, err = operationFailed(err.Error())
 				return
 			}
-			if len(cleanSteps) != 0 {
-				
+			// Ports can only be moved between port groups while the
+			// node is manageable.
+			var networkingChanged bool
+			networkingChanged, err = p.configureNetworking(ironicNode.UUID, data.Networking, false)
+			if err != nil {
+				result, err = transientError(err)
+				return
+			}
+			if len(cleanSteps) != 0 || networkingChanged {
 				result, err = p.changeNodeProvisionState(
 					ironicNode,
 					nodes.ProvisionStateOpts{Target: nodes.TargetManage},
@@ -1081,6 +1123,10 @@ func (p *ironicProvisioner) Prepare(data provisioner.PrepareData, unprepared boo
 
 	case nodes.Manageable:
 		if unprepared {
+			if _, err = p.configureNetworking(ironicNode.UUID, data.Networking, true); err != nil {
+				result, err = transientError(err)
+				return
+			}
 			started, result, err = p.startManualCleaning(bmcAccess, ironicNode, data)
 			if started || result.Dirty || result.ErrorMessage != "" || err != nil {
 				return
@@ -1178,16 +1224,32 @@ func (p *ironicProvisioner) getConfigDrive(data provisioner.ProvisionData) (conf
 	return
 }
 
-func (p *ironicProvisioner) getCustomDeploySteps(customDeploy *metal3v1alpha1.CustomDeploy) (deploySteps []nodes.DeployStep) {
-	if customDeploy != nil && customDeploy.Method != "" {
+func (p *ironicProvisioner) getDeploySteps(data provisioner.ProvisionData) (deploySteps []nodes.DeployStep, err error) {
+	if data.CustomDeploy != nil && data.CustomDeploy.Method != "" {
 		deploySteps = append(deploySteps, nodes.DeployStep{
 			Interface: nodes.InterfaceDeploy,
-			Step:      customDeploy.Method,
+			Step:      data.CustomDeploy.Method,
 			Args:      map[string]interface{}{},
 			Priority:  customDeployPriority,
 		})
 	}
 
+	for _, step := range data.DeploySteps {
+		args := map[string]interface{}{}
+		if step.Args != nil && len(step.Args.Raw) > 0 {
+			if err = json.Unmarshal(step.Args.Raw, &args); err != nil {
+				err = errors.Wrap(err, fmt.Sprintf("invalid arguments for deploy step %s.%s", step.Interface, step.Step))
+				return
+			}
+		}
+		deploySteps = append(deploySteps, nodes.DeployStep{
+			Interface: nodes.StepInterface(step.Interface),
+			Step:      step.Step,
+			Args:      args,
+			Priority:  step.StepPriority(),
+		})
+	}
+
 	return
 }
 
@@ -1234,12 +1296,17 @@ func (p *ironicProvisioner) Provision(data provisioner.ProvisionData) (result pr
 			return transientError(err)
 		}
 
+		deploySteps, err := p.getDeploySteps(data)
+		if err != nil {
+			return operationFailed(err.Error())
+		}
+
 		return p.changeNodeProvisionState(
 			ironicNode,
 			nodes.ProvisionStateOpts{
 				Target:      nodes.TargetActive,
 				ConfigDrive: configDrive,
-				DeploySteps: p.getCustomDeploySteps(data.CustomDeploy),
+				DeploySteps: deploySteps,
 			},
 		)
 
@@ -1271,12 +1338,17 @@ func (p *ironicProvisioner) Provision(data provisioner.ProvisionData) (result pr
 			return transientError(err)
 		}
 
+		deploySteps, err := p.getDeploySteps(data)
+		if err != nil {
+			return operationFailed(err.Error())
+		}
+
 		return p.changeNodeProvisionState(
 			ironicNode,
 			nodes.ProvisionStateOpts{
 				Target:      nodes.TargetActive,
 				ConfigDrive: configDrive,
-				DeploySteps: p.getCustomDeploySteps(data.CustomDeploy),
+				DeploySteps: deploySteps,
 			},
 		)
 
@@ -1651,53 +1723,48 @@ func ironicNodeName(objMeta metav1.ObjectMeta) string {
 func (p *ironicProvisioner) IsReady() (result bool, err error) {
 	p.debugLog.Info("verifying ironic provisioner dependencies")
 
-	checker := newIronicDependenciesChecker(p.client, p.inspector, p.log)
+	checker := newIronicDependenciesChecker(p.client, p.inspector, p.conductorGroup, p.log)
 	return checker.IsReady()
 }
 
-func (p *ironicProvisioner) HasCapacity() (result bool, err error) {
-
-	hosts, err := p.loadBusyHosts()
-	if err != nil {
-		p.log.Error(err, "Unable to get hosts for determining current provisioner capacity")
-		return false, err
+// LastHostState returns the provision state, step and last error of
+// the node as last read from Ironic.
+func (p *ironicProvisioner) LastHostState() *provisioner.HostState {
+	if p.lastNode == nil {
+		return nil
 	}
-
-	// If the current host is already under processing then let's skip the test
-	if _, ok := hosts[ironicNodeName(p.objectMeta)]; ok {
-		return true, nil
+	step := p.lastNode.DeployStep
+	if len(step) == 0 {
+		step = p.lastNode.CleanStep
+	}
+	return &provisioner.HostState{
+		State:     p.lastNode.ProvisionState,
+		Target:    p.lastNode.TargetProvisionState,
+		Step:      stepName(step),
+		LastError: p.lastNode.LastError,
 	}
+}
 
-	return len(hosts) < p.config.maxBusyHosts, nil
+// stepName returns "<interface>.<step>" for a clean or deploy step as
+// reported by Ironic.
+func stepName(step map[string]interface{}) string {
+	iface, _ := step["interface"].(string)
+	name, _ := step["step"].(string)
+	if iface == "" || name == "" {
+		return name
+	}
+	return iface + "." + name
 }
 
-func (p *ironicProvisioner) loadBusyHosts() (hosts map[string]struct{}, err error) {
+func (p *ironicProvisioner) HasCapacity(data provisioner.CapacityData) (result bool, err error) {
 
-	hosts = make(map[string]struct{})
-	pager := nodes.List(p.client, nodes.ListOpts{
-		Fields: []string{"uuid,name,provision_state,driver_internal_info,target_provision_state"},
+	result, err = p.capacity.reserve(ironicNodeName(p.objectMeta), data, capacityLimits{
+		total:        p.config.maxBusyHosts,
+		perOperation: p.config.maxBusyHostsPerOperation,
 	})
-
-	page, err := pager.AllPages()
-	if err != nil {
-		return nil, err
-	}
-
-	allNodes, err := nodes.ExtractNodes(page)
 	if err != nil {
-		return nil, err
-	}
-
-	for _, node := range allNodes {
-
-		switch nodes.ProvisionState(node.ProvisionState) {
-		case nodes.Cleaning, nodes.CleanWait,
-			nodes.Inspecting, nodes.InspectWait,
-			nodes.Deploying, nodes.DeployWait,
-			nodes.Deleting:
-			hosts[node.Name] = struct{}{}
-		}
+		p.log.Error(err, "Unable to get hosts for determining current provisioner capacity")
+		return false, err
 	}
-
-	return hosts, nil
+	return result, nil
 }
@@ -0,0 +1,270 @@
+package ironic
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/gophercloud/gophercloud"
+	"github.com/gophercloud/gophercloud/openstack/baremetal/v1/ports"
+	"github.com/pkg/errors"
+
+	metal3v1alpha1 "github.com/shweta50/baremetal-operator/apis/metal3.io/v1alpha1"
+)
+
+// networkingPortKey marks in the extra field of a port that it was
+// created for the networking settings of the host, so it can be removed
+// when the NIC is dropped from them.
+const networkingPortKey = "metal3_networking"
+
+// portGroup is an Ironic port group. Gophercloud does not support the
+// port group API yet.
+type portGroup struct {
+	UUID                     string `json:"uuid,omitempty"`
+	Name                     string `json:"name"`
+	NodeUUID                 string `json:"node_uuid,omitempty"`
+	Address                  string `json:"address"`
+	Mode                     string `json:"mode"`
+	StandalonePortsSupported bool   `json:"standalone_ports_supported"`
+}
+
+// portSettings are the settings of the port of one NIC listed in the
+// networking settings.
+type portSettings struct {
+	bond            string
+	physicalNetwork string
+}
+
+func (p *ironicProvisioner) portGroupPrefix() string {
+	return ironicNodeName(p.objectMeta) + nameSeparator
+}
+
+func (p *ironicProvisioner) listPortGroups(nodeUUID string) (groups []portGroup, err error) {
+	var body struct {
+		PortGroups []portGroup `json:"portgroups"`
+	}
+	_, err = p.client.Get(
+		p.client.ServiceURL("portgroups", "detail")+"?node="+url.QueryEscape(nodeUUID),
+		&body, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list ironic port groups")
+	}
+	return body.PortGroups, nil
+}
+
+func (p *ironicProvisioner) createPortGroup(group portGroup) (created portGroup, err error) {
+	p.log.Info("creating ironic port group", "name", group.Name, "mode", group.Mode, "MAC", group.Address)
+	_, err = p.client.Post(p.client.ServiceURL("portgroups"), group, &created, &gophercloud.RequestOpts{
+		OkCodes: []int{201},
+	})
+	if err != nil {
+		err = errors.Wrap(err, fmt.Sprintf("failed to create ironic port group %s", group.Name))
+	}
+	return
+}
+
+func (p *ironicProvisioner) updatePortGroup(group portGroup) error {
+	p.log.Info("updating ironic port group", "name", group.Name, "mode", group.Mode, "MAC", group.Address)
+	patch := []ports.UpdateOperation{
+		{Op: ports.ReplaceOp, Path: "/mode", Value: group.Mode},
+		{Op: ports.ReplaceOp, Path: "/address", Value: group.Address},
+	}
+	_, err := p.client.Patch(p.client.ServiceURL("portgroups", group.UUID), patch, nil, &gophercloud.RequestOpts{
+		OkCodes: []int{200},
+	})
+	if err != nil {
+		return errors.Wrap(err, fmt.Sprintf("failed to update ironic port group %s", group.Name))
+	}
+	return nil
+}
+
+func (p *ironicProvisioner) deletePortGroup(group portGroup) error {
+	p.log.Info("deleting ironic port group", "name", group.Name)
+	_, err := p.client.Delete(p.client.ServiceURL("portgroups", group.UUID), nil)
+	if err != nil {
+		return errors.Wrap(err, fmt.Sprintf("failed to delete ironic port group %s", group.Name))
+	}
+	return nil
+}
+
+func (p *ironicProvisioner) listNodePorts(nodeUUID string) ([]ports.Port, error) {
+	allPages, err := ports.ListDetail(p.client, ports.ListOpts{NodeUUID: nodeUUID}).AllPages()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list ironic ports")
+	}
+	return ports.ExtractPorts(allPages)
+}
+
+// configureNetworking brings the port groups and ports of the node in
+// line with the networking settings of the host, and returns whether
+// anything had to change. When apply is false, nothing is changed in
+// Ironic.
+//
+// Only the port groups named after the host and the ports created for
+// the networking settings are removed, so ports created by other means,
+// such as the one of the BootMACAddress, are left in place.
+func (p *ironicProvisioner) configureNetworking(nodeUUID string, networking *metal3v1alpha1.NetworkingConfig, apply bool) (changed bool, err error) {
+	if networking == nil {
+		networking = &metal3v1alpha1.NetworkingConfig{}
+	}
+
+	groups, err := p.listPortGroups(nodeUUID)
+	if err != nil {
+		return false, err
+	}
+	prefix := p.portGroupPrefix()
+	existing := map[string]portGroup{}
+	owned := map[string]bool{}
+	for _, group := range groups {
+		if strings.HasPrefix(group.Name, prefix) {
+			existing[strings.TrimPrefix(group.Name, prefix)] = group
+			owned[group.UUID] = true
+		}
+	}
+
+	// Port groups have to exist before their ports are attached.
+	groupUUIDs := map[string]string{}
+	for _, bond := range networking.Bonds {
+		mode := bond.Mode
+		if mode == "" {
+			mode = metal3v1alpha1.BondMode8023AD
+		}
+		want := portGroup{
+			Name:                     prefix + bond.Name,
+			NodeUUID:                 nodeUUID,
+			Address:                  strings.ToLower(bond.BondMACAddress()),
+			Mode:                     string(mode),
+			StandalonePortsSupported: true,
+		}
+		group, found := existing[bond.Name]
+		switch {
+		case !found:
+			changed = true
+			if apply {
+				if group, err = p.createPortGroup(want); err != nil {
+					return
+				}
+			}
+		case group.Mode != want.Mode || !strings.EqualFold(group.Address, want.Address):
+			changed = true
+			if apply {
+				want.UUID = group.UUID
+				if err = p.updatePortGroup(want); err != nil {
+					return
+				}
+			}
+		}
+		groupUUIDs[bond.Name] = group.UUID
+		delete(existing, bond.Name)
+	}
+
+	desired := map[string]portSettings{}
+	for _, nic := range networking.NICs {
+		desired[strings.ToLower(nic.MACAddress)] = portSettings{physicalNetwork: nic.PhysicalNetwork}
+	}
+	for _, bond := range networking.Bonds {
+		for _, member := range bond.Members {
+			desired[strings.ToLower(member)] = portSettings{bond: bond.Name, physicalNetwork: bond.PhysicalNetwork}
+		}
+	}
+
+	nodePorts, err := p.listNodePorts(nodeUUID)
+	if err != nil {
+		return false, err
+	}
+	for _, port := range nodePorts {
+		address := strings.ToLower(port.Address)
+		want, found := desired[address]
+		delete(desired, address)
+
+		if !found {
+			if _, ours := port.Extra[networkingPortKey]; ours && address != strings.ToLower(p.bootMACAddress) {
+				changed = true
+				if apply {
+					if err = p.deletePort(port); err != nil {
+						return
+					}
+				}
+				continue
+			}
+			// Leave the port as it is, unless its bond is going away
+			want.physicalNetwork = port.PhysicalNetwork
+		}
+
+		var patch ports.UpdateOpts
+		if want.bond == "" {
+			if owned[port.PortGroupUUID] {
+				patch = append(patch, ports.UpdateOperation{Op: ports.RemoveOp, Path: "/portgroup_uuid"})
+			}
+		} else if groupUUID := groupUUIDs[want.bond]; groupUUID == "" || groupUUID != port.PortGroupUUID {
+			patch = append(patch, ports.UpdateOperation{Op: ports.ReplaceOp, Path: "/portgroup_uuid", Value: groupUUID})
+		}
+		if want.physicalNetwork != port.PhysicalNetwork {
+			if want.physicalNetwork == "" {
+				patch = append(patch, ports.UpdateOperation{Op: ports.RemoveOp, Path: "/physical_network"})
+			} else {
+				patch = append(patch, ports.UpdateOperation{Op: ports.ReplaceOp, Path: "/physical_network", Value: want.physicalNetwork})
+			}
+		}
+		if len(patch) == 0 {
+			continue
+		}
+		changed = true
+		if apply {
+			p.log.Info("updating ironic port", "MAC", port.Address, "bond", want.bond, "physicalNetwork", want.physicalNetwork)
+			if _, err = ports.Update(p.client, port.UUID, patch).Extract(); err != nil {
+				err = errors.Wrap(err, fmt.Sprintf("failed to update ironic port %s", port.Address))
+				return
+			}
+		}
+	}
+
+	for address, want := range desired {
+		changed = true
+		if apply {
+			if err = p.createNetworkingPort(nodeUUID, address, groupUUIDs[want.bond], want.physicalNetwork); err != nil {
+				return
+			}
+		}
+	}
+
+	// Port groups can only be removed once they have no ports left.
+	for _, group := range existing {
+		changed = true
+		if apply {
+			if err = p.deletePortGroup(group); err != nil {
+				return
+			}
+		}
+	}
+
+	return
+}
+
+func (p *ironicProvisioner) createNetworkingPort(nodeUUID, address, groupUUID, physicalNetwork string) error {
+	p.log.Info("creating ironic port for node", "NodeUUID", nodeUUID, "MAC", address, "physicalNetwork", physicalNetwork)
+
+	disable := false
+	_, err := ports.Create(
+		p.client,
+		ports.CreateOpts{
+			NodeUUID:        nodeUUID,
+			Address:         address,
+			PortGroupUUID:   groupUUID,
+			PhysicalNetwork: physicalNetwork,
+			PXEEnabled:      &disable,
+			Extra:           map[string]interface{}{networkingPortKey: true},
+		}).Extract()
+	if err != nil {
+		return errors.Wrap(err, fmt.Sprintf("failed to create ironic port for node: %s, MAC: %s", nodeUUID, address))
+	}
+	return nil
+}
+
+func (p *ironicProvisioner) deletePort(port ports.Port) error {
+	p.log.Info("deleting ironic port", "MAC", port.Address)
+	if err := ports.Delete(p.client, port.UUID).ExtractErr(); err != nil {
+		return errors.Wrap(err, fmt.Sprintf("failed to delete ironic port %s", port.Address))
+	}
+	return nil
+}
@@ -0,0 +1,102 @@
+package ironic
+
+import (
+	"testing"
+
+	"github.com/gophercloud/gophercloud/openstack/baremetal/v1/nodes"
+	"github.com/gophercloud/gophercloud/openstack/baremetal/v1/ports"
+	"github.com/stretchr/testify/assert"
+
+	metal3v1alpha1 "github.com/shweta50/baremetal-operator/apis/metal3.io/v1alpha1"
+	"github.com/shweta50/baremetal-operator/pkg/bmc"
+	"github.com/shweta50/baremetal-operator/pkg/provisioner/ironic/clients"
+	"github.com/shweta50/baremetal-operator/pkg/provisioner/ironic/testserver"
+)
+
+func portsByAddress(allPorts []ports.Port) map[string]ports.Port {
+	result := map[string]ports.Port{}
+	for _, port := range allPorts {
+		result[port.Address] = port
+	}
+	return result
+}
+
+func TestConfigureNetworking(t *testing.T) {
+	sim := testserver.NewSimulator(t).Start()
+	defer sim.Stop()
+
+	host := makeHost()
+	host.Spec.BootMACAddress = "52:54:00:00:00:01"
+	auth := clients.AuthConfig{Type: clients.NoAuth}
+	prov, err := newProvisionerWithSettings(host, bmc.Credentials{}, nullEventPublisher,
+		sim.Endpoint(), auth, sim.InspectorEndpoint(), auth)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	node, err := nodes.Create(prov.client, nodes.CreateOpts{Name: "myns~myhost", Driver: "ipmi"}).Extract()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = prov.createPXEEnabledNodePort(node.UUID, host.Spec.BootMACAddress); err != nil {
+		t.Fatal(err)
+	}
+
+	networking := &metal3v1alpha1.NetworkingConfig{
+		NICs: []metal3v1alpha1.NICConfig{
+			{MACAddress: "52:54:00:00:00:03", PhysicalNetwork: "storage"},
+		},
+		Bonds: []metal3v1alpha1.BondConfig{{
+			Name:            "bond0",
+			Members:         []string{"52:54:00:00:00:01", "52:54:00:00:00:02"},
+			PhysicalNetwork: "provisioning",
+		}},
+	}
+
+	changed, err := prov.configureNetworking(node.UUID, networking, false)
+	assert.NoError(t, err)
+	assert.True(t, changed)
+	assert.Empty(t, sim.PortGroups(node.UUID))
+	assert.Len(t, sim.Ports(node.UUID), 1)
+
+	changed, err = prov.configureNetworking(node.UUID, networking, true)
+	assert.NoError(t, err)
+	assert.True(t, changed)
+
+	groups := sim.PortGroups(node.UUID)
+	if assert.Len(t, groups, 1) {
+		assert.Equal(t, "myns~myhost~bond0", groups[0]["name"])
+		assert.Equal(t, "802.3ad", groups[0]["mode"])
+		assert.Equal(t, "52:54:00:00:00:01", groups[0]["address"])
+	}
+	nodePorts := portsByAddress(sim.Ports(node.UUID))
+	if assert.Len(t, nodePorts, 3) {
+		groupUUID := groups[0]["uuid"]
+		assert.Equal(t, groupUUID, nodePorts["52:54:00:00:00:01"].PortGroupUUID)
+		assert.True(t, nodePorts["52:54:00:00:00:01"].PXEEnabled)
+		assert.Equal(t, groupUUID, nodePorts["52:54:00:00:00:02"].PortGroupUUID)
+		assert.Equal(t, "provisioning", nodePorts["52:54:00:00:00:02"].PhysicalNetwork)
+		assert.Equal(t, "", nodePorts["52:54:00:00:00:03"].PortGroupUUID)
+		assert.Equal(t, "storage", nodePorts["52:54:00:00:00:03"].PhysicalNetwork)
+	}
+
+	changed, err = prov.configureNetworking(node.UUID, networking, true)
+	assert.NoError(t, err)
+	assert.False(t, changed)
+
+	networking.Bonds[0].Mode = metal3v1alpha1.BondModeActiveBackup
+	changed, err = prov.configureNetworking(node.UUID, networking, true)
+	assert.NoError(t, err)
+	assert.True(t, changed)
+	assert.Equal(t, "active-backup", sim.PortGroups(node.UUID)[0]["mode"])
+
+	changed, err = prov.configureNetworking(node.UUID, nil, true)
+	assert.NoError(t, err)
+	assert.True(t, changed)
+	assert.Empty(t, sim.PortGroups(node.UUID))
+	nodePorts = portsByAddress(sim.Ports(node.UUID))
+	if assert.Len(t, nodePorts, 1) {
+		assert.Equal(t, "", nodePorts["52:54:00:00:00:01"].PortGroupUUID)
+		assert.Equal(t, "provisioning", nodePorts["52:54:00:00:00:01"].PhysicalNetwork)
+	}
+}
@@ -28,6 +28,7 @@ func (r *RAIDTestBMC) ManagementInterface() string                           { r
 func (r *RAIDTestBMC) PowerInterface() string                                { return "" }
 func (r *RAIDTestBMC) RAIDInterface() string                                 { return "" }
 func (r *RAIDTestBMC) VendorInterface() string                               { return "" }
+func (r *RAIDTestBMC) OutOfBandInspectInterface() string                     { return "" }
 func (r *RAIDTestBMC) SupportsSecureBoot() bool                              { return false }
 func (r *RAIDTestBMC) BuildBIOSSettings(fwConf *metal3v1alpha1.FirmwareConfig) ([]map[string]string, error) {
 	return nil, nil
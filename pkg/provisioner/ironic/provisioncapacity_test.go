@@ -2,12 +2,15 @@ package ironic
 
 import (
 	"fmt"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/gophercloud/gophercloud/openstack/baremetal/v1/nodes"
 	"github.com/stretchr/testify/assert"
 
 	"github.com/shweta50/baremetal-operator/pkg/bmc"
+	"github.com/shweta50/baremetal-operator/pkg/provisioner"
 	"github.com/shweta50/baremetal-operator/pkg/provisioner/ironic/clients"
 	"github.com/shweta50/baremetal-operator/pkg/provisioner/ironic/testserver"
 )
@@ -89,7 +92,7 @@ func TestHasCapacity(t *testing.T) {
 			}
 			prov.config.maxBusyHosts = tc.provisioningLimit
 
-			result, err := prov.HasCapacity()
+			result, err := prov.HasCapacity(provisioner.CapacityData{Operation: provisioner.OperationProvisioning})
 
 			assert.Equal(t, tc.expectedHasCapacity, result)
 
@@ -102,3 +105,177 @@ func TestHasCapacity(t *testing.T) {
 		})
 	}
 }
+
+func TestHasCapacitySharedCache(t *testing.T) {
+	ironic := testserver.NewIronic(t).Nodes([]nodes.Node{
+		{Name: "myns" + nameSeparator + "busy", ProvisionState: string(nodes.Deploying)},
+		{Name: "myns" + nameSeparator + "idle", ProvisionState: string(nodes.Active)},
+	})
+	ironic.Start()
+	defer ironic.Stop()
+
+	auth := clients.AuthConfig{Type: clients.NoAuth}
+	client, err := clients.IronicClient(ironic.Endpoint(), auth, clients.TLSConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	factory := newTestProvisionerFactory()
+	factory.clientIronic = client
+	factory.config.maxBusyHosts = 2
+	factory.capacity = newCapacityCache(client, time.Hour)
+
+	hasCapacity := func(name string) bool {
+		data := provisioner.CapacityData{Operation: provisioner.OperationProvisioning}
+		host := makeHost()
+		host.Name = name
+		prov, err := factory.ironicProvisioner(provisioner.BuildHostData(host, bmc.Credentials{}), nullEventPublisher)
+		if err != nil {
+			t.Fatal(err)
+		}
+		result, err := prov.HasCapacity(data)
+		assert.NoError(t, err)
+		return result
+	}
+
+	assert.True(t, hasCapacity("host-1"), "one slot is free")
+	assert.False(t, hasCapacity("host-2"), "the free slot is reserved for host-1")
+	assert.True(t, hasCapacity("host-1"), "host-1 keeps its reservation")
+	assert.True(t, hasCapacity("busy"), "busy hosts are always allowed")
+	assert.Equal(t, 1, strings.Count(ironic.Requests, "/v1/nodes;"), "nodes are listed once")
+
+	stats := factory.capacity.stats()
+	assert.Equal(t, 1, stats.busy[provisioner.OperationProvisioning])
+	assert.Equal(t, 1, stats.reserved[provisioner.OperationProvisioning])
+
+	// Once Ironic reports the reserved host as busy, the reservation
+	// is no longer needed.
+	updated := testserver.NewIronic(t).Nodes([]nodes.Node{
+		{Name: "myns" + nameSeparator + "busy", ProvisionState: string(nodes.Deploying)},
+		{Name: "myns" + nameSeparator + "host-1", ProvisionState: string(nodes.Inspecting)},
+	})
+	updated.Start()
+	defer updated.Stop()
+	factory.capacity.client, err = clients.IronicClient(updated.Endpoint(), auth, clients.TLSConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	factory.capacity.maxAge = 0
+	assert.False(t, hasCapacity("host-2"))
+	stats = factory.capacity.stats()
+	assert.Equal(t, 1, stats.busy[provisioner.OperationProvisioning])
+	assert.Equal(t, 1, stats.busy[provisioner.OperationInspection])
+	assert.Empty(t, stats.reserved[provisioner.OperationProvisioning])
+}
+
+func TestCapacityOperationLimits(t *testing.T) {
+	ironic := testserver.NewIronic(t).Nodes([]nodes.Node{
+		{Name: "inspect-1", ProvisionState: string(nodes.InspectWait)},
+		{Name: "inspect-2", ProvisionState: string(nodes.Inspecting)},
+		{Name: "clean-1", ProvisionState: string(nodes.CleanWait)},
+	})
+	ironic.Start()
+	defer ironic.Stop()
+
+	client, err := clients.IronicClient(ironic.Endpoint(), clients.AuthConfig{Type: clients.NoAuth}, clients.TLSConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	inspection := provisioner.CapacityData{Operation: provisioner.OperationInspection}
+	provisioning := provisioner.CapacityData{Operation: provisioner.OperationProvisioning}
+	cleaning := provisioner.CapacityData{Operation: provisioner.OperationCleaning}
+	deleting := provisioner.CapacityData{Operation: provisioner.OperationDeleting}
+
+	cases := []struct {
+		name     string
+		limits   capacityLimits
+		requests []provisioner.CapacityData
+		expected []bool
+	}{
+		{
+			name:     "overall-limit-only",
+			limits:   capacityLimits{total: 4},
+			requests: []provisioner.CapacityData{inspection, provisioning},
+			expected: []bool{true, false},
+		},
+		{
+			name: "inspection-limit-leaves-room-for-deploys",
+			limits: capacityLimits{total: 6, perOperation: map[provisioner.Operation]int{
+				provisioner.OperationInspection: 2,
+			}},
+			requests: []provisioner.CapacityData{inspection, provisioning, provisioning},
+			expected: []bool{false, true, true},
+		},
+		{
+			name: "cleaning-limit",
+			limits: capacityLimits{total: 6, perOperation: map[provisioner.Operation]int{
+				provisioner.OperationCleaning: 1,
+			}},
+			requests: []provisioner.CapacityData{cleaning, inspection},
+			expected: []bool{false, true},
+		},
+		{
+			name: "deleting-limit-leaves-room-for-cleaning",
+			limits: capacityLimits{total: 6, perOperation: map[provisioner.Operation]int{
+				provisioner.OperationDeleting: 1,
+			}},
+			requests: []provisioner.CapacityData{deleting, cleaning, deleting},
+			expected: []bool{true, true, false},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			cache := newCapacityCache(client, time.Hour)
+			for i, data := range tc.requests {
+				result, err := cache.reserve(fmt.Sprintf("host-%d", i), data, tc.limits)
+				assert.NoError(t, err)
+				assert.Equal(t, tc.expected[i], result, "request %d for %s", i, data.Operation)
+			}
+		})
+	}
+}
+
+func TestCapacityPriority(t *testing.T) {
+	ironic := testserver.NewIronic(t).Nodes([]nodes.Node{
+		{Name: "inspect-1", ProvisionState: string(nodes.InspectWait)},
+	})
+	ironic.Start()
+	defer ironic.Stop()
+
+	client, err := clients.IronicClient(ironic.Endpoint(), clients.AuthConfig{Type: clients.NoAuth}, clients.TLSConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	limits := capacityLimits{total: 2, perOperation: map[provisioner.Operation]int{
+		provisioner.OperationCleaning: 1,
+	}}
+	urgentDeploy := provisioner.CapacityData{Operation: provisioner.OperationProvisioning, Priority: 10}
+	inspection := provisioner.CapacityData{Operation: provisioner.OperationInspection}
+
+	cache := newCapacityCache(client, time.Hour)
+	result, err := cache.reserve("deploy", urgentDeploy, limits)
+	assert.NoError(t, err)
+	assert.True(t, result)
+
+	// Both slots are used, the urgent deploy has to wait.
+	result, _ = cache.reserve("deploy-2", urgentDeploy, limits)
+	assert.False(t, result)
+
+	// Once a slot is free, the waiting deploy gets it before
+	// inspections with a lower priority.
+	delete(cache.busy, "inspect-1")
+	result, _ = cache.reserve("inspect-2", inspection, limits)
+	assert.False(t, result, "the free slot is left to the deploy")
+	result, _ = cache.reserve("deploy-2", urgentDeploy, limits)
+	assert.True(t, result)
+
+	// Waiting hosts that can not get a slot because of the limit of
+	// their own operation do not hold others back.
+	delete(cache.reserved, "deploy")
+	cache.busy["clean-1"] = provisioner.OperationCleaning
+	delete(cache.reserved, "deploy-2")
+	result, _ = cache.reserve("clean-2", provisioner.CapacityData{Operation: provisioner.OperationCleaning, Priority: 10}, limits)
+	assert.False(t, result)
+	result, _ = cache.reserve("inspect-2", inspection, limits)
+	assert.True(t, result)
+}
@@ -14,9 +14,10 @@ import (
 func TestProvisionerIsReady(t *testing.T) {
 
 	cases := []struct {
-		name      string
-		ironic    *testserver.IronicMock
-		inspector *testserver.InspectorMock
+		name           string
+		conductorGroup string
+		ironic         *testserver.IronicMock
+		inspector      *testserver.InspectorMock
 
 		expectedIronicCalls    string
 		expectedInspectorCalls string
@@ -70,6 +71,31 @@ func TestProvisionerIsReady(t *testing.T) {
 			expectedIronicCalls:    "/v1;/v1/drivers;",
 			expectedInspectorCalls: "/v1;",
 		},
+		{
+			name:                   "ConductorGroupAlive",
+			conductorGroup:         "Rack-1",
+			ironic:                 testserver.NewIronic(t).Ready().WithDrivers().WithConductors("", "rack-1"),
+			inspector:              testserver.NewInspector(t).Ready(),
+			expectedIronicCalls:    "/v1;/v1/drivers;/v1/conductors;",
+			expectedInspectorCalls: "/v1;",
+			expectedIsReady:        true,
+		},
+		{
+			name:                "ConductorGroupWithoutConductor",
+			conductorGroup:      "rack-2",
+			ironic:              testserver.NewIronic(t).Ready().WithDrivers().WithConductors("", "rack-1"),
+			inspector:           testserver.NewInspector(t).Ready(),
+			expectedIronicCalls: "/v1;/v1/drivers;/v1/conductors;",
+			expectedIsReady:     false,
+		},
+		{
+			name:                   "EmptyConductorGroup",
+			ironic:                 testserver.NewIronic(t).Ready().WithDrivers().WithConductors("rack-1"),
+			inspector:              testserver.NewInspector(t).Ready(),
+			expectedIronicCalls:    "/v1;/v1/drivers;",
+			expectedInspectorCalls: "/v1;",
+			expectedIsReady:        true,
+		},
 	}
 
 	for _, tc := range cases {
@@ -88,7 +114,9 @@ func TestProvisionerIsReady(t *testing.T) {
 
 			ironicEndpoint := tc.ironic.Endpoint()
 			inspectorEndpoint := tc.inspector.Endpoint()
-			prov, err := newProvisionerWithSettings(makeHost(), bmc.Credentials{}, nil,
+			host := makeHost()
+			host.Spec.ConductorGroup = tc.conductorGroup
+			prov, err := newProvisionerWithSettings(host, bmc.Credentials{}, nil,
 				ironicEndpoint, auth, inspectorEndpoint, auth,
 			)
 			if err != nil {
@@ -83,6 +83,10 @@ func (a *testAccessDetails) VendorInterface() string {
 	return ""
 }
 
+func (a *testAccessDetails) OutOfBandInspectInterface() string {
+	return ""
+}
+
 func (a *testAccessDetails) SupportsSecureBoot() bool {
 	return false
 }
@@ -1,7 +1,14 @@
 package testserver
 
 import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
 	"net/http"
+	"sort"
+	"strings"
+	"sync"
 	"testing"
 
 	"github.com/gophercloud/gophercloud/openstack/baremetalintrospection/v1/introspection"
@@ -10,12 +17,16 @@ import (
 // InspectorMock is a test server that implements Ironic Inspector's semantics
 type InspectorMock struct {
 	*MockServer
+
+	rulesLock sync.Mutex
+	rules     map[string]map[string]interface{}
+	lastRule  int
 }
 
 // NewInspector builds a new inspector mock server
 func NewInspector(t *testing.T) *InspectorMock {
 	return &InspectorMock{
-		New(t, "inspector"),
+		MockServer: New(t, "inspector"),
 	}
 }
 
@@ -62,3 +73,86 @@ func (m *InspectorMock) WithIntrospectionDataFailed(nodeUUID string, errorCode i
 	m.ErrorResponse("/v1/introspection/"+nodeUUID+"/data", errorCode)
 	return m
 }
+
+// WithRules makes the server keep introspection rules in memory and
+// answer the rules API the way Ironic Inspector does
+func (m *InspectorMock) WithRules() *InspectorMock {
+	m.rules = map[string]map[string]interface{}{}
+	m.Handler("/v1/rules", m.handleRules)
+	m.Handler("/v1/rules/", m.handleRules)
+	return m
+}
+
+// Rules returns the introspection rules stored in the server, sorted by UUID
+func (m *InspectorMock) Rules() (rules []map[string]interface{}) {
+	m.rulesLock.Lock()
+	defer m.rulesLock.Unlock()
+	for _, rule := range m.rules {
+		rules = append(rules, rule)
+	}
+	sort.Slice(rules, func(i, j int) bool {
+		return fmt.Sprint(rules[i]["uuid"]) < fmt.Sprint(rules[j]["uuid"])
+	})
+	return
+}
+
+func (m *InspectorMock) handleRules(w http.ResponseWriter, r *http.Request) {
+	m.rulesLock.Lock()
+	defer m.rulesLock.Unlock()
+
+	id := strings.Trim(strings.TrimPrefix(r.URL.Path, "/v1/rules"), "/")
+	switch {
+	case id == "" && r.Method == http.MethodGet:
+		list := []map[string]interface{}{}
+		for uuid, rule := range m.rules {
+			list = append(list, map[string]interface{}{"uuid": uuid, "description": rule["description"]})
+		}
+		m.SendJSONResponse(map[string]interface{}{"rules": list}, http.StatusOK, w, r)
+	case id == "" && r.Method == http.MethodPost:
+		body, _ := ioutil.ReadAll(r.Body)
+		r.Body = ioutil.NopCloser(bytes.NewReader(body))
+		rule := map[string]interface{}{}
+		if err := json.Unmarshal(body, &rule); err != nil {
+			m.rulesError(w, r, http.StatusBadRequest, "Invalid JSON body")
+			return
+		}
+		if actions, _ := rule["actions"].([]interface{}); len(actions) == 0 {
+			m.rulesError(w, r, http.StatusBadRequest, "Validation failed for actions: [] is too short")
+			return
+		}
+		uuid, _ := rule["uuid"].(string)
+		if uuid == "" {
+			m.lastRule++
+			uuid = fmt.Sprintf("00000000-0000-0000-0000-%012d", m.lastRule)
+			rule["uuid"] = uuid
+		}
+		if _, exists := m.rules[uuid]; exists {
+			m.rulesError(w, r, http.StatusConflict, fmt.Sprintf("Rule with UUID %s already exists", uuid))
+			return
+		}
+		m.rules[uuid] = rule
+		m.SendJSONResponse(rule, http.StatusCreated, w, r)
+	case id == "" && r.Method == http.MethodDelete:
+		m.rules = map[string]map[string]interface{}{}
+		m.sendData(w, r, http.StatusNoContent, "")
+	default:
+		rule, exists := m.rules[id]
+		if !exists {
+			m.rulesError(w, r, http.StatusNotFound, fmt.Sprintf("Rule %s was not found", id))
+			return
+		}
+		switch r.Method {
+		case http.MethodGet:
+			m.SendJSONResponse(rule, http.StatusOK, w, r)
+		case http.MethodDelete:
+			delete(m.rules, id)
+			m.sendData(w, r, http.StatusNoContent, "")
+		default:
+			m.rulesError(w, r, http.StatusMethodNotAllowed, "Method not allowed")
+		}
+	}
+}
+
+func (m *InspectorMock) rulesError(w http.ResponseWriter, r *http.Request, code int, message string) {
+	m.SendJSONResponse(map[string]interface{}{"error": map[string]interface{}{"message": message}}, code, w, r)
+}
@@ -36,6 +36,8 @@ func (m *IronicMock) WithDefaultResponses() *IronicMock {
 	m.AddDefaultResponse("/v1/nodes/{id}/states/power", "", http.StatusAccepted, "{}")
 	m.AddDefaultResponse("/v1/nodes/{id}/states/raid", "", http.StatusNoContent, "{}")
 	m.AddDefaultResponse("/v1/nodes/{id}/validate", "", http.StatusOK, "{}")
+	m.AddDefaultResponse("/v1/ports/detail", "", http.StatusOK, `{"ports": []}`)
+	m.AddDefaultResponse("/v1/portgroups/detail", "", http.StatusOK, `{"portgroups": []}`)
 	m.Ready()
 
 	return m
@@ -86,6 +88,21 @@ func (m *IronicMock) WithDrivers() *IronicMock {
 	return m
 }
 
+// WithConductors configures the server so /v1/conductors returns an
+// alive conductor in each of the given conductor groups
+func (m *IronicMock) WithConductors(groups ...string) *IronicMock {
+	conductors := []map[string]interface{}{}
+	for i, group := range groups {
+		conductors = append(conductors, map[string]interface{}{
+			"hostname":        fmt.Sprintf("conductor-%d", i),
+			"conductor_group": group,
+			"alive":           true,
+		})
+	}
+	m.ResponseJSON("/v1/conductors", map[string]interface{}{"conductors": conductors})
+	return m
+}
+
 func (m *IronicMock) buildURL(url string, method string) string {
 	return fmt.Sprintf("%s:%s", url, method)
 }
@@ -0,0 +1,1133 @@
+package testserver
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gophercloud/gophercloud/openstack/baremetal/v1/nodes"
+	"github.com/gophercloud/gophercloud/openstack/baremetal/v1/ports"
+	"github.com/gophercloud/gophercloud/openstack/baremetalintrospection/v1/introspection"
+)
+
+/*
+The Simulator is a stateful alternative to IronicMock and
+InspectorMock. Instead of canned responses it keeps nodes, ports and
+introspection results in memory and moves nodes through the Ironic
+state machine the way a conductor would: API calls start transitions
+(for example "manage" puts an enrolled node in "verifying") and every
+call to Tick advances each node by one step (from "verifying" to
+"manageable"). Requests can be failed with a Fault, and transitions
+can be failed with a Failure, so error handling can be exercised
+end-to-end.
+*/
+
+// Fault makes the simulator answer matching requests with an error.
+type Fault struct {
+	// Method to match, any method when empty.
+	Method string
+	// PathPrefix to match, any path when empty.
+	PathPrefix string
+	// StatusCode returned instead of handling the request.
+	StatusCode int
+	// Count is the number of requests to fail, zero fails all of
+	// them until the faults are cleared.
+	Count int
+}
+
+func (f *Fault) matches(r *http.Request) bool {
+	return (f.Method == "" || f.Method == r.Method) &&
+		strings.HasPrefix(r.URL.Path, f.PathPrefix)
+}
+
+// Failure makes the next transition of a node out of State fail, as
+// if the conductor had hit an error. The node moves to the matching
+// failed state ("inspect failed", "clean failed", "deploy failed"...)
+// and Message is recorded as its last_error. State may also be a
+// target power state such as "power on", in which case the power
+// state does not change.
+type Failure struct {
+	// Node is the UUID or name of the node, any node when empty.
+	Node    string
+	State   string
+	Message string
+}
+
+// failedStates maps the transitional states to the state a node
+// lands in when the transition fails.
+var failedStates = map[nodes.ProvisionState]nodes.ProvisionState{
+	nodes.Verifying:   nodes.Enroll,
+	nodes.Inspecting:  nodes.InspectFail,
+	nodes.InspectWait: nodes.InspectFail,
+	nodes.Cleaning:    nodes.CleanFail,
+	nodes.CleanWait:   nodes.CleanFail,
+	nodes.Deploying:   nodes.DeployFail,
+	nodes.DeployWait:  nodes.DeployFail,
+	nodes.Deleting:    nodes.Error,
+	nodes.Adopting:    nodes.AdoptFail,
+}
+
+// deletableStates are the states in which a node can be deleted
+// without being in maintenance.
+var deletableStates = map[nodes.ProvisionState]bool{
+	nodes.Enroll:      true,
+	nodes.Manageable:  true,
+	nodes.Available:   true,
+	nodes.InspectFail: true,
+	nodes.CleanFail:   true,
+	nodes.AdoptFail:   true,
+}
+
+type simNode map[string]interface{}
+
+func (n simNode) str(field string) string {
+	value, _ := n[field].(string)
+	return value
+}
+
+func (n simNode) provisionState() nodes.ProvisionState {
+	return nodes.ProvisionState(n.str("provision_state"))
+}
+
+func (n simNode) setProvisionState(state nodes.ProvisionState, target nodes.ProvisionState) {
+	n["provision_state"] = string(state)
+	if target == "" {
+		n["target_provision_state"] = nil
+	} else {
+		n["target_provision_state"] = string(target)
+	}
+}
+
+func (n simNode) setPowerState(state string) {
+	n["power_state"] = state
+	n["target_power_state"] = nil
+}
+
+func (n simNode) hasTrait(trait string) bool {
+	traits, _ := n["traits"].([]interface{})
+	for _, t := range traits {
+		if t == trait {
+			return true
+		}
+	}
+	return false
+}
+
+func (n simNode) subMap(field string) map[string]interface{} {
+	value, ok := n[field].(map[string]interface{})
+	if !ok {
+		value = map[string]interface{}{}
+		n[field] = value
+	}
+	return value
+}
+
+// Simulator serves the Ironic and Ironic Inspector APIs from an
+// in-memory model. It is safe for concurrent use.
+type Simulator struct {
+	t         *testing.T
+	mu        sync.Mutex
+	ironic    *httptest.Server
+	inspector *httptest.Server
+
+	nodes          map[string]simNode
+	ports          map[string]map[string]interface{}
+	portGroups     map[string]map[string]interface{}
+	introspections map[string]*introspection.Introspection
+	data           map[string]introspection.Data
+	faults         []*Fault
+	failures       []Failure
+	lastID         int
+
+	// IntrospectionData returns the data reported by the inspector
+	// for a node, given its boot MAC. It defaults to a small virtual
+	// machine with a single disk and NIC.
+	IntrospectionData func(bootMAC string) introspection.Data
+}
+
+// NewSimulator builds a simulator with no nodes
+func NewSimulator(t *testing.T) *Simulator {
+	return &Simulator{
+		t:                 t,
+		nodes:             map[string]simNode{},
+		ports:             map[string]map[string]interface{}{},
+		portGroups:        map[string]map[string]interface{}{},
+		introspections:    map[string]*introspection.Introspection{},
+		data:              map[string]introspection.Data{},
+		IntrospectionData: DefaultIntrospectionData,
+	}
+}
+
+// Start runs the Ironic and Inspector servers
+func (s *Simulator) Start() *Simulator {
+	s.ironic = httptest.NewServer(s.handler("ironic", s.handleIronic))
+	s.inspector = httptest.NewServer(s.handler("inspector", s.handleInspector))
+	return s
+}
+
+// Stop closes the servers down
+func (s *Simulator) Stop() {
+	s.ironic.Close()
+	s.inspector.Close()
+}
+
+// Endpoint returns the URL of the Ironic API
+func (s *Simulator) Endpoint() string {
+	return s.ironic.URL + "/v1/"
+}
+
+// InspectorEndpoint returns the URL of the Inspector API
+func (s *Simulator) InspectorEndpoint() string {
+	return s.inspector.URL + "/v1/"
+}
+
+// InjectFault adds a fault. Faults are checked in the order they were
+// added and apply to both servers.
+func (s *Simulator) InjectFault(fault Fault) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	f := fault
+	s.faults = append(s.faults, &f)
+}
+
+// ClearFaults removes all the faults and pending failures.
+func (s *Simulator) ClearFaults() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.faults = nil
+	s.failures = nil
+}
+
+// InjectFailure makes the next matching transition fail.
+func (s *Simulator) InjectFailure(failure Failure) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failures = append(s.failures, failure)
+}
+
+// Node returns the current state of a node, looked up by UUID or name.
+func (s *Simulator) Node(ident string) (node nodes.Node, found bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	n := s.findNode(ident)
+	if n == nil {
+		return
+	}
+	content, _ := json.Marshal(n)
+	if err := json.Unmarshal(content, &node); err != nil {
+		s.t.Error(err)
+	}
+	return node, true
+}
+
+// Ports returns the ports of a node, given its UUID.
+func (s *Simulator) Ports(nodeUUID string) (result []ports.Port) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, port := range s.sortedPorts() {
+		if port["node_uuid"] != nodeUUID {
+			continue
+		}
+		var p ports.Port
+		content, _ := json.Marshal(port)
+		if err := json.Unmarshal(content, &p); err != nil {
+			s.t.Error(err)
+		}
+		result = append(result, p)
+	}
+	return
+}
+
+// PortGroups returns the port groups of a node, given its UUID.
+func (s *Simulator) PortGroups(nodeUUID string) (result []map[string]interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, group := range sortedByUUID(s.portGroups) {
+		if group["node_uuid"] == nodeUUID {
+			result = append(result, group)
+		}
+	}
+	return
+}
+
+// Nodes returns the number of nodes.
+func (s *Simulator) Nodes() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.nodes)
+}
+
+// Tick advances every node by one step of its current transition.
+func (s *Simulator) Tick() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, uuid := range s.sortedNodeIDs() {
+		s.advance(s.nodes[uuid])
+	}
+}
+
+// TickEvery calls Tick periodically until the returned function is
+// called, for tests that do not drive the simulator step by step.
+func (s *Simulator) TickEvery(interval time.Duration) (stop func()) {
+	done := make(chan struct{})
+	ticker := time.NewTicker(interval)
+	go func() {
+		for {
+			select {
+			case <-ticker.C:
+				s.Tick()
+			case <-done:
+				ticker.Stop()
+				return
+			}
+		}
+	}()
+	return func() { close(done) }
+}
+
+func (s *Simulator) sortedNodeIDs() []string {
+	ids := make([]string, 0, len(s.nodes))
+	for uuid := range s.nodes {
+		ids = append(ids, uuid)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+func (s *Simulator) newUUID() string {
+	s.lastID++
+	return fmt.Sprintf("00000000-0000-4000-8000-%012d", s.lastID)
+}
+
+func (s *Simulator) findNode(ident string) simNode {
+	if n, ok := s.nodes[ident]; ok {
+		return n
+	}
+	for _, uuid := range s.sortedNodeIDs() {
+		if n := s.nodes[uuid]; n.str("name") == ident && ident != "" {
+			return n
+		}
+	}
+	return nil
+}
+
+// takeFailure consumes the first failure matching the node and state.
+func (s *Simulator) takeFailure(n simNode, state string) (message string, found bool) {
+	for i, f := range s.failures {
+		if f.State != state ||
+			(f.Node != "" && f.Node != n.str("uuid") && f.Node != n.str("name")) {
+			continue
+		}
+		s.failures = append(s.failures[:i], s.failures[i+1:]...)
+		message = f.Message
+		if message == "" {
+			message = fmt.Sprintf("simulated failure in %s", state)
+		}
+		return message, true
+	}
+	return "", false
+}
+
+func (s *Simulator) advance(n simNode) {
+	uuid := n.str("uuid")
+
+	if target := n.str("target_power_state"); target != "" {
+		if message, failed := s.takeFailure(n, target); failed {
+			n["target_power_state"] = nil
+			n["last_error"] = message
+		} else if target == string(nodes.SoftPowerOff) {
+			n.setPowerState(powerOff)
+		} else {
+			n.setPowerState(target)
+		}
+	}
+
+	state := n.provisionState()
+	failedState, transitional := failedStates[state]
+	if !transitional {
+		return
+	}
+	if message, failed := s.takeFailure(n, string(state)); failed {
+		n.setProvisionState(failedState, "")
+		n["last_error"] = message
+		if intro, ok := s.introspections[uuid]; ok && !intro.Finished {
+			intro.Finished = true
+			intro.State = "error"
+			intro.Error = message
+			intro.FinishedAt = time.Now().UTC()
+		}
+		s.t.Logf("simulator: node %s failed in %s: %s", uuid, state, message)
+		return
+	}
+
+	target := nodes.ProvisionState(n.str("target_provision_state"))
+	switch state {
+	case nodes.Verifying:
+		n.setProvisionState(nodes.Manageable, "")
+		n.setPowerState(powerOff)
+	case nodes.Inspecting:
+		if outOfBand(n) {
+			n.setProvisionState(nodes.Manageable, "")
+			s.inspectOutOfBand(n)
+			n["inspection_finished_at"] = time.Now().UTC().Format(time.RFC3339)
+			break
+		}
+		n.setProvisionState(nodes.InspectWait, target)
+	case nodes.InspectWait:
+		n.setProvisionState(nodes.Manageable, "")
+		n.setPowerState(powerOff)
+		intro := s.introspections[uuid]
+		intro.Finished = true
+		intro.State = "finished"
+		intro.FinishedAt = time.Now().UTC()
+		s.data[uuid] = s.IntrospectionData(s.bootMAC(uuid))
+		n["inspection_finished_at"] = intro.FinishedAt.Format(time.RFC3339)
+	case nodes.Cleaning:
+		n.setProvisionState(nodes.CleanWait, target)
+	case nodes.CleanWait:
+		n.setProvisionState(target, "")
+		n.setPowerState(powerOff)
+		n["clean_step"] = map[string]interface{}{}
+	case nodes.Deploying:
+		n.setProvisionState(nodes.DeployWait, target)
+	case nodes.DeployWait:
+		n.setProvisionState(nodes.Active, "")
+		n.setPowerState(powerOn)
+		n["deploy_step"] = map[string]interface{}{}
+	case nodes.Deleting:
+		delete(n.subMap("instance_info"), "configdrive")
+		if automatedClean(n) {
+			n.setProvisionState(nodes.Cleaning, nodes.Available)
+		} else {
+			n.setProvisionState(nodes.Available, "")
+			n.setPowerState(powerOff)
+		}
+	case nodes.Adopting:
+		n.setProvisionState(nodes.Active, "")
+	}
+	s.t.Logf("simulator: node %s moved from %s to %s", uuid, state, n.provisionState())
+}
+
+const (
+	powerOn  = string(nodes.PowerOn)
+	powerOff = string(nodes.PowerOff)
+)
+
+// outOfBand returns whether the node is inspected through its BMC
+// rather than by the inspector.
+func outOfBand(n simNode) bool {
+	switch n.str("inspect_interface") {
+	case "", "inspector", "no-inspection":
+		return false
+	}
+	return true
+}
+
+// inspectOutOfBand records what a BMC reports: the basic properties and
+// a port for each NIC.
+func (s *Simulator) inspectOutOfBand(n simNode) {
+	uuid := n.str("uuid")
+	data := s.IntrospectionData(s.bootMAC(uuid))
+	properties := n.subMap("properties")
+	properties["cpu_arch"] = data.CPUArch
+	properties["cpus"] = data.CPUs
+	properties["memory_mb"] = data.MemoryMB
+	if len(data.Inventory.Disks) > 0 {
+		properties["local_gb"] = data.Inventory.Disks[0].Size / (1024 * 1024 * 1024)
+	}
+
+	names := make([]string, 0, len(data.AllInterfaces))
+	for name := range data.AllInterfaces {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		address := data.AllInterfaces[name].MAC
+		exists := false
+		for _, port := range s.ports {
+			exists = exists || strings.EqualFold(fmt.Sprint(port["address"]), address)
+		}
+		if exists {
+			continue
+		}
+		port := map[string]interface{}{
+			"uuid":        s.newUUID(),
+			"address":     address,
+			"node_uuid":   uuid,
+			"pxe_enabled": false,
+		}
+		s.ports[port["uuid"].(string)] = port
+	}
+}
+
+func automatedClean(n simNode) bool {
+	// Ironic uses the conductor default when the node does not say.
+	clean, ok := n["automated_clean"].(bool)
+	return clean || !ok
+}
+
+func (s *Simulator) bootMAC(uuid string) string {
+	for _, port := range s.sortedPorts() {
+		if port["node_uuid"] == uuid && port["pxe_enabled"] == true {
+			address, _ := port["address"].(string)
+			return address
+		}
+	}
+	return ""
+}
+
+func (s *Simulator) sortedPorts() []map[string]interface{} {
+	return sortedByUUID(s.ports)
+}
+
+func sortedByUUID(objects map[string]map[string]interface{}) []map[string]interface{} {
+	ids := make([]string, 0, len(objects))
+	for uuid := range objects {
+		ids = append(ids, uuid)
+	}
+	sort.Strings(ids)
+	result := make([]map[string]interface{}, 0, len(ids))
+	for _, uuid := range ids {
+		result = append(result, objects[uuid])
+	}
+	return result
+}
+
+// DefaultIntrospectionData describes a small virtual machine booting
+// from a NIC with the given MAC.
+func DefaultIntrospectionData(bootMAC string) introspection.Data {
+	return introspection.Data{
+		CPUArch:  "x86_64",
+		CPUs:     4,
+		MemoryMB: 8192,
+		MACs:     []string{bootMAC},
+		AllInterfaces: map[string]introspection.BaseInterfaceType{
+			"eth0": {MAC: bootMAC, IP: "192.168.111.20", PXE: true},
+		},
+		Inventory: introspection.InventoryType{
+			Hostname: "localhost",
+			Boot:     introspection.BootInfoType{CurrentBootMode: "uefi", PXEInterface: bootMAC},
+			CPU: introspection.CPUType{
+				Architecture: "x86_64",
+				Count:        4,
+				Frequency:    "2400.000",
+				ModelName:    "Simulated CPU",
+			},
+			Disks: []introspection.RootDiskType{{
+				Name:  "/dev/sda",
+				Model: "Simulated Disk",
+				Size:  100 * 1024 * 1024 * 1024,
+			}},
+			Interfaces: []introspection.InterfaceType{{
+				Name:        "eth0",
+				MACAddress:  bootMAC,
+				IPV4Address: "192.168.111.20",
+				HasCarrier:  true,
+			}},
+			Memory: introspection.MemoryType{PhysicalMb: 8192, Total: 8 * 1024 * 1024 * 1024},
+			SystemVendor: introspection.SystemVendorType{
+				Manufacturer: "Metal3",
+				ProductName:  "Simulated",
+				SerialNumber: bootMAC,
+			},
+		},
+	}
+}
+
+// simError is an error answered by the simulator, with the Ironic
+// error body format.
+type simError struct {
+	code    int
+	message string
+}
+
+func (e *simError) Error() string {
+	return e.message
+}
+
+func errorf(code int, format string, args ...interface{}) *simError {
+	return &simError{code: code, message: fmt.Sprintf(format, args...)}
+}
+
+type simHandler func(method string, parts []string, query map[string][]string, body []byte) (int, interface{}, *simError)
+
+func (s *Simulator) handler(name string, handle simHandler) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+
+		var code int
+		var payload interface{}
+		var err *simError
+
+		s.mu.Lock()
+		if fault := s.injectedFault(r); fault != 0 {
+			err = errorf(fault, "injected fault")
+		} else {
+			parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+			code, payload, err = handle(r.Method, parts, r.URL.Query(), body)
+		}
+		if err != nil {
+			code = err.code
+			payload = map[string]string{"error_message": err.message}
+		}
+		content, _ := json.Marshal(payload)
+		s.mu.Unlock()
+
+		s.t.Logf("%s: %s %s -> %d %s", name, r.Method, r.URL, code, content)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(code)
+		if code != http.StatusNoContent {
+			w.Write(content)
+		}
+	}
+}
+
+func (s *Simulator) injectedFault(r *http.Request) int {
+	for i, f := range s.faults {
+		if !f.matches(r) {
+			continue
+		}
+		if f.Count > 0 {
+			f.Count--
+			if f.Count == 0 {
+				s.faults = append(s.faults[:i], s.faults[i+1:]...)
+			}
+		}
+		return f.StatusCode
+	}
+	return 0
+}
+
+func decode(body []byte, out interface{}) *simError {
+	if err := json.Unmarshal(body, out); err != nil {
+		return errorf(http.StatusBadRequest, "invalid request body: %s", err)
+	}
+	return nil
+}
+
+func (s *Simulator) handleIronic(method string, parts []string, query map[string][]string, body []byte) (int, interface{}, *simError) {
+	if len(parts) == 0 || parts[0] != "v1" {
+		return 0, nil, errorf(http.StatusNotFound, "not found")
+	}
+	switch {
+	case len(parts) == 1 && method == http.MethodGet:
+		return http.StatusOK, map[string]interface{}{"id": "v1"}, nil
+	case len(parts) == 2 && parts[1] == "drivers" && method == http.MethodGet:
+		return http.StatusOK, map[string]interface{}{
+			"drivers": []map[string]interface{}{
+				{"name": "ipmi", "hosts": []string{"simulator"}},
+				{"name": "redfish", "hosts": []string{"simulator"}},
+			},
+		}, nil
+	case len(parts) >= 2 && parts[1] == "nodes":
+		return s.handleNodes(method, parts[2:], body)
+	case len(parts) >= 2 && parts[1] == "ports":
+		return s.handlePorts(method, parts[2:], query, body)
+	case len(parts) >= 2 && parts[1] == "portgroups":
+		return s.handlePortGroups(method, parts[2:], query, body)
+	}
+	return 0, nil, errorf(http.StatusNotFound, "not found")
+}
+
+func (s *Simulator) handleNodes(method string, parts []string, body []byte) (int, interface{}, *simError) {
+	if len(parts) == 0 || (len(parts) == 1 && parts[0] == "detail") {
+		switch method {
+		case http.MethodGet:
+			list := []simNode{}
+			for _, uuid := range s.sortedNodeIDs() {
+				list = append(list, s.nodes[uuid])
+			}
+			return http.StatusOK, map[string]interface{}{"nodes": list}, nil
+		case http.MethodPost:
+			if len(parts) == 0 {
+				return s.createNode(body)
+			}
+		}
+		return 0, nil, errorf(http.StatusMethodNotAllowed, "method not allowed")
+	}
+
+	n := s.findNode(parts[0])
+	if n == nil {
+		return 0, nil, errorf(http.StatusNotFound, "Node %s could not be found.", parts[0])
+	}
+	switch {
+	case len(parts) == 1 && method == http.MethodGet:
+		return http.StatusOK, n, nil
+	case len(parts) == 1 && method == http.MethodPatch:
+		return s.updateNode(n, body)
+	case len(parts) == 1 && method == http.MethodDelete:
+		return s.deleteNode(n)
+	case len(parts) == 2 && parts[1] == "validate" && method == http.MethodGet:
+		result := map[string]interface{}{}
+		for _, iface := range []string{"boot", "deploy", "inspect", "management", "power", "raid"} {
+			result[iface] = map[string]interface{}{"result": true}
+		}
+		return http.StatusOK, result, nil
+	case len(parts) == 3 && parts[1] == "traits" && method == http.MethodPut:
+		if !n.hasTrait(parts[2]) {
+			traits, _ := n["traits"].([]interface{})
+			n["traits"] = append(traits, parts[2])
+		}
+		return http.StatusNoContent, nil, nil
+	case len(parts) == 3 && parts[1] == "traits" && method == http.MethodDelete:
+		if !n.hasTrait(parts[2]) {
+			return 0, nil, errorf(http.StatusNotFound, "Node %s does not have trait %s.", parts[0], parts[2])
+		}
+		traits, _ := n["traits"].([]interface{})
+		kept := []interface{}{}
+		for _, t := range traits {
+			if t != parts[2] {
+				kept = append(kept, t)
+			}
+		}
+		n["traits"] = kept
+		return http.StatusNoContent, nil, nil
+	case len(parts) == 3 && parts[1] == "states" && method == http.MethodPut:
+		switch parts[2] {
+		case "provision":
+			return s.changeProvisionState(n, body)
+		case "power":
+			return s.changePowerState(n, body)
+		case "raid":
+			var config map[string]interface{}
+			if err := decode(body, &config); err != nil {
+				return 0, nil, err
+			}
+			n["target_raid_config"] = config
+			return http.StatusNoContent, nil, nil
+		}
+	}
+	return 0, nil, errorf(http.StatusNotFound, "not found")
+}
+
+func (s *Simulator) createNode(body []byte) (int, interface{}, *simError) {
+	n := simNode{}
+	if err := decode(body, &n); err != nil {
+		return 0, nil, err
+	}
+	if name := n.str("name"); name != "" && s.findNode(name) != nil {
+		return 0, nil, errorf(http.StatusConflict, "A node with name %s already exists.", name)
+	}
+	n["uuid"] = s.newUUID()
+	n.setProvisionState(nodes.Enroll, "")
+	n["power_state"] = nil
+	n["target_power_state"] = nil
+	n["maintenance"] = false
+	n["last_error"] = nil
+	if _, ok := n["traits"]; !ok {
+		n["traits"] = []interface{}{}
+	}
+	for _, field := range []string{"driver_info", "instance_info", "properties", "extra", "driver_internal_info"} {
+		n.subMap(field)
+	}
+	s.nodes[n.str("uuid")] = n
+	return http.StatusCreated, n, nil
+}
+
+func (s *Simulator) updateNode(n simNode, body []byte) (int, interface{}, *simError) {
+	var patch []nodes.UpdateOperation
+	if err := decode(body, &patch); err != nil {
+		return 0, nil, err
+	}
+	for _, op := range patch {
+		path := strings.Split(strings.Trim(op.Path, "/"), "/")
+		switch path[0] {
+		case "uuid", "provision_state", "target_provision_state", "power_state", "target_power_state":
+			return 0, nil, errorf(http.StatusBadRequest, "'/%s' is an internal attribute and can not be updated", path[0])
+		case "name":
+			if other := s.findNode(fmt.Sprint(op.Value)); other != nil && other.str("uuid") != n.str("uuid") {
+				return 0, nil, errorf(http.StatusConflict, "A node with name %v already exists.", op.Value)
+			}
+		}
+
+		parent := map[string]interface{}(n)
+		for _, field := range path[:len(path)-1] {
+			parent = simNode(parent).subMap(field)
+		}
+		last := path[len(path)-1]
+		switch op.Op {
+		case nodes.AddOp, nodes.ReplaceOp:
+			parent[last] = op.Value
+		case nodes.RemoveOp:
+			if _, ok := parent[last]; !ok {
+				return 0, nil, errorf(http.StatusBadRequest, "can't remove non-existent object '%s'", last)
+			}
+			delete(parent, last)
+		default:
+			return 0, nil, errorf(http.StatusBadRequest, "unsupported patch operation %s", op.Op)
+		}
+	}
+	return http.StatusOK, n, nil
+}
+
+func (s *Simulator) deleteNode(n simNode) (int, interface{}, *simError) {
+	maintenance, _ := n["maintenance"].(bool)
+	if !maintenance && !deletableStates[n.provisionState()] {
+		return 0, nil, errorf(http.StatusConflict, "Can not delete node %s in state %s", n.str("uuid"), n.provisionState())
+	}
+	uuid := n.str("uuid")
+	delete(s.nodes, uuid)
+	delete(s.introspections, uuid)
+	delete(s.data, uuid)
+	for id, port := range s.ports {
+		if port["node_uuid"] == uuid {
+			delete(s.ports, id)
+		}
+	}
+	for id, group := range s.portGroups {
+		if group["node_uuid"] == uuid {
+			delete(s.portGroups, id)
+		}
+	}
+	return http.StatusNoContent, nil, nil
+}
+
+func (s *Simulator) changeProvisionState(n simNode, body []byte) (int, interface{}, *simError) {
+	var opts nodes.ProvisionStateOpts
+	if err := decode(body, &opts); err != nil {
+		return 0, nil, err
+	}
+	state := n.provisionState()
+	if n.str("target_provision_state") != "" {
+		return 0, nil, errorf(http.StatusConflict, "Node %s is locked, current state is %s", n.str("uuid"), state)
+	}
+
+	invalid := errorf(http.StatusBadRequest,
+		"The requested action \"%s\" can not be performed on node \"%s\" while it is in state \"%s\".",
+		opts.Target, n.str("uuid"), state)
+	in := func(states ...nodes.ProvisionState) bool {
+		for _, st := range states {
+			if st == state {
+				return true
+			}
+		}
+		return false
+	}
+
+	n["last_error"] = nil
+	switch opts.Target {
+	case nodes.TargetManage:
+		switch {
+		case state == nodes.Enroll:
+			n.setProvisionState(nodes.Verifying, nodes.Manageable)
+		case in(nodes.Available, nodes.InspectFail, nodes.CleanFail, nodes.AdoptFail):
+			n.setProvisionState(nodes.Manageable, "")
+		default:
+			return 0, nil, invalid
+		}
+	case nodes.TargetInspect:
+		if !in(nodes.Manageable, nodes.InspectFail) {
+			return 0, nil, invalid
+		}
+		n.setProvisionState(nodes.Inspecting, nodes.Manageable)
+		n["inspection_finished_at"] = nil
+		if outOfBand(n) {
+			break
+		}
+		s.introspections[n.str("uuid")] = &introspection.Introspection{
+			UUID:      n.str("uuid"),
+			State:     "waiting",
+			StartedAt: time.Now().UTC(),
+		}
+		delete(s.data, n.str("uuid"))
+	case nodes.TargetProvide:
+		if !in(nodes.Manageable) {
+			return 0, nil, invalid
+		}
+		if automatedClean(n) {
+			n.setProvisionState(nodes.Cleaning, nodes.Available)
+		} else {
+			n.setProvisionState(nodes.Available, "")
+		}
+	case nodes.TargetClean:
+		if !in(nodes.Manageable) {
+			return 0, nil, invalid
+		}
+		if len(opts.CleanSteps) == 0 {
+			return 0, nil, errorf(http.StatusBadRequest, "clean_steps are required for manual cleaning")
+		}
+		n.setProvisionState(nodes.Cleaning, nodes.Manageable)
+		n["clean_step"] = opts.CleanSteps[0]
+	case nodes.TargetActive:
+		if !in(nodes.Available, nodes.DeployFail) {
+			return 0, nil, invalid
+		}
+		if traits, ok := n.subMap("instance_info")["traits"].([]interface{}); ok {
+			for _, trait := range traits {
+				if !n.hasTrait(fmt.Sprint(trait)) {
+					return 0, nil, errorf(http.StatusBadRequest,
+						"Cannot deploy node %s: trait %v is requested but not set on the node", n.str("uuid"), trait)
+				}
+			}
+		}
+		if opts.ConfigDrive != nil {
+			n.subMap("instance_info")["configdrive"] = opts.ConfigDrive
+		}
+		n.subMap("driver_internal_info")["user_deploy_steps"] = opts.DeploySteps
+		n.setProvisionState(nodes.Deploying, nodes.Active)
+	case nodes.TargetDeleted:
+		if !in(nodes.Active, nodes.DeployFail, nodes.DeployWait, nodes.Error) {
+			return 0, nil, invalid
+		}
+		n.setProvisionState(nodes.Deleting, nodes.Available)
+	case nodes.TargetAdopt:
+		if !in(nodes.Manageable, nodes.AdoptFail) {
+			return 0, nil, invalid
+		}
+		n.setProvisionState(nodes.Adopting, nodes.Active)
+	case nodes.TargetAbort:
+		failed, ok := failedStates[state]
+		if !ok || !in(nodes.CleanWait, nodes.InspectWait, nodes.DeployWait) {
+			return 0, nil, invalid
+		}
+		n.setProvisionState(failed, "")
+		n["last_error"] = "aborted"
+	default:
+		return 0, nil, invalid
+	}
+	return http.StatusAccepted, nil, nil
+}
+
+func (s *Simulator) changePowerState(n simNode, body []byte) (int, interface{}, *simError) {
+	var opts nodes.PowerStateOpts
+	if err := decode(body, &opts); err != nil {
+		return 0, nil, err
+	}
+	if n.str("target_power_state") != "" {
+		return 0, nil, errorf(http.StatusConflict, "Node %s is locked by a power state change", n.str("uuid"))
+	}
+	switch opts.Target {
+	case nodes.PowerOn, nodes.PowerOff, nodes.SoftPowerOff:
+		n["target_power_state"] = string(opts.Target)
+	case nodes.Rebooting, nodes.SoftRebooting:
+		n["target_power_state"] = powerOn
+	default:
+		return 0, nil, errorf(http.StatusBadRequest, "unsupported power state %s", opts.Target)
+	}
+	n["last_error"] = nil
+	return http.StatusAccepted, nil, nil
+}
+
+// portUpdatableStates are the states in which Ironic lets the port
+// group of a port change without putting the node in maintenance.
+var portUpdatableStates = map[nodes.ProvisionState]bool{
+	nodes.Enroll:      true,
+	nodes.Manageable:  true,
+	nodes.Inspecting:  true,
+	nodes.InspectWait: true,
+}
+
+// matchesNode returns whether an object belongs to the node named in
+// the query, if any.
+func matchesNode(object map[string]interface{}, query map[string][]string) bool {
+	for _, key := range []string{"node", "node_uuid"} {
+		if node := query[key]; len(node) > 0 && node[0] != object["node_uuid"] {
+			return false
+		}
+	}
+	return true
+}
+
+// patchObject applies a JSON patch to the top level fields of an
+// object.
+func patchObject(object map[string]interface{}, body []byte) *simError {
+	var patch []ports.UpdateOperation
+	if err := decode(body, &patch); err != nil {
+		return err
+	}
+	for _, op := range patch {
+		field := strings.Trim(op.Path, "/")
+		switch op.Op {
+		case ports.AddOp, ports.ReplaceOp:
+			object[field] = op.Value
+		case ports.RemoveOp:
+			object[field] = nil
+		default:
+			return errorf(http.StatusBadRequest, "unsupported patch operation %s", op.Op)
+		}
+	}
+	return nil
+}
+
+func (s *Simulator) handlePorts(method string, parts []string, query map[string][]string, body []byte) (int, interface{}, *simError) {
+	switch {
+	case method == http.MethodGet && (len(parts) == 0 || (len(parts) == 1 && parts[0] == "detail")):
+		list := []map[string]interface{}{}
+		for _, port := range s.sortedPorts() {
+			if address := query["address"]; len(address) > 0 && !strings.EqualFold(address[0], fmt.Sprint(port["address"])) {
+				continue
+			}
+			if !matchesNode(port, query) {
+				continue
+			}
+			list = append(list, port)
+		}
+		return http.StatusOK, map[string]interface{}{"ports": list}, nil
+	case method == http.MethodPost && len(parts) == 0:
+		var opts ports.CreateOpts
+		if err := decode(body, &opts); err != nil {
+			return 0, nil, err
+		}
+		if s.findNode(opts.NodeUUID) == nil {
+			return 0, nil, errorf(http.StatusBadRequest, "Node %s could not be found.", opts.NodeUUID)
+		}
+		for _, port := range s.ports {
+			if strings.EqualFold(fmt.Sprint(port["address"]), opts.Address) {
+				return 0, nil, errorf(http.StatusConflict, "A port with MAC address %s already exists.", opts.Address)
+			}
+		}
+		if opts.PortGroupUUID != "" && s.portGroups[opts.PortGroupUUID] == nil {
+			return 0, nil, errorf(http.StatusBadRequest, "Portgroup %s could not be found.", opts.PortGroupUUID)
+		}
+		port := map[string]interface{}{
+			"uuid":             s.newUUID(),
+			"address":          opts.Address,
+			"node_uuid":        opts.NodeUUID,
+			"pxe_enabled":      opts.PXEEnabled == nil || *opts.PXEEnabled,
+			"portgroup_uuid":   nilIfEmpty(opts.PortGroupUUID),
+			"physical_network": nilIfEmpty(opts.PhysicalNetwork),
+			"extra":            opts.Extra,
+		}
+		s.ports[port["uuid"].(string)] = port
+		return http.StatusCreated, port, nil
+	case len(parts) == 1 && method != http.MethodGet:
+		port, found := s.ports[parts[0]]
+		if !found {
+			return 0, nil, errorf(http.StatusNotFound, "Port %s could not be found.", parts[0])
+		}
+		if method == http.MethodDelete {
+			delete(s.ports, parts[0])
+			return http.StatusNoContent, nil, nil
+		}
+		if method != http.MethodPatch {
+			break
+		}
+		n := s.findNode(fmt.Sprint(port["node_uuid"]))
+		if maintenance, _ := n["maintenance"].(bool); !maintenance && !portUpdatableStates[n.provisionState()] &&
+			strings.Contains(string(body), "/portgroup_uuid") {
+			return 0, nil, errorf(http.StatusConflict, "Can not change the port group of a port of node %s in state %s",
+				n.str("uuid"), n.provisionState())
+		}
+		updated := map[string]interface{}{}
+		for key, value := range port {
+			updated[key] = value
+		}
+		if err := patchObject(updated, body); err != nil {
+			return 0, nil, err
+		}
+		if group, ok := updated["portgroup_uuid"].(string); ok && s.portGroups[group] == nil {
+			return 0, nil, errorf(http.StatusBadRequest, "Portgroup %s could not be found.", group)
+		}
+		s.ports[parts[0]] = updated
+		return http.StatusOK, updated, nil
+	}
+	return 0, nil, errorf(http.StatusMethodNotAllowed, "method not allowed")
+}
+
+func (s *Simulator) handlePortGroups(method string, parts []string, query map[string][]string, body []byte) (int, interface{}, *simError) {
+	switch {
+	case method == http.MethodGet && (len(parts) == 0 || (len(parts) == 1 && parts[0] == "detail")):
+		list := []map[string]interface{}{}
+		for _, group := range sortedByUUID(s.portGroups) {
+			if matchesNode(group, query) {
+				list = append(list, group)
+			}
+		}
+		return http.StatusOK, map[string]interface{}{"portgroups": list}, nil
+	case method == http.MethodPost && len(parts) == 0:
+		group := map[string]interface{}{}
+		if err := decode(body, &group); err != nil {
+			return 0, nil, err
+		}
+		if s.findNode(fmt.Sprint(group["node_uuid"])) == nil {
+			return 0, nil, errorf(http.StatusBadRequest, "Node %v could not be found.", group["node_uuid"])
+		}
+		for _, other := range s.portGroups {
+			if other["name"] == group["name"] {
+				return 0, nil, errorf(http.StatusConflict, "A portgroup with name %v already exists.", group["name"])
+			}
+		}
+		group["uuid"] = s.newUUID()
+		s.portGroups[group["uuid"].(string)] = group
+		return http.StatusCreated, group, nil
+	case len(parts) == 1:
+		group, found := s.portGroups[parts[0]]
+		if !found {
+			return 0, nil, errorf(http.StatusNotFound, "Portgroup %s could not be found.", parts[0])
+		}
+		switch method {
+		case http.MethodGet:
+			return http.StatusOK, group, nil
+		case http.MethodPatch:
+			if err := patchObject(group, body); err != nil {
+				return 0, nil, err
+			}
+			return http.StatusOK, group, nil
+		case http.MethodDelete:
+			for _, port := range s.ports {
+				if port["portgroup_uuid"] == parts[0] {
+					return 0, nil, errorf(http.StatusConflict, "Portgroup %s has ports", parts[0])
+				}
+			}
+			delete(s.portGroups, parts[0])
+			return http.StatusNoContent, nil, nil
+		}
+	}
+	return 0, nil, errorf(http.StatusMethodNotAllowed, "method not allowed")
+}
+
+func nilIfEmpty(value string) interface{} {
+	if value == "" {
+		return nil
+	}
+	return value
+}
+
+func (s *Simulator) handleInspector(method string, parts []string, query map[string][]string, body []byte) (int, interface{}, *simError) {
+	if len(parts) == 0 || parts[0] != "v1" || method != http.MethodGet {
+		return 0, nil, errorf(http.StatusNotFound, "not found")
+	}
+	if len(parts) == 1 {
+		return http.StatusOK, map[string]interface{}{"id": "v1"}, nil
+	}
+	if parts[1] != "introspection" || len(parts) < 3 || len(parts) > 4 {
+		return 0, nil, errorf(http.StatusNotFound, "not found")
+	}
+
+	uuid := parts[2]
+	intro, ok := s.introspections[uuid]
+	if !ok {
+		return 0, nil, errorf(http.StatusNotFound, "Introspection for node %s not found", uuid)
+	}
+	if len(parts) == 3 {
+		status := map[string]interface{}{
+			"uuid":       intro.UUID,
+			"state":      intro.State,
+			"finished":   intro.Finished,
+			"error":      nil,
+			"started_at": intro.StartedAt.Format(time.RFC3339),
+		}
+		if intro.Error != "" {
+			status["error"] = intro.Error
+		}
+		if intro.Finished {
+			status["finished_at"] = intro.FinishedAt.Format(time.RFC3339)
+		}
+		return http.StatusOK, status, nil
+	}
+	if parts[3] != "data" {
+		return 0, nil, errorf(http.StatusNotFound, "not found")
+	}
+	data, ok := s.data[uuid]
+	if !ok {
+		return 0, nil, errorf(http.StatusNotFound, "Introspection data for node %s not found", uuid)
+	}
+	return http.StatusOK, data, nil
+}
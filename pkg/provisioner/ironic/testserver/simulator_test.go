@@ -0,0 +1,215 @@
+package testserver
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/gophercloud/gophercloud"
+	"github.com/gophercloud/gophercloud/openstack/baremetal/v1/nodes"
+	"github.com/gophercloud/gophercloud/openstack/baremetal/v1/ports"
+	"github.com/gophercloud/gophercloud/openstack/baremetalintrospection/v1/introspection"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/shweta50/baremetal-operator/pkg/provisioner/ironic/clients"
+)
+
+func newTestSimulator(t *testing.T) (*Simulator, *gophercloud.ServiceClient, *gophercloud.ServiceClient) {
+	sim := NewSimulator(t).Start()
+	t.Cleanup(sim.Stop)
+
+	noauth := clients.AuthConfig{Type: clients.NoAuth}
+	ironic, err := clients.IronicClient(sim.Endpoint(), noauth, clients.TLSConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	inspector, err := clients.InspectorClient(sim.InspectorEndpoint(), noauth, clients.TLSConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return sim, ironic, inspector
+}
+
+func createTestNode(t *testing.T, client *gophercloud.ServiceClient) *nodes.Node {
+	node, err := nodes.Create(client, nodes.CreateOpts{Name: "myns~myhost", Driver: "ipmi"}).Extract()
+	if err != nil {
+		t.Fatal(err)
+	}
+	pxe := true
+	_, err = ports.Create(client, ports.CreateOpts{
+		NodeUUID: node.UUID, Address: "52:54:00:00:00:01", PXEEnabled: &pxe,
+	}).Extract()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return node
+}
+
+// changeState requests a provision state change and ticks the
+// simulator until the node settles, returning the states it went
+// through.
+func changeState(t *testing.T, sim *Simulator, client *gophercloud.ServiceClient, uuid string, opts nodes.ProvisionStateOpts) []string {
+	if err := nodes.ChangeProvisionState(client, uuid, opts).ExtractErr(); err != nil {
+		t.Fatalf("could not change state to %s: %s", opts.Target, err)
+	}
+	var states []string
+	for i := 0; i < 10; i++ {
+		node, _ := sim.Node(uuid)
+		states = append(states, node.ProvisionState)
+		if node.TargetProvisionState == "" {
+			return states
+		}
+		sim.Tick()
+	}
+	t.Fatalf("node did not settle: %v", states)
+	return nil
+}
+
+func TestSimulatorLifecycle(t *testing.T) {
+	sim, client, inspector := newTestSimulator(t)
+	node := createTestNode(t, client)
+	assert.Equal(t, string(nodes.Enroll), node.ProvisionState)
+
+	assert.Equal(t, []string{"verifying", "manageable"},
+		changeState(t, sim, client, node.UUID, nodes.ProvisionStateOpts{Target: nodes.TargetManage}))
+
+	_, err := introspection.GetIntrospectionStatus(inspector, node.UUID).Extract()
+	assert.IsType(t, gophercloud.ErrDefault404{}, err)
+	assert.Equal(t, []string{"inspecting", "inspect wait", "manageable"},
+		changeState(t, sim, client, node.UUID, nodes.ProvisionStateOpts{Target: nodes.TargetInspect}))
+	status, err := introspection.GetIntrospectionStatus(inspector, node.UUID).Extract()
+	assert.NoError(t, err)
+	assert.True(t, status.Finished)
+	data, err := introspection.GetIntrospectionData(inspector, node.UUID).Extract()
+	assert.NoError(t, err)
+	assert.Equal(t, "52:54:00:00:00:01", data.Inventory.Interfaces[0].MACAddress)
+
+	assert.Equal(t, []string{"cleaning", "clean wait", "available"},
+		changeState(t, sim, client, node.UUID, nodes.ProvisionStateOpts{Target: nodes.TargetProvide}))
+	assert.Equal(t, []string{"deploying", "wait call-back", "active"},
+		changeState(t, sim, client, node.UUID, nodes.ProvisionStateOpts{
+			Target:      nodes.TargetActive,
+			ConfigDrive: nodes.ConfigDrive{UserData: "#cloud-config"},
+		}))
+	current, _ := sim.Node(node.UUID)
+	assert.Equal(t, "power on", current.PowerState)
+	assert.NotNil(t, current.InstanceInfo["configdrive"])
+
+	assert.Equal(t, []string{"deleting", "cleaning", "clean wait", "available"},
+		changeState(t, sim, client, node.UUID, nodes.ProvisionStateOpts{Target: nodes.TargetDeleted}))
+	current, _ = sim.Node(node.UUID)
+	assert.Equal(t, "power off", current.PowerState)
+}
+
+func TestSimulatorInvalidTransitions(t *testing.T) {
+	sim, client, _ := newTestSimulator(t)
+	node := createTestNode(t, client)
+
+	err := nodes.ChangeProvisionState(client, node.UUID, nodes.ProvisionStateOpts{Target: nodes.TargetActive}).ExtractErr()
+	assert.IsType(t, gophercloud.ErrDefault400{}, err)
+
+	err = nodes.ChangeProvisionState(client, node.UUID, nodes.ProvisionStateOpts{Target: nodes.TargetManage}).ExtractErr()
+	assert.NoError(t, err)
+	err = nodes.ChangeProvisionState(client, node.UUID, nodes.ProvisionStateOpts{Target: nodes.TargetManage}).ExtractErr()
+	assert.IsType(t, gophercloud.ErrDefault409{}, err, "a node in transition is busy")
+
+	sim.Tick()
+	_, err = ports.Create(client, ports.CreateOpts{NodeUUID: node.UUID, Address: "52:54:00:00:00:01"}).Extract()
+	assert.IsType(t, gophercloud.ErrDefault409{}, err, "MAC addresses are unique")
+	_, err = nodes.Create(client, nodes.CreateOpts{Name: "myns~myhost"}).Extract()
+	assert.IsType(t, gophercloud.ErrDefault409{}, err, "names are unique")
+}
+
+func TestSimulatorUpdateAndDelete(t *testing.T) {
+	sim, client, _ := newTestSimulator(t)
+	node := createTestNode(t, client)
+
+	updated, err := nodes.Update(client, node.UUID, nodes.UpdateOpts{
+		nodes.UpdateOperation{Op: nodes.AddOp, Path: "/instance_info/image_source", Value: "http://example.test/image"},
+		nodes.UpdateOperation{Op: nodes.AddOp, Path: "/automated_clean", Value: false},
+	}).Extract()
+	assert.NoError(t, err)
+	assert.Equal(t, "http://example.test/image", updated.InstanceInfo["image_source"])
+	assert.False(t, *updated.AutomatedClean)
+
+	_, err = nodes.Update(client, node.UUID, nodes.UpdateOpts{
+		nodes.UpdateOperation{Op: nodes.AddOp, Path: "/provision_state", Value: "active"},
+	}).Extract()
+	assert.IsType(t, gophercloud.ErrDefault400{}, err)
+
+	changeState(t, sim, client, node.UUID, nodes.ProvisionStateOpts{Target: nodes.TargetManage})
+	assert.Equal(t, []string{"available"},
+		changeState(t, sim, client, node.UUID, nodes.ProvisionStateOpts{Target: nodes.TargetProvide}),
+		"cleaning is skipped when automated_clean is false")
+	changeState(t, sim, client, node.UUID, nodes.ProvisionStateOpts{Target: nodes.TargetActive})
+
+	err = nodes.Delete(client, node.UUID).ExtractErr()
+	assert.IsType(t, gophercloud.ErrDefault409{}, err, "active nodes can not be deleted")
+	_, err = nodes.Update(client, node.UUID, nodes.UpdateOpts{
+		nodes.UpdateOperation{Op: nodes.AddOp, Path: "/maintenance", Value: true},
+	}).Extract()
+	assert.NoError(t, err)
+	assert.NoError(t, nodes.Delete(client, node.UUID).ExtractErr())
+	assert.Equal(t, 0, sim.Nodes())
+
+	allPorts, err := ports.List(client, ports.ListOpts{Address: "52:54:00:00:00:01"}).AllPages()
+	assert.NoError(t, err)
+	empty, _ := allPorts.IsEmpty()
+	assert.True(t, empty, "ports are deleted with their node")
+}
+
+func TestSimulatorPower(t *testing.T) {
+	sim, client, _ := newTestSimulator(t)
+	node := createTestNode(t, client)
+	changeState(t, sim, client, node.UUID, nodes.ProvisionStateOpts{Target: nodes.TargetManage})
+
+	assert.NoError(t, nodes.ChangePowerState(client, node.UUID, nodes.PowerStateOpts{Target: nodes.PowerOn}).ExtractErr())
+	current, _ := sim.Node(node.UUID)
+	assert.Equal(t, "power off", current.PowerState)
+	assert.Equal(t, "power on", current.TargetPowerState)
+	err := nodes.ChangePowerState(client, node.UUID, nodes.PowerStateOpts{Target: nodes.PowerOff}).ExtractErr()
+	assert.IsType(t, gophercloud.ErrDefault409{}, err)
+
+	sim.Tick()
+	current, _ = sim.Node(node.UUID)
+	assert.Equal(t, "power on", current.PowerState)
+	assert.Empty(t, current.TargetPowerState)
+
+	sim.InjectFailure(Failure{State: "soft power off", Message: "no ACPI"})
+	assert.NoError(t, nodes.ChangePowerState(client, node.UUID, nodes.PowerStateOpts{Target: nodes.SoftPowerOff}).ExtractErr())
+	sim.Tick()
+	current, _ = sim.Node(node.UUID)
+	assert.Equal(t, "power on", current.PowerState)
+	assert.Equal(t, "no ACPI", current.LastError)
+}
+
+func TestSimulatorFailures(t *testing.T) {
+	sim, client, inspector := newTestSimulator(t)
+	node := createTestNode(t, client)
+
+	sim.InjectFailure(Failure{Node: "myns~myhost", State: "verifying", Message: "bad credentials"})
+	assert.Equal(t, []string{"verifying", "enroll"},
+		changeState(t, sim, client, node.UUID, nodes.ProvisionStateOpts{Target: nodes.TargetManage}))
+	current, _ := sim.Node(node.UUID)
+	assert.Equal(t, "bad credentials", current.LastError)
+
+	changeState(t, sim, client, node.UUID, nodes.ProvisionStateOpts{Target: nodes.TargetManage})
+	sim.InjectFailure(Failure{State: "inspect wait"})
+	assert.Equal(t, []string{"inspecting", "inspect wait", "inspect failed"},
+		changeState(t, sim, client, node.UUID, nodes.ProvisionStateOpts{Target: nodes.TargetInspect}))
+	status, err := introspection.GetIntrospectionStatus(inspector, node.UUID).Extract()
+	assert.NoError(t, err)
+	assert.Equal(t, "simulated failure in inspect wait", status.Error)
+
+	sim.InjectFault(Fault{Method: http.MethodGet, PathPrefix: "/v1/nodes", StatusCode: http.StatusServiceUnavailable, Count: 1})
+	_, err = nodes.Get(client, node.UUID).Extract()
+	assert.IsType(t, gophercloud.ErrDefault503{}, err)
+	_, err = nodes.Get(client, node.UUID).Extract()
+	assert.NoError(t, err)
+
+	sim.InjectFault(Fault{PathPrefix: "/v1/introspection", StatusCode: http.StatusInternalServerError})
+	_, err = introspection.GetIntrospectionStatus(inspector, node.UUID).Extract()
+	assert.IsType(t, gophercloud.ErrDefault500{}, err)
+	sim.ClearFaults()
+	_, err = introspection.GetIntrospectionStatus(inspector, node.UUID).Extract()
+	assert.NoError(t, err)
+}
@@ -0,0 +1,81 @@
+package ironic
+
+import (
+	"fmt"
+
+	"github.com/gophercloud/gophercloud"
+	"github.com/gophercloud/gophercloud/openstack/baremetal/v1/nodes"
+	"github.com/pkg/errors"
+
+	metal3v1alpha1 "github.com/shweta50/baremetal-operator/apis/metal3.io/v1alpha1"
+)
+
+// instanceTraits returns the value of instance_info/traits, which
+// Ironic matches against its deploy templates when deploying.
+func instanceTraits(traits []metal3v1alpha1.Trait) interface{} {
+	if len(traits) == 0 {
+		return nil
+	}
+	result := make([]string, 0, len(traits))
+	for _, trait := range traits {
+		result = append(result, string(trait))
+	}
+	return result
+}
+
+// addNodeTraits adds the traits requested for the instance to the
+// node, since Ironic refuses to deploy an instance with traits the node
+// does not have. Traits set on the node by other means are kept.
+func (p *ironicProvisioner) addNodeTraits(ironicNode *nodes.Node, traits []metal3v1alpha1.Trait) error {
+	existing := map[string]bool{}
+	for _, trait := range ironicNode.Traits {
+		existing[trait] = true
+	}
+
+	for _, trait := range traits {
+		if existing[string(trait)] {
+			continue
+		}
+		p.log.Info("adding trait to node", "trait", trait)
+		_, err := p.client.Put(p.client.ServiceURL("nodes", ironicNode.UUID, "traits", string(trait)), nil, nil,
+			&gophercloud.RequestOpts{OkCodes: []int{204}})
+		if err != nil {
+			return errors.Wrap(err, fmt.Sprintf("failed to add trait %s", trait))
+		}
+		ironicNode.Traits = append(ironicNode.Traits, string(trait))
+	}
+	return nil
+}
+
+// removeNodeTraits removes from the node the traits added for a
+// previous instance that are no longer requested, so that the traits of
+// the node follow the host spec.
+func (p *ironicProvisioner) removeNodeTraits(ironicNode *nodes.Node, previous, traits []metal3v1alpha1.Trait) error {
+	requested := map[metal3v1alpha1.Trait]bool{}
+	for _, trait := range traits {
+		requested[trait] = true
+	}
+
+	for _, trait := range previous {
+		if requested[trait] {
+			continue
+		}
+		kept := make([]string, 0, len(ironicNode.Traits))
+		for _, existing := range ironicNode.Traits {
+			if existing != string(trait) {
+				kept = append(kept, existing)
+			}
+		}
+		if len(kept) == len(ironicNode.Traits) {
+			continue
+		}
+		p.log.Info("removing trait from node", "trait", trait)
+		_, err := p.client.Delete(p.client.ServiceURL("nodes", ironicNode.UUID, "traits", string(trait)),
+			&gophercloud.RequestOpts{OkCodes: []int{204}})
+		if err != nil {
+			return errors.Wrap(err, fmt.Sprintf("failed to remove trait %s", trait))
+		}
+		ironicNode.Traits = kept
+	}
+	return nil
+}
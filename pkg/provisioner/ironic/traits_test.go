@@ -0,0 +1,141 @@
+package ironic
+
+import (
+	"testing"
+
+	"github.com/gophercloud/gophercloud/openstack/baremetal/v1/nodes"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	metal3v1alpha1 "github.com/shweta50/baremetal-operator/apis/metal3.io/v1alpha1"
+	"github.com/shweta50/baremetal-operator/pkg/bmc"
+	"github.com/shweta50/baremetal-operator/pkg/provisioner"
+	"github.com/shweta50/baremetal-operator/pkg/provisioner/fixture"
+	"github.com/shweta50/baremetal-operator/pkg/provisioner/ironic/clients"
+	"github.com/shweta50/baremetal-operator/pkg/provisioner/ironic/testserver"
+)
+
+func TestGetDeploySteps(t *testing.T) {
+	priority := 150
+	data := provisioner.ProvisionData{
+		CustomDeploy: &metal3v1alpha1.CustomDeploy{Method: "install_coreos"},
+		DeploySteps: []metal3v1alpha1.DeployStep{
+			{
+				Interface: metal3v1alpha1.DeployStepInterfaceBIOS,
+				Step:      "apply_configuration",
+				Args:      &runtime.RawExtension{Raw: []byte(`{"settings": [{"name": "LogicalProc", "value": "Disabled"}]}`)},
+				Priority:  &priority,
+			},
+			{Interface: metal3v1alpha1.DeployStepInterfaceManagement, Step: "update_firmware"},
+		},
+	}
+
+	prov := &ironicProvisioner{}
+	steps, err := prov.getDeploySteps(data)
+	assert.NoError(t, err)
+	assert.Equal(t, []nodes.DeployStep{
+		{Interface: nodes.InterfaceDeploy, Step: "install_coreos", Args: map[string]interface{}{}, Priority: 80},
+		{
+			Interface: nodes.InterfaceBIOS,
+			Step:      "apply_configuration",
+			Args: map[string]interface{}{
+				"settings": []interface{}{map[string]interface{}{"name": "LogicalProc", "value": "Disabled"}},
+			},
+			Priority: 150,
+		},
+		{Interface: nodes.InterfaceManagement, Step: "update_firmware", Args: map[string]interface{}{}, Priority: 80},
+	}, steps)
+
+	data.DeploySteps[1].Args = &runtime.RawExtension{Raw: []byte(`"v2.1"`)}
+	_, err = prov.getDeploySteps(data)
+	assert.Error(t, err)
+}
+
+func TestProvisionWithTraits(t *testing.T) {
+	sim := testserver.NewSimulator(t).Start()
+	defer sim.Stop()
+
+	host := makeHost()
+	auth := clients.AuthConfig{Type: clients.NoAuth}
+	prov, err := newProvisionerWithSettings(host, bmc.Credentials{}, nullEventPublisher,
+		sim.Endpoint(), auth, sim.InspectorEndpoint(), auth)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	automatedClean := false
+	node, err := nodes.Create(prov.client, nodes.CreateOpts{
+		Name:           "myns~myhost",
+		Driver:         "ipmi",
+		AutomatedClean: &automatedClean,
+	}).Extract()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, target := range []nodes.TargetProvisionState{nodes.TargetManage, nodes.TargetProvide} {
+		if err = nodes.ChangeProvisionState(prov.client, node.UUID, nodes.ProvisionStateOpts{Target: target}).ExtractErr(); err != nil {
+			t.Fatal(err)
+		}
+		sim.Tick()
+	}
+	// Set outside of the host spec, must be kept
+	if err = prov.addNodeTraits(node, []metal3v1alpha1.Trait{"CUSTOM_RACK_A"}); err != nil {
+		t.Fatal(err)
+	}
+
+	host.Status.Provisioning.ID = node.UUID
+	prov, err = newProvisionerWithSettings(host, bmc.Credentials{}, nullEventPublisher,
+		sim.Endpoint(), auth, sim.InspectorEndpoint(), auth)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := prov.Provision(provisioner.ProvisionData{
+		Image:       *host.Spec.Image,
+		HostConfig:  fixture.NewHostConfigData("", "", ""),
+		DeploySteps: []metal3v1alpha1.DeployStep{{Interface: metal3v1alpha1.DeployStepInterfaceRAID, Step: "apply_configuration"}},
+		Traits:      []metal3v1alpha1.Trait{"CUSTOM_HYPERTHREADING_OFF", "CUSTOM_RACK_A"},
+	})
+	assert.NoError(t, err)
+	assert.Empty(t, result.ErrorMessage)
+
+	deployed, _ := sim.Node(node.UUID)
+	assert.Equal(t, string(nodes.Deploying), deployed.ProvisionState)
+	assert.ElementsMatch(t, []string{"CUSTOM_RACK_A", "CUSTOM_HYPERTHREADING_OFF"}, deployed.Traits)
+	assert.Equal(t, []interface{}{"CUSTOM_HYPERTHREADING_OFF", "CUSTOM_RACK_A"}, deployed.InstanceInfo["traits"])
+	assert.Equal(t, []interface{}{
+		map[string]interface{}{"interface": "raid", "step": "apply_configuration", "args": map[string]interface{}{}, "priority": float64(80)},
+	}, deployed.DriverInternalInfo["user_deploy_steps"])
+}
+
+func TestRemoveNodeTraits(t *testing.T) {
+	sim := testserver.NewSimulator(t).Start()
+	defer sim.Stop()
+
+	host := makeHost()
+	auth := clients.AuthConfig{Type: clients.NoAuth}
+	prov, err := newProvisionerWithSettings(host, bmc.Credentials{}, nullEventPublisher,
+		sim.Endpoint(), auth, sim.InspectorEndpoint(), auth)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	node, err := nodes.Create(prov.client, nodes.CreateOpts{Name: "myns~myhost", Driver: "ipmi"}).Extract()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = prov.addNodeTraits(node, []metal3v1alpha1.Trait{"CUSTOM_RACK_A", "CUSTOM_OLD", "CUSTOM_KEPT"}); err != nil {
+		t.Fatal(err)
+	}
+
+	// CUSTOM_RACK_A was not added for an instance, CUSTOM_GONE is no
+	// longer on the node
+	err = prov.removeNodeTraits(node,
+		[]metal3v1alpha1.Trait{"CUSTOM_OLD", "CUSTOM_KEPT", "CUSTOM_GONE"},
+		[]metal3v1alpha1.Trait{"CUSTOM_KEPT", "CUSTOM_NEW"})
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []string{"CUSTOM_RACK_A", "CUSTOM_KEPT"}, node.Traits)
+
+	updated, _ := sim.Node(node.UUID)
+	assert.ElementsMatch(t, []string{"CUSTOM_RACK_A", "CUSTOM_KEPT"}, updated.Traits)
+}
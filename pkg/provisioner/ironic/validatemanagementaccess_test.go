@@ -261,6 +261,78 @@ func TestValidateManagementAccessExistingNodeNameUpdate(t *testing.T) {
 	assert.Equal(t, false, result.Dirty)
 }
 
+func TestValidateManagementAccessCreateWithConductorGroup(t *testing.T) {
+	host := makeHost()
+	host.Spec.BootMACAddress = ""
+	host.Spec.ConductorGroup = "Site-A"
+	host.Status.Provisioning.ID = "" // so we don't lookup by uuid
+
+	var createdNode *nodes.Node
+
+	createCallback := func(node nodes.Node) {
+		createdNode = &node
+	}
+
+	ironic := testserver.NewIronic(t).Ready().CreateNodes(createCallback).NoNode(host.Namespace + nameSeparator + host.Name).NoNode(host.Name)
+	ironic.AddDefaultResponse("/v1/nodes/node-0", "PATCH", http.StatusOK, "{}")
+	ironic.Start()
+	defer ironic.Stop()
+
+	auth := clients.AuthConfig{Type: clients.NoAuth}
+	prov, err := newProvisionerWithSettings(host, bmc.Credentials{}, nullEventPublisher,
+		ironic.Endpoint(), auth, testserver.NewInspector(t).Endpoint(), auth,
+	)
+	if err != nil {
+		t.Fatalf("could not create provisioner: %s", err)
+	}
+
+	result, _, err := prov.ValidateManagementAccess(provisioner.ManagementAccessData{}, false, false)
+	if err != nil {
+		t.Fatalf("error from ValidateManagementAccess: %s", err)
+	}
+	assert.Equal(t, "", result.ErrorMessage)
+	assert.Equal(t, "site-a", createdNode.ConductorGroup)
+}
+
+func TestValidateManagementAccessExistingNodeConductorGroupUpdate(t *testing.T) {
+	host := makeHost()
+	host.Spec.BootMACAddress = ""
+	host.Spec.ConductorGroup = "site-b"
+	host.Status.Provisioning.ID = "uuid"
+
+	ironic := testserver.NewIronic(t).
+		Node(
+			nodes.Node{
+				Name:           host.Namespace + nameSeparator + host.Name,
+				UUID:           "uuid",
+				ConductorGroup: "site-a",
+			}).
+		NodeUpdate(
+			nodes.Node{
+				Name:           host.Namespace + nameSeparator + host.Name,
+				UUID:           "uuid",
+				ConductorGroup: "site-b",
+			})
+	ironic.Start()
+	defer ironic.Stop()
+
+	auth := clients.AuthConfig{Type: clients.NoAuth}
+	prov, err := newProvisionerWithSettings(host, bmc.Credentials{}, nullEventPublisher,
+		ironic.Endpoint(), auth, testserver.NewInspector(t).Endpoint(), auth,
+	)
+	if err != nil {
+		t.Fatalf("could not create provisioner: %s", err)
+	}
+
+	result, _, err := prov.ValidateManagementAccess(provisioner.ManagementAccessData{}, false, false)
+	if err != nil {
+		t.Fatalf("error from ValidateManagementAccess: %s", err)
+	}
+	assert.Equal(t, "", result.ErrorMessage)
+	updates, _ := ironic.GetLastRequestFor("/v1/nodes/uuid", http.MethodPatch)
+	assert.Contains(t, updates, `{"op":"add","path":"/conductor_group","value":"site-b"}`)
+}
+
 func TestValidateManagementAccessExistingNodeContinue(t *testing.T) {
 	statuses := []nodes.ProvisionState{
 		nodes.Manageable,
@@ -0,0 +1,70 @@
+package provisioner
+
+import (
+	metal3v1alpha1 "github.com/shweta50/baremetal-operator/apis/metal3.io/v1alpha1"
+)
+
+// NetworkPhase tells a NetworkSwitcher which networks the host needs.
+type NetworkPhase string
+
+const (
+	// NetworkPhaseProvisioning is before an image is deployed, when the
+	// host must be on the provisioning network.
+	NetworkPhaseProvisioning NetworkPhase = "provisioning"
+	// NetworkPhaseProvisioned is after an image is deployed, when the
+	// host can be moved to its tenant networks.
+	NetworkPhaseProvisioned NetworkPhase = "provisioned"
+	// NetworkPhaseDeprovisioning is before the host is cleaned, when it
+	// must be back on the provisioning network.
+	NetworkPhaseDeprovisioning NetworkPhase = "deprovisioning"
+)
+
+// NetworkSwitchData holds what a NetworkSwitcher knows about the host.
+type NetworkSwitchData struct {
+	Namespace     string
+	Name          string
+	ProvisionerID string
+	Phase         NetworkPhase
+
+	BootMACAddress string
+	// NICs are the NICs found by inspection, with the switch ports
+	// and VLANs reported by LLDP.
+	NICs []metal3v1alpha1.NIC
+	// Networking holds the NICs and bonds set by the user, if any.
+	Networking *metal3v1alpha1.NetworkingConfig
+}
+
+// BuildNetworkSwitchData returns the data of a host for a
+// NetworkSwitcher.
+func BuildNetworkSwitchData(host metal3v1alpha1.BareMetalHost, phase NetworkPhase) NetworkSwitchData {
+	data := NetworkSwitchData{
+		Namespace:      host.Namespace,
+		Name:           host.Name,
+		ProvisionerID:  host.Status.Provisioning.ID,
+		Phase:          phase,
+		BootMACAddress: host.Spec.BootMACAddress,
+		Networking:     host.Spec.Networking.DeepCopy(),
+	}
+	if host.Status.HardwareDetails != nil {
+		data.NICs = host.Status.HardwareDetails.DeepCopy().NIC
+	}
+	return data
+}
+
+// NetworkSwitcher moves a host between networks, for instance by
+// changing the VLANs of the switch ports it is cabled to, around the
+// provisioning and deprovisioning of the host.
+type NetworkSwitcher interface {
+	// SwitchNetwork puts the host on the networks needed for the
+	// phase. A Dirty result means the change is still in progress and
+	// SwitchNetwork will be called again.
+	SwitchNetwork(data NetworkSwitchData) (result Result, err error)
+}
+
+// NullNetworkSwitcher leaves the host on a flat network.
+type NullNetworkSwitcher struct{}
+
+// SwitchNetwork does nothing.
+func (NullNetworkSwitcher) SwitchNetwork(data NetworkSwitchData) (Result, error) {
+	return Result{}, nil
+}
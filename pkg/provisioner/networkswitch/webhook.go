@@ -0,0 +1,138 @@
+/*
+Package networkswitch holds the NetworkSwitcher implementations.
+*/
+package networkswitch
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	metal3v1alpha1 "github.com/shweta50/baremetal-operator/apis/metal3.io/v1alpha1"
+	"github.com/shweta50/baremetal-operator/pkg/provisioner"
+)
+
+const (
+	webhookHTTPTimeout = time.Second * 30
+
+	// defaultRetryDelay is how long to wait before calling a webhook
+	// again when it accepted a request without a Retry-After header.
+	defaultRetryDelay = time.Second * 10
+)
+
+// NewFromEnv returns the NetworkSwitcher configured with the
+// NETWORK_SWITCH_WEBHOOK_URL environment variable, or one leaving the
+// hosts on a flat network if it is not set.
+func NewFromEnv() (provisioner.NetworkSwitcher, error) {
+	url := os.Getenv("NETWORK_SWITCH_WEBHOOK_URL")
+	if url == "" {
+		return provisioner.NullNetworkSwitcher{}, nil
+	}
+	webhook := &Webhook{URL: url}
+	if tokenFile := os.Getenv("NETWORK_SWITCH_WEBHOOK_TOKEN_FILE"); tokenFile != "" {
+		token, err := ioutil.ReadFile(filepath.Clean(tokenFile))
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to read the network switch webhook token")
+		}
+		webhook.Token = strings.TrimSpace(string(token))
+	}
+	return webhook, nil
+}
+
+// Webhook is a NetworkSwitcher posting each change to an HTTP
+// endpoint, which drives the switches.
+//
+// The endpoint answers 200 or 204 once the host is on the networks of
+// the phase, or 202 while it is being moved, in which case it is
+// called again after the delay given by the Retry-After header. Other
+// 4xx answers fail the operation with the body of the response as
+// error message, while 5xx answers are retried.
+type Webhook struct {
+	URL string
+	// Token is sent as a bearer token, if not empty.
+	Token      string
+	HTTPClient *http.Client
+}
+
+// webhookHost identifies the host in a webhook request.
+type webhookHost struct {
+	Namespace     string `json:"namespace"`
+	Name          string `json:"name"`
+	ProvisionerID string `json:"provisionerID,omitempty"`
+}
+
+// webhookRequest is the body posted to the webhook.
+type webhookRequest struct {
+	Host           webhookHost                      `json:"host"`
+	Phase          provisioner.NetworkPhase         `json:"phase"`
+	BootMACAddress string                           `json:"bootMACAddress,omitempty"`
+	NICs           []metal3v1alpha1.NIC             `json:"nics"`
+	Networking     *metal3v1alpha1.NetworkingConfig `json:"networking,omitempty"`
+}
+
+func (w *Webhook) httpClient() *http.Client {
+	if w.HTTPClient != nil {
+		return w.HTTPClient
+	}
+	return &http.Client{Timeout: webhookHTTPTimeout}
+}
+
+// SwitchNetwork posts the host and phase to the webhook.
+func (w *Webhook) SwitchNetwork(data provisioner.NetworkSwitchData) (result provisioner.Result, err error) {
+	body, err := json.Marshal(webhookRequest{
+		Host: webhookHost{
+			Namespace:     data.Namespace,
+			Name:          data.Name,
+			ProvisionerID: data.ProvisionerID,
+		},
+		Phase:          data.Phase,
+		BootMACAddress: data.BootMACAddress,
+		NICs:           data.NICs,
+		Networking:     data.Networking,
+	})
+	if err != nil {
+		return
+	}
+
+	req, err := http.NewRequest(http.MethodPost, w.URL, bytes.NewReader(body))
+	if err != nil {
+		return
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if w.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+w.Token)
+	}
+
+	resp, err := w.httpClient().Do(req)
+	if err != nil {
+		err = errors.Wrap(err, "failed to call the network switch webhook")
+		return
+	}
+	defer resp.Body.Close()
+	message, _ := ioutil.ReadAll(resp.Body)
+
+	switch {
+	case resp.StatusCode == http.StatusOK || resp.StatusCode == http.StatusNoContent:
+	case resp.StatusCode == http.StatusAccepted:
+		result.Dirty = true
+		result.RequeueAfter = defaultRetryDelay
+		if seconds, convErr := strconv.Atoi(resp.Header.Get("Retry-After")); convErr == nil && seconds > 0 {
+			result.RequeueAfter = time.Duration(seconds) * time.Second
+		}
+	case resp.StatusCode >= 400 && resp.StatusCode < 500:
+		result.ErrorMessage = fmt.Sprintf("network switch webhook refused the %s networks: %s",
+			data.Phase, strings.TrimSpace(string(message)))
+	default:
+		err = fmt.Errorf("network switch webhook returned %d: %s", resp.StatusCode, strings.TrimSpace(string(message)))
+	}
+	return
+}
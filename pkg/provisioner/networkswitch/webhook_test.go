@@ -0,0 +1,122 @@
+package networkswitch
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	metal3v1alpha1 "github.com/shweta50/baremetal-operator/apis/metal3.io/v1alpha1"
+	"github.com/shweta50/baremetal-operator/pkg/provisioner"
+)
+
+func TestWebhook(t *testing.T) {
+	testCases := []struct {
+		Scenario       string
+		StatusCode     int
+		RetryAfter     string
+		ExpectedResult provisioner.Result
+		ExpectedError  bool
+	}{
+		{
+			Scenario:   "switched",
+			StatusCode: http.StatusOK,
+		},
+		{
+			Scenario:   "no content",
+			StatusCode: http.StatusNoContent,
+		},
+		{
+			Scenario:       "in progress",
+			StatusCode:     http.StatusAccepted,
+			ExpectedResult: provisioner.Result{Dirty: true, RequeueAfter: defaultRetryDelay},
+		},
+		{
+			Scenario:       "in progress with delay",
+			StatusCode:     http.StatusAccepted,
+			RetryAfter:     "30",
+			ExpectedResult: provisioner.Result{Dirty: true, RequeueAfter: 30 * time.Second},
+		},
+		{
+			Scenario:   "refused",
+			StatusCode: http.StatusConflict,
+			ExpectedResult: provisioner.Result{
+				ErrorMessage: "network switch webhook refused the provisioned networks: no such VLAN",
+			},
+		},
+		{
+			Scenario:      "server error",
+			StatusCode:    http.StatusBadGateway,
+			ExpectedError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Scenario, func(t *testing.T) {
+			var received webhookRequest
+			var auth string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				auth = r.Header.Get("Authorization")
+				if err := json.NewDecoder(r.Body).Decode(&received); err != nil {
+					t.Error(err)
+				}
+				if tc.RetryAfter != "" {
+					w.Header().Set("Retry-After", tc.RetryAfter)
+				}
+				w.WriteHeader(tc.StatusCode)
+				if tc.StatusCode != http.StatusNoContent {
+					w.Write([]byte("no such VLAN\n"))
+				}
+			}))
+			defer server.Close()
+
+			webhook := &Webhook{URL: server.URL, Token: "secret"}
+			result, err := webhook.SwitchNetwork(provisioner.NetworkSwitchData{
+				Namespace:      "myns",
+				Name:           "myhost",
+				Phase:          provisioner.NetworkPhaseProvisioned,
+				BootMACAddress: "00:00:00:00:00:01",
+				NICs: []metal3v1alpha1.NIC{
+					{
+						Name:   "eth0",
+						MAC:    "00:00:00:00:00:01",
+						VLANID: 10,
+						LLDP:   &metal3v1alpha1.LLDP{SwitchID: "00:1c:73:aa:bb:cc", PortID: "Ethernet12"},
+					},
+				},
+			})
+
+			if tc.ExpectedError {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tc.ExpectedResult, result)
+			assert.Equal(t, "Bearer secret", auth)
+			assert.Equal(t, webhookHost{Namespace: "myns", Name: "myhost"}, received.Host)
+			assert.Equal(t, provisioner.NetworkPhaseProvisioned, received.Phase)
+			if assert.Len(t, received.NICs, 1) {
+				assert.Equal(t, metal3v1alpha1.VLANID(10), received.NICs[0].VLANID)
+				assert.Equal(t, &metal3v1alpha1.LLDP{SwitchID: "00:1c:73:aa:bb:cc", PortID: "Ethernet12"}, received.NICs[0].LLDP)
+			}
+		})
+	}
+}
+
+func TestNewFromEnv(t *testing.T) {
+	os.Unsetenv("NETWORK_SWITCH_WEBHOOK_TOKEN_FILE")
+	os.Unsetenv("NETWORK_SWITCH_WEBHOOK_URL")
+	switcher, err := NewFromEnv()
+	assert.NoError(t, err)
+	assert.Equal(t, provisioner.NullNetworkSwitcher{}, switcher)
+
+	os.Setenv("NETWORK_SWITCH_WEBHOOK_URL", "http://switches.example.test/hook")
+	defer os.Unsetenv("NETWORK_SWITCH_WEBHOOK_URL")
+	switcher, err = NewFromEnv()
+	assert.NoError(t, err)
+	assert.Equal(t, &Webhook{URL: "http://switches.example.test/hook"}, switcher)
+}
@@ -26,6 +26,7 @@ type HostData struct {
 	DisableCertificateVerification bool
 	BootMACAddress                 string
 	ProvisionerID                  string
+	ConductorGroup                 string
 }
 
 func BuildHostData(host metal3v1alpha1.BareMetalHost, bmcCreds bmc.Credentials) HostData {
@@ -36,6 +37,7 @@ func BuildHostData(host metal3v1alpha1.BareMetalHost, bmcCreds bmc.Credentials)
 		DisableCertificateVerification: host.Spec.BMC.DisableCertificateVerification,
 		BootMACAddress:                 host.Spec.BootMACAddress,
 		ProvisionerID:                  host.Status.Provisioning.ID,
+		ConductorGroup:                 host.Spec.ConductorGroup,
 	}
 }
 
@@ -44,6 +46,34 @@ type Factory interface {
 	NewProvisioner(hostData HostData, publish EventPublisher) (Provisioner, error)
 }
 
+// InspectionRuleData holds the information needed to apply an
+// inspection rule.
+type InspectionRuleData struct {
+	ObjectMeta metav1.ObjectMeta
+	ID         string
+	Spec       metal3v1alpha1.InspectionRuleSpec
+}
+
+func BuildInspectionRuleData(rule metal3v1alpha1.InspectionRule) InspectionRuleData {
+	return InspectionRuleData{
+		ObjectMeta: *rule.ObjectMeta.DeepCopy(),
+		ID:         string(rule.UID),
+		Spec:       *rule.Spec.DeepCopy(),
+	}
+}
+
+// InspectionRuleManager is implemented by the factories of the
+// provisioners supporting inspection rules.
+type InspectionRuleManager interface {
+	// ApplyInspectionRule creates the rule when the provisioner does
+	// not have it, and replaces it when replace is set.
+	ApplyInspectionRule(rule InspectionRuleData, replace bool) error
+
+	// RemoveInspectionRule deletes the rule, if the provisioner has
+	// it.
+	RemoveInspectionRule(rule InspectionRuleData) error
+}
+
 // HostConfigData retrieves host configuration data
 type HostConfigData interface {
 	// UserData is the interface for a function to retrieve user
@@ -73,12 +103,39 @@ type AdoptData struct {
 
 type InspectData struct {
 	BootMode metal3v1alpha1.BootMode
+	Mode     metal3v1alpha1.InspectionMode
 }
 
 type PrepareData struct {
 	RAIDConfig      *metal3v1alpha1.RAIDConfig
 	RootDeviceHints *metal3v1alpha1.RootDeviceHints
 	FirmwareConfig  *metal3v1alpha1.FirmwareConfig
+	Networking      *metal3v1alpha1.NetworkingConfig
+}
+
+// Operation is a class of operations sharing their own provisioning
+// slots in the backend.
+type Operation string
+
+const (
+	// OperationInspection is the inspection of the hardware
+	OperationInspection Operation = "inspection"
+	// OperationProvisioning is the deployment of an image
+	OperationProvisioning Operation = "provisioning"
+	// OperationCleaning is the cleaning of a host after it is
+	// deprovisioned
+	OperationCleaning Operation = "cleaning"
+	// OperationDeleting is the removal of a host from the provisioner
+	OperationDeleting Operation = "deleting"
+)
+
+// Operations lists every Operation.
+var Operations = []Operation{OperationInspection, OperationProvisioning, OperationCleaning, OperationDeleting}
+
+type CapacityData struct {
+	Operation Operation
+	// Priority orders the hosts waiting for a slot, higher first.
+	Priority int
 }
 
 type ProvisionData struct {
@@ -88,6 +145,11 @@ type ProvisionData struct {
 	HardwareProfile hardware.Profile
 	RootDeviceHints *metal3v1alpha1.RootDeviceHints
 	CustomDeploy    *metal3v1alpha1.CustomDeploy
+	DeploySteps     []metal3v1alpha1.DeployStep
+	Traits          []metal3v1alpha1.Trait
+	// PreviousTraits are the traits requested when the host was last
+	// provisioned, to be removed if they are no longer requested.
+	PreviousTraits []metal3v1alpha1.Trait
 }
 
 // Provisioner holds the state information for talking to the
@@ -155,8 +217,24 @@ type Provisioner interface {
 	// all the incoming requests.
 	IsReady() (result bool, err error)
 
-	// HasCapacity checks if the backend has a free (de)provisioning slot for the current host
-	HasCapacity() (result bool, err error)
+	// HasCapacity checks if the backend has a free slot for the
+	// operation the current host is about to start
+	HasCapacity(data CapacityData) (result bool, err error)
+
+	// LastHostState returns the state of the host in the provisioning
+	// backend as last read by the provisioner, or nil if it was not
+	// read. It does not talk to the backend.
+	LastHostState() *HostState
+}
+
+// HostState is the state of a host in the provisioning backend.
+type HostState struct {
+	State  string
+	Target string
+	// Step is the clean or deploy step running, as
+	// "<interface>.<step>".
+	Step      string
+	LastError string
 }
 
 // Result holds the response from a call in the Provsioner API.
@@ -180,3 +258,13 @@ type HardwareState struct {
 
 // ErrNeedsRegistration raised if the host is not registered
 var ErrNeedsRegistration = errors.New("Host not registered")
+
+// HostConfigError is returned by NewProvisioner when the settings of
+// the host do not allow it to be managed until they are changed.
+type HostConfigError struct {
+	Err error
+}
+
+func (e HostConfigError) Error() string {
+	return e.Err.Error()
+}
@@ -0,0 +1,65 @@
+package redfish
+
+import (
+	"fmt"
+
+	metal3v1alpha1 "github.com/shweta50/baremetal-operator/apis/metal3.io/v1alpha1"
+)
+
+// biosAttributeNames lists, for each firmware setting, the names
+// vendors use for the matching BIOS attribute. The first name present
+// in the Bios resource of the system is used.
+var biosAttributeNames = map[string][]string{
+	"virtualizationEnabled": {
+		"ProcVirtualization", // Dell, HPE
+		"IntelVirtualizationTechnology",
+	},
+	"simultaneousMultithreadingEnabled": {
+		"LogicalProc",        // Dell
+		"ProcHyperthreading", // HPE
+		"HyperThreading",
+	},
+	"sriovEnabled": {
+		"SriovGlobalEnable", // Dell
+		"Sriov",             // HPE
+		"SRIOVEnable",
+	},
+}
+
+func biosValue(enabled bool) string {
+	if enabled {
+		return "Enabled"
+	}
+	return "Disabled"
+}
+
+// buildBIOSAttributes returns the BIOS attributes to change so the
+// system matches the firmware config, given its current attributes.
+func buildBIOSAttributes(config *metal3v1alpha1.FirmwareConfig, current map[string]interface{}) (map[string]interface{}, error) {
+	settings := map[string]*bool{
+		"virtualizationEnabled":             config.VirtualizationEnabled,
+		"simultaneousMultithreadingEnabled": config.SimultaneousMultithreadingEnabled,
+		"sriovEnabled":                      config.SriovEnabled,
+	}
+
+	changes := map[string]interface{}{}
+	for setting, enabled := range settings {
+		if enabled == nil {
+			continue
+		}
+		name := ""
+		for _, candidate := range biosAttributeNames[setting] {
+			if _, ok := current[candidate]; ok {
+				name = candidate
+				break
+			}
+		}
+		if name == "" {
+			return nil, fmt.Errorf("firmware setting %s is not supported by the BMC", setting)
+		}
+		if value := biosValue(*enabled); current[name] != value {
+			changes[name] = value
+		}
+	}
+	return changes, nil
+}
@@ -0,0 +1,236 @@
+package redfish
+
+import (
+	"bytes"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/shweta50/baremetal-operator/pkg/bmc"
+)
+
+const (
+	redfishSystemsPath = "/redfish/v1/Systems"
+	httpTimeout        = time.Second * 30
+)
+
+// HTTPError is returned when the BMC answers a request with an error
+// status.
+type HTTPError struct {
+	Method     string
+	Path       string
+	StatusCode int
+}
+
+func (e HTTPError) Error() string {
+	return fmt.Sprintf("%s %s returned %d %s", e.Method, e.Path,
+		e.StatusCode, http.StatusText(e.StatusCode))
+}
+
+// isUnauthorized returns true when the BMC rejected the credentials.
+func isUnauthorized(err error) bool {
+	httpErr, ok := errors.Cause(err).(*HTTPError)
+	return ok && (httpErr.StatusCode == http.StatusUnauthorized ||
+		httpErr.StatusCode == http.StatusForbidden)
+}
+
+// client talks to the Redfish service of a single BMC.
+type client struct {
+	endpoint string
+	creds    bmc.Credentials
+	http     *http.Client
+}
+
+func newClient(endpoint string, creds bmc.Credentials, disableCertificateVerification bool) *client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if disableCertificateVerification {
+		transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: true} // #nosec
+	}
+	return &client{
+		endpoint: endpoint,
+		creds:    creds,
+		http:     &http.Client{Transport: transport, Timeout: httpTimeout},
+	}
+}
+
+func (c *client) do(method, path string, body interface{}, headers map[string]string) (*http.Response, error) {
+	var content []byte
+	if body != nil {
+		var err error
+		if content, err = json.Marshal(body); err != nil {
+			return nil, err
+		}
+	}
+
+	req, err := http.NewRequest(method, c.endpoint+path, bytes.NewReader(content))
+	if err != nil {
+		return nil, err
+	}
+	req.SetBasicAuth(c.creds.Username, c.creds.Password)
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, errors.Wrapf(err, "%s %s failed", method, path)
+	}
+	if resp.StatusCode >= http.StatusBadRequest {
+		resp.Body.Close()
+		return nil, &HTTPError{Method: method, Path: path, StatusCode: resp.StatusCode}
+	}
+	return resp, nil
+}
+
+// get decodes the resource at path into out and returns its ETag.
+func (c *client) get(path string, out interface{}) (etag string, err error) {
+	resp, err := c.do(http.MethodGet, path, nil, nil)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if err = json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return "", errors.Wrapf(err, "failed to decode %s", path)
+	}
+	return resp.Header.Get("ETag"), nil
+}
+
+func (c *client) patch(path string, body interface{}, etag string) error {
+	headers := map[string]string{}
+	if etag != "" {
+		headers["If-Match"] = etag
+	}
+	resp, err := c.do(http.MethodPatch, path, body, headers)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+func (c *client) post(path string, body interface{}) error {
+	resp, err := c.do(http.MethodPost, path, body, nil)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// The resources below only hold the subset of the Redfish schema the
+// provisioner uses.
+
+type odataID struct {
+	ID string `json:"@odata.id"`
+}
+
+type collection struct {
+	Members []odataID `json:"Members"`
+}
+
+type actionTarget struct {
+	Target          string   `json:"target"`
+	AllowableValues []string `json:"ResetType@Redfish.AllowableValues"`
+}
+
+type computerSystem struct {
+	ID               string `json:"Id"`
+	Manufacturer     string `json:"Manufacturer"`
+	Model            string `json:"Model"`
+	SerialNumber     string `json:"SerialNumber"`
+	BiosVersion      string `json:"BiosVersion"`
+	HostName         string `json:"HostName"`
+	PowerState       string `json:"PowerState"`
+	ProcessorSummary struct {
+		Count                 int    `json:"Count"`
+		LogicalProcessorCount int    `json:"LogicalProcessorCount"`
+		Model                 string `json:"Model"`
+	} `json:"ProcessorSummary"`
+	MemorySummary struct {
+		TotalSystemMemoryGiB float64 `json:"TotalSystemMemoryGiB"`
+	} `json:"MemorySummary"`
+	Processors         odataID `json:"Processors"`
+	Storage            odataID `json:"Storage"`
+	EthernetInterfaces odataID `json:"EthernetInterfaces"`
+	Bios               odataID `json:"Bios"`
+	Links              struct {
+		ManagedBy []odataID `json:"ManagedBy"`
+	} `json:"Links"`
+	Actions struct {
+		Reset actionTarget `json:"#ComputerSystem.Reset"`
+	} `json:"Actions"`
+	Boot struct {
+		BootSourceOverrideTarget  string `json:"BootSourceOverrideTarget"`
+		BootSourceOverrideEnabled string `json:"BootSourceOverrideEnabled"`
+	} `json:"Boot"`
+}
+
+type processor struct {
+	Model                 string  `json:"Model"`
+	ProcessorType         string  `json:"ProcessorType"`
+	ProcessorArchitecture string  `json:"ProcessorArchitecture"`
+	InstructionSet        string  `json:"InstructionSet"`
+	MaxSpeedMHz           float64 `json:"MaxSpeedMHz"`
+	TotalThreads          int     `json:"TotalThreads"`
+}
+
+type storage struct {
+	Drives []odataID `json:"Drives"`
+}
+
+type drive struct {
+	Name          string `json:"Name"`
+	Model         string `json:"Model"`
+	Manufacturer  string `json:"Manufacturer"`
+	SerialNumber  string `json:"SerialNumber"`
+	MediaType     string `json:"MediaType"`
+	Protocol      string `json:"Protocol"`
+	CapacityBytes int64  `json:"CapacityBytes"`
+	Identifiers   []struct {
+		DurableName       string `json:"DurableName"`
+		DurableNameFormat string `json:"DurableNameFormat"`
+	} `json:"Identifiers"`
+}
+
+type ipAddress struct {
+	Address string `json:"Address"`
+}
+
+type ethernetInterface struct {
+	ID            string      `json:"Id"`
+	Name          string      `json:"Name"`
+	MACAddress    string      `json:"MACAddress"`
+	SpeedMbps     int         `json:"SpeedMbps"`
+	IPv4Addresses []ipAddress `json:"IPv4Addresses"`
+	IPv6Addresses []ipAddress `json:"IPv6Addresses"`
+}
+
+type bios struct {
+	Attributes map[string]interface{} `json:"Attributes"`
+	Settings   struct {
+		SettingsObject odataID `json:"SettingsObject"`
+	} `json:"@Redfish.Settings"`
+}
+
+type manager struct {
+	VirtualMedia odataID `json:"VirtualMedia"`
+}
+
+type virtualMedia struct {
+	ID         string   `json:"Id"`
+	MediaTypes []string `json:"MediaTypes"`
+	Image      string   `json:"Image"`
+	Inserted   bool     `json:"Inserted"`
+	Actions    struct {
+		InsertMedia actionTarget `json:"#VirtualMedia.InsertMedia"`
+		EjectMedia  actionTarget `json:"#VirtualMedia.EjectMedia"`
+	} `json:"Actions"`
+}
@@ -0,0 +1,13 @@
+package redfish
+
+import "fmt"
+
+// UnsupportedBMCError is returned when the BMC address of a host does
+// not point to a Redfish service.
+type UnsupportedBMCError struct {
+	bmcType string
+}
+
+func (e UnsupportedBMCError) Error() string {
+	return fmt.Sprintf("BMC type %s is not supported by the redfish provisioner", e.bmcType)
+}
@@ -0,0 +1,198 @@
+package redfish
+
+import (
+	"strings"
+
+	metal3v1alpha1 "github.com/shweta50/baremetal-operator/apis/metal3.io/v1alpha1"
+)
+
+// getMembers returns the members of the collection at path, or none
+// when the path is empty because the BMC does not expose it.
+func (c *client) getMembers(path string) ([]odataID, error) {
+	if path == "" {
+		return nil, nil
+	}
+	members := collection{}
+	if _, err := c.get(path, &members); err != nil {
+		return nil, err
+	}
+	return members.Members, nil
+}
+
+func cpuArch(proc processor) string {
+	switch strings.ToLower(proc.InstructionSet) {
+	case "x86-64":
+		return "x86_64"
+	case "arm-a64":
+		return "aarch64"
+	case "power":
+		return "ppc64le"
+	}
+	switch strings.ToLower(proc.ProcessorArchitecture) {
+	case "x86":
+		return "x86_64"
+	case "arm":
+		return "aarch64"
+	case "power":
+		return "ppc64le"
+	}
+	return proc.ProcessorArchitecture
+}
+
+func (c *client) getCPU(system *computerSystem) (cpu metal3v1alpha1.CPU, err error) {
+	cpu.Model = system.ProcessorSummary.Model
+	cpu.Count = system.ProcessorSummary.LogicalProcessorCount
+
+	members, err := c.getMembers(system.Processors.ID)
+	if err != nil {
+		return cpu, err
+	}
+	threads := 0
+	for _, member := range members {
+		proc := processor{}
+		if _, err = c.get(member.ID, &proc); err != nil {
+			return cpu, err
+		}
+		if proc.ProcessorType != "" && proc.ProcessorType != "CPU" {
+			continue
+		}
+		threads += proc.TotalThreads
+		if cpu.Arch == "" {
+			cpu.Arch = cpuArch(proc)
+			cpu.ClockMegahertz = metal3v1alpha1.ClockSpeed(proc.MaxSpeedMHz)
+			if cpu.Model == "" {
+				cpu.Model = proc.Model
+			}
+		}
+	}
+
+	// Like the inspector, count logical processors rather than
+	// sockets when the BMC reports them.
+	if cpu.Count == 0 {
+		cpu.Count = threads
+	}
+	if cpu.Count == 0 {
+		cpu.Count = system.ProcessorSummary.Count
+	}
+	return cpu, nil
+}
+
+func diskType(d drive) metal3v1alpha1.DiskType {
+	switch {
+	case strings.EqualFold(d.Protocol, "NVMe"):
+		return metal3v1alpha1.NVME
+	case d.MediaType == "HDD":
+		return metal3v1alpha1.HDD
+	default:
+		return metal3v1alpha1.SSD
+	}
+}
+
+func (c *client) getStorage(system *computerSystem) ([]metal3v1alpha1.Storage, error) {
+	controllers, err := c.getMembers(system.Storage.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	disks := []metal3v1alpha1.Storage{}
+	for _, controller := range controllers {
+		s := storage{}
+		if _, err = c.get(controller.ID, &s); err != nil {
+			return nil, err
+		}
+		for _, member := range s.Drives {
+			d := drive{}
+			if _, err = c.get(member.ID, &d); err != nil {
+				return nil, err
+			}
+			disk := metal3v1alpha1.Storage{
+				Name:         d.Name,
+				Type:         diskType(d),
+				Rotational:   diskType(d) == metal3v1alpha1.HDD,
+				SizeBytes:    metal3v1alpha1.Capacity(d.CapacityBytes),
+				Vendor:       d.Manufacturer,
+				Model:        d.Model,
+				SerialNumber: d.SerialNumber,
+			}
+			for _, id := range d.Identifiers {
+				if id.DurableNameFormat == "NAA" || id.DurableNameFormat == "EUI" {
+					disk.WWN = id.DurableName
+					break
+				}
+			}
+			disks = append(disks, disk)
+		}
+	}
+	return disks, nil
+}
+
+func (c *client) getNICs(system *computerSystem, bootMACAddress string) ([]metal3v1alpha1.NIC, error) {
+	members, err := c.getMembers(system.EthernetInterfaces.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	nics := []metal3v1alpha1.NIC{}
+	for _, member := range members {
+		iface := ethernetInterface{}
+		if _, err = c.get(member.ID, &iface); err != nil {
+			return nil, err
+		}
+		nic := metal3v1alpha1.NIC{
+			Name:      iface.ID,
+			MAC:       strings.ToLower(iface.MACAddress),
+			SpeedGbps: iface.SpeedMbps / 1000,
+			PXE:       bootMACAddress != "" && strings.EqualFold(iface.MACAddress, bootMACAddress),
+		}
+		if nic.Name == "" {
+			nic.Name = iface.Name
+		}
+
+		// As with the inspector, report one NIC per address.
+		addresses := []string{}
+		for _, addr := range append(iface.IPv4Addresses, iface.IPv6Addresses...) {
+			if addr.Address != "" {
+				addresses = append(addresses, addr.Address)
+			}
+		}
+		if len(addresses) == 0 {
+			nics = append(nics, nic)
+		}
+		for _, addr := range addresses {
+			nic.IP = addr
+			nics = append(nics, nic)
+		}
+	}
+	return nics, nil
+}
+
+// getHardwareDetails builds the HardwareDetails of the system from
+// its Processors, Storage and EthernetInterfaces resources.
+func (c *client) getHardwareDetails(system *computerSystem, bootMACAddress string) (*metal3v1alpha1.HardwareDetails, error) {
+	details := &metal3v1alpha1.HardwareDetails{
+		SystemVendor: metal3v1alpha1.HardwareSystemVendor{
+			Manufacturer: system.Manufacturer,
+			ProductName:  system.Model,
+			SerialNumber: system.SerialNumber,
+		},
+		Firmware: metal3v1alpha1.Firmware{
+			BIOS: metal3v1alpha1.BIOS{
+				Version: system.BiosVersion,
+			},
+		},
+		RAMMebibytes: int(system.MemorySummary.TotalSystemMemoryGiB * 1024),
+		Hostname:     system.HostName,
+	}
+
+	var err error
+	if details.CPU, err = c.getCPU(system); err != nil {
+		return nil, err
+	}
+	if details.Storage, err = c.getStorage(system); err != nil {
+		return nil, err
+	}
+	if details.NIC, err = c.getNICs(system, bootMACAddress); err != nil {
+		return nil, err
+	}
+	return details, nil
+}
@@ -0,0 +1,543 @@
+package redfish
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	logz "sigs.k8s.io/controller-runtime/pkg/log/zap"
+
+	metal3v1alpha1 "github.com/shweta50/baremetal-operator/apis/metal3.io/v1alpha1"
+	"github.com/shweta50/baremetal-operator/pkg/bmc"
+	"github.com/shweta50/baremetal-operator/pkg/provisioner"
+)
+
+/*
+Package redfish implements a provisioner that talks to the Redfish
+service of the BMC directly, without Ironic. It is meant for small
+sites where running Ironic is too heavy, and only supports booting
+live ISO images through virtual media.
+*/
+
+var (
+	powerRequeueDelay     = time.Second * 10
+	provisionRequeueDelay = time.Second * 10
+)
+
+const (
+	powerOn          = "On"
+	powerOff         = "Off"
+	powerPoweringOn  = "PoweringOn"
+	powerPoweringOff = "PoweringOff"
+)
+
+type redfishProvisionerFactory struct {
+	log logr.Logger
+}
+
+// NewProvisionerFactory returns a Factory for provisioners talking
+// Redfish to the BMC of each host.
+func NewProvisionerFactory() provisioner.Factory {
+	return &redfishProvisionerFactory{
+		log: logz.New().WithName("provisioner").WithName("redfish"),
+	}
+}
+
+// NewProvisioner returns a new Redfish Provisioner for the host.
+func (f *redfishProvisionerFactory) NewProvisioner(hostData provisioner.HostData, publisher provisioner.EventPublisher) (provisioner.Provisioner, error) {
+	return &redfishProvisioner{
+		objectMeta:              hostData.ObjectMeta,
+		systemPath:              hostData.ProvisionerID,
+		bmcAddress:              hostData.BMCAddress,
+		bmcCreds:                hostData.BMCCredentials,
+		disableCertVerification: hostData.DisableCertificateVerification,
+		bootMACAddress:          hostData.BootMACAddress,
+		log:                     f.log.WithValues("host", hostData.ObjectMeta.Namespace+"~"+hostData.ObjectMeta.Name),
+		publisher:               publisher,
+	}, nil
+}
+
+// redfishProvisioner implements the provisioner.Provisioner interface
+// on top of the Redfish API of the BMC. The ID of the host in the
+// provisioner is the path of its ComputerSystem resource.
+type redfishProvisioner struct {
+	objectMeta              metav1.ObjectMeta
+	systemPath              string
+	bmcAddress              string
+	bmcCreds                bmc.Credentials
+	disableCertVerification bool
+	bootMACAddress          string
+	log                     logr.Logger
+	publisher               provisioner.EventPublisher
+}
+
+func operationContinuing(delay time.Duration) (provisioner.Result, error) {
+	return provisioner.Result{
+		Dirty:        true,
+		RequeueAfter: delay,
+	}, nil
+}
+
+func operationComplete() (provisioner.Result, error) {
+	return provisioner.Result{}, nil
+}
+
+func operationFailed(message string) (provisioner.Result, error) {
+	return provisioner.Result{ErrorMessage: message}, nil
+}
+
+func transientError(err error) (provisioner.Result, error) {
+	return provisioner.Result{}, err
+}
+
+// client returns a client for the Redfish service of the BMC along
+// with the system ID from the BMC address, if any.
+func (p *redfishProvisioner) client() (*client, string, error) {
+	accessDetails, err := bmc.NewAccessDetails(p.bmcAddress, p.disableCertVerification)
+	if err != nil {
+		return nil, "", err
+	}
+	driverInfo := accessDetails.DriverInfo(p.bmcCreds)
+	endpoint, ok := driverInfo["redfish_address"].(string)
+	if !ok {
+		return nil, "", &UnsupportedBMCError{bmcType: accessDetails.Type()}
+	}
+	systemID, _ := driverInfo["redfish_system_id"].(string)
+	return newClient(endpoint, p.bmcCreds, p.disableCertVerification), systemID, nil
+}
+
+// findSystem returns the path of the ComputerSystem managed through
+// the BMC. Without a system ID in the BMC address, the BMC must only
+// manage one system.
+func findSystem(c *client, systemID string) (string, error) {
+	if systemID != "" && systemID != "/" {
+		return "/" + strings.Trim(systemID, "/"), nil
+	}
+	members, err := c.getMembers(redfishSystemsPath)
+	if err != nil {
+		return "", err
+	}
+	if len(members) != 1 {
+		return "", fmt.Errorf("BMC manages %d systems, the BMC address must include the system path", len(members))
+	}
+	return members[0].ID, nil
+}
+
+// system returns a client and the ComputerSystem of the host.
+func (p *redfishProvisioner) system() (*client, *computerSystem, error) {
+	c, systemID, err := p.client()
+	if err != nil {
+		return nil, nil, err
+	}
+	if p.systemPath == "" {
+		if p.systemPath, err = findSystem(c, systemID); err != nil {
+			return nil, nil, err
+		}
+	}
+	system := &computerSystem{}
+	if _, err = c.get(p.systemPath, system); err != nil {
+		return nil, nil, err
+	}
+	return c, system, nil
+}
+
+// ValidateManagementAccess tests the connection information for the
+// host to verify that the location and credentials work.
+func (p *redfishProvisioner) ValidateManagementAccess(data provisioner.ManagementAccessData, credentialsChanged, force bool) (result provisioner.Result, provID string, err error) {
+	p.log.Info("validating management access")
+
+	c, systemID, err := p.client()
+	if err != nil {
+		result, err = operationFailed(err.Error())
+		return
+	}
+
+	// Look the system up again when the credentials change, the
+	// previous ones may not have been allowed to see it.
+	if p.systemPath == "" || credentialsChanged {
+		p.systemPath, err = findSystem(c, systemID)
+	}
+	if err == nil {
+		_, err = c.get(p.systemPath, &computerSystem{})
+	}
+	if err != nil {
+		if isUnauthorized(err) {
+			result, err = operationFailed(fmt.Sprintf("BMC rejected the credentials: %s", err))
+			return
+		}
+		result, err = transientError(errors.Wrap(err, "failed to reach the BMC"))
+		return
+	}
+
+	return provisioner.Result{}, p.systemPath, nil
+}
+
+// InspectHardware reads the inventory of the host from the
+// ComputerSystem resource and its Processors, Storage and
+// EthernetInterfaces collections. Unlike with Ironic the host is not
+// booted, so inspection completes in a single call.
+func (p *redfishProvisioner) InspectHardware(data provisioner.InspectData, force, refresh bool) (result provisioner.Result, started bool, details *metal3v1alpha1.HardwareDetails, err error) {
+	p.log.Info("inspecting hardware")
+
+	c, system, err := p.system()
+	if err != nil {
+		result, err = transientError(errors.Wrap(err, "failed to read system"))
+		return
+	}
+
+	details, err = c.getHardwareDetails(system, p.bootMACAddress)
+	if err != nil {
+		result, err = transientError(errors.Wrap(err, "failed to read hardware inventory"))
+		return
+	}
+
+	p.publisher("InspectionComplete", "Hardware inspection completed")
+	result, err = operationComplete()
+	return
+}
+
+// UpdateHardwareState fetches the power state of the system.
+func (p *redfishProvisioner) UpdateHardwareState() (hwState provisioner.HardwareState, err error) {
+	_, system, err := p.system()
+	if err != nil {
+		return
+	}
+
+	switch system.PowerState {
+	case powerOn, powerOff:
+		poweredOn := system.PowerState == powerOn
+		hwState.PoweredOn = &poweredOn
+	default:
+		p.log.Info("could not determine power state", "value", system.PowerState)
+	}
+	return
+}
+
+// Adopt has nothing to do, as no state is kept outside the BMC.
+func (p *redfishProvisioner) Adopt(data provisioner.AdoptData, force bool) (result provisioner.Result, err error) {
+	return operationComplete()
+}
+
+// Prepare applies the firmware settings to the pending BIOS settings
+// of the system. They take effect the next time the host boots, which
+// happens when it is provisioned. RAID configuration is not supported.
+func (p *redfishProvisioner) Prepare(data provisioner.PrepareData, unprepared bool) (result provisioner.Result, started bool, err error) {
+	if !unprepared {
+		result, err = operationComplete()
+		return
+	}
+
+	if data.RAIDConfig != nil &&
+		(len(data.RAIDConfig.HardwareRAIDVolumes) != 0 || len(data.RAIDConfig.SoftwareRAIDVolumes) != 0) {
+		result, err = operationFailed("RAID configuration is not supported by the redfish provisioner")
+		return
+	}
+
+	started = true
+	if data.FirmwareConfig == nil {
+		result, err = operationComplete()
+		return
+	}
+
+	c, system, err := p.system()
+	if err != nil {
+		result, err = transientError(errors.Wrap(err, "failed to read system"))
+		return
+	}
+	if system.Bios.ID == "" {
+		result, err = operationFailed("the BMC does not expose BIOS settings")
+		return
+	}
+
+	current := bios{}
+	if _, err = c.get(system.Bios.ID, &current); err != nil {
+		result, err = transientError(errors.Wrap(err, "failed to read BIOS settings"))
+		return
+	}
+	changes, err := buildBIOSAttributes(data.FirmwareConfig, current.Attributes)
+	if err != nil {
+		result, err = operationFailed(err.Error())
+		return
+	}
+	if len(changes) == 0 {
+		result, err = operationComplete()
+		return
+	}
+
+	settingsPath := current.Settings.SettingsObject.ID
+	if settingsPath == "" {
+		settingsPath = strings.TrimRight(system.Bios.ID, "/") + "/Settings"
+	}
+	if err = c.patch(settingsPath, map[string]interface{}{"Attributes": changes}, ""); err != nil {
+		result, err = transientError(errors.Wrap(err, "failed to update BIOS settings"))
+		return
+	}
+	p.log.Info("updated BIOS settings", "attributes", changes)
+	p.publisher("FirmwareSettingsUpdated", "BIOS settings will be applied on the next boot")
+	result, err = operationComplete()
+	return
+}
+
+// findCDMedia returns the path of the virtual CD drive of the BMC
+// managing the system.
+func findCDMedia(c *client, system *computerSystem) (string, *virtualMedia, error) {
+	for _, mgr := range system.Links.ManagedBy {
+		m := manager{}
+		if _, err := c.get(mgr.ID, &m); err != nil {
+			return "", nil, err
+		}
+		members, err := c.getMembers(m.VirtualMedia.ID)
+		if err != nil {
+			return "", nil, err
+		}
+		for _, member := range members {
+			media := &virtualMedia{}
+			if _, err = c.get(member.ID, media); err != nil {
+				return "", nil, err
+			}
+			for _, mediaType := range media.MediaTypes {
+				if mediaType == "CD" || mediaType == "DVD" {
+					return member.ID, media, nil
+				}
+			}
+		}
+	}
+	return "", nil, errors.New("the BMC has no virtual CD drive")
+}
+
+func actionPath(target actionTarget, resource, action string) string {
+	if target.Target != "" {
+		return target.Target
+	}
+	return strings.TrimRight(resource, "/") + "/Actions/" + action
+}
+
+func ejectMedia(c *client, path string, media *virtualMedia) error {
+	return c.post(actionPath(media.Actions.EjectMedia, path, "VirtualMedia.EjectMedia"),
+		map[string]interface{}{})
+}
+
+func (p *redfishProvisioner) reset(c *client, system *computerSystem, resetType string) error {
+	return c.post(actionPath(system.Actions.Reset, p.systemPath, "ComputerSystem.Reset"),
+		map[string]string{"ResetType": resetType})
+}
+
+// bootsFromCD returns true when the system boots from the virtual CD
+// drive until told otherwise.
+func bootsFromCD(system *computerSystem) bool {
+	return system.Boot.BootSourceOverrideTarget == "Cd" &&
+		system.Boot.BootSourceOverrideEnabled == "Continuous"
+}
+
+// Provision boots the live ISO image from the virtual CD drive of the
+// BMC. It returns true for its dirty flag until the image is inserted
+// and the host has been powered on with it.
+//
+// The host is powered off before the media and boot device are changed
+// and only powered on once both are set, so that a host found powered
+// on with the image inserted and booting from CD is known to have
+// booted the image, even if a previous call failed half way through.
+func (p *redfishProvisioner) Provision(data provisioner.ProvisionData) (result provisioner.Result, err error) {
+	p.log.Info("provisioning image to host")
+
+	if data.CustomDeploy != nil || !data.Image.IsLiveISO() {
+		return operationFailed("the redfish provisioner can only boot live-iso images")
+	}
+
+	c, system, err := p.system()
+	if err != nil {
+		return transientError(errors.Wrap(err, "failed to read system"))
+	}
+	mediaPath, media, err := findCDMedia(c, system)
+	if err != nil {
+		return operationFailed(err.Error())
+	}
+
+	configured := media.Inserted && media.Image == data.Image.URL && bootsFromCD(system)
+	switch {
+	case configured && system.PowerState == powerOn:
+		p.log.Info("live ISO booted")
+		p.publisher("ProvisioningComplete", "Image provisioning completed")
+		return operationComplete()
+	case system.PowerState == powerPoweringOn || system.PowerState == powerPoweringOff:
+		p.log.Info("waiting for power status to change")
+		return operationContinuing(powerRequeueDelay)
+	case system.PowerState != powerOff:
+		p.log.Info("powering off host before changing its boot device")
+		if err = p.reset(c, system, "ForceOff"); err != nil {
+			return transientError(errors.Wrap(err, "failed to power off host"))
+		}
+		return operationContinuing(powerRequeueDelay)
+	}
+
+	if !configured {
+		if media.Inserted && media.Image != data.Image.URL {
+			p.log.Info("ejecting previous image", "image", media.Image)
+			if err = ejectMedia(c, mediaPath, media); err != nil {
+				return transientError(errors.Wrap(err, "failed to eject virtual media"))
+			}
+			media.Inserted = false
+		}
+
+		if !media.Inserted {
+			err = c.post(actionPath(media.Actions.InsertMedia, mediaPath, "VirtualMedia.InsertMedia"),
+				map[string]interface{}{
+					"Image":          data.Image.URL,
+					"Inserted":       true,
+					"WriteProtected": true,
+				})
+			if err != nil {
+				return transientError(errors.Wrap(err, "failed to insert virtual media"))
+			}
+		}
+
+		boot := map[string]interface{}{
+			"BootSourceOverrideTarget":  "Cd",
+			"BootSourceOverrideEnabled": "Continuous",
+		}
+		if data.BootMode == metal3v1alpha1.Legacy {
+			boot["BootSourceOverrideMode"] = "Legacy"
+		} else {
+			boot["BootSourceOverrideMode"] = "UEFI"
+		}
+		if err = c.patch(p.systemPath, map[string]interface{}{"Boot": boot}, ""); err != nil {
+			return transientError(errors.Wrap(err, "failed to set boot device"))
+		}
+	}
+
+	if err = p.reset(c, system, powerOn); err != nil {
+		return transientError(errors.Wrap(err, "failed to power on host"))
+	}
+
+	p.publisher("ProvisioningStarted", fmt.Sprintf("Booting live ISO %s", data.Image.URL))
+	return operationContinuing(provisionRequeueDelay)
+}
+
+// Deprovision ejects the live ISO and stops booting from the virtual
+// CD drive. It only completes once both are done, so a failure to
+// reset the boot device after the media was ejected is retried.
+func (p *redfishProvisioner) Deprovision(force bool) (result provisioner.Result, err error) {
+	p.log.Info("deprovisioning host")
+
+	c, system, err := p.system()
+	if err != nil {
+		return transientError(errors.Wrap(err, "failed to read system"))
+	}
+	mediaPath, media, err := findCDMedia(c, system)
+	if err != nil {
+		return operationFailed(err.Error())
+	}
+
+	if !media.Inserted && !bootsFromCD(system) {
+		p.publisher("DeprovisionComplete", "Image deprovisioning completed")
+		return operationComplete()
+	}
+
+	p.publisher("DeprovisionStarted", "Image deprovisioning started")
+	if media.Inserted {
+		if err = ejectMedia(c, mediaPath, media); err != nil {
+			return transientError(errors.Wrap(err, "failed to eject virtual media"))
+		}
+	}
+	if bootsFromCD(system) {
+		boot := map[string]interface{}{"BootSourceOverrideEnabled": "Disabled"}
+		if err = c.patch(p.systemPath, map[string]interface{}{"Boot": boot}, ""); err != nil {
+			return transientError(errors.Wrap(err, "failed to reset boot device"))
+		}
+	}
+	return operationContinuing(provisionRequeueDelay)
+}
+
+// Delete has nothing to remove, as the host is not registered
+// anywhere but in the BMC.
+func (p *redfishProvisioner) Delete() (result provisioner.Result, err error) {
+	p.log.Info("deleting host")
+	return operationComplete()
+}
+
+// Detach has nothing to remove, as the host is not registered
+// anywhere but in the BMC.
+func (p *redfishProvisioner) Detach() (result provisioner.Result, err error) {
+	p.log.Info("detaching host")
+	return operationComplete()
+}
+
+// changePower asks the BMC to reach the target power state and returns
+// true for the dirty flag until it does.
+func (p *redfishProvisioner) changePower(target, transition string, resetTypes ...string) (result provisioner.Result, err error) {
+	c, system, err := p.system()
+	if err != nil {
+		return transientError(errors.Wrap(err, "failed to read system"))
+	}
+
+	switch system.PowerState {
+	case target:
+		return operationComplete()
+	case transition:
+		p.log.Info("waiting for power status to change")
+		return operationContinuing(powerRequeueDelay)
+	}
+
+	// Use the first reset type the BMC supports, or the first one if
+	// it does not say.
+	resetType := resetTypes[0]
+	if allowed := system.Actions.Reset.AllowableValues; len(allowed) != 0 {
+	search:
+		for _, candidate := range resetTypes {
+			for _, value := range allowed {
+				if candidate == value {
+					resetType = candidate
+					break search
+				}
+			}
+		}
+	}
+
+	p.log.Info("changing power state", "resetType", resetType)
+	if err = p.reset(c, system, resetType); err != nil {
+		return transientError(errors.Wrapf(err, "failed to power %s host", strings.ToLower(target)))
+	}
+	p.publisher("Power"+target, fmt.Sprintf("Host powered %s", strings.ToLower(target)))
+	return operationContinuing(powerRequeueDelay)
+}
+
+// PowerOn ensures the server is powered on independently of any image
+// provisioning operation.
+func (p *redfishProvisioner) PowerOn(force bool) (result provisioner.Result, err error) {
+	p.log.Info("ensuring host is powered on")
+
+	return p.changePower(powerOn, powerPoweringOn, powerOn)
+}
+
+// PowerOff ensures the server is powered off independently of any image
+// provisioning operation.
+func (p *redfishProvisioner) PowerOff(rebootMode metal3v1alpha1.RebootMode, force bool) (result provisioner.Result, err error) {
+	p.log.Info(fmt.Sprintf("ensuring host is powered off (mode: %s)", rebootMode))
+
+	resetTypes := []string{"ForceOff"}
+	if rebootMode == metal3v1alpha1.RebootModeSoft && !force {
+		resetTypes = []string{"GracefulShutdown", "ForceOff"}
+	}
+	return p.changePower(powerOff, powerPoweringOff, resetTypes...)
+}
+
+// IsReady always returns true, there is no provisioning service to
+// wait for.
+func (p *redfishProvisioner) IsReady() (result bool, err error) {
+	return true, nil
+}
+
+// HasCapacity always returns true, each host is handled by its own
+// BMC.
+func (p *redfishProvisioner) HasCapacity(data provisioner.CapacityData) (result bool, err error) {
+	return true, nil
+}
+
+// LastHostState returns nil, the BMC does not track provisioning
+// states.
+func (p *redfishProvisioner) LastHostState() *provisioner.HostState {
+	return nil
+}
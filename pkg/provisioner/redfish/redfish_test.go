@@ -0,0 +1,302 @@
+package redfish
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	metal3v1alpha1 "github.com/shweta50/baremetal-operator/apis/metal3.io/v1alpha1"
+	"github.com/shweta50/baremetal-operator/pkg/bmc"
+	"github.com/shweta50/baremetal-operator/pkg/provisioner"
+	"github.com/shweta50/baremetal-operator/pkg/redfishemulator"
+)
+
+func newTestBMC(t *testing.T) (*redfishemulator.Emulator, *httptest.Server) {
+	system := redfishemulator.DefaultSystem("1")
+	system.Manufacturer = "Contoso"
+	system.Model = "Edge 1000"
+	system.SerialNumber = "ABC123"
+	system.BiosVersion = "2.1.0"
+	system.CPU = redfishemulator.CPU{
+		Sockets:        1,
+		ThreadsPerCPU:  8,
+		Model:          "Contoso CPU",
+		MaxSpeedMHz:    3000,
+		InstructionSet: "x86-64",
+	}
+	system.MemoryGiB = 16
+	system.Drives = []redfishemulator.Drive{{
+		ID:            "1",
+		Name:          "Drive 1",
+		Model:         "Contoso NVMe",
+		Manufacturer:  "Contoso",
+		SerialNumber:  "D1",
+		MediaType:     "SSD",
+		Protocol:      "NVMe",
+		CapacityBytes: 960197124096,
+		WWN:           "0x5000c500a0b1c2d3",
+	}}
+	system.NICs = []redfishemulator.NIC{{
+		ID:          "NIC1",
+		MACAddress:  "52:54:00:AA:BB:CC",
+		SpeedMbps:   10000,
+		IPv4Address: "192.168.111.20",
+	}}
+	system.BiosAttributes = map[string]interface{}{
+		"ProcVirtualization": "Disabled",
+		"LogicalProc":        "Enabled",
+	}
+
+	emulator := redfishemulator.New("admin", "password")
+	emulator.AddSystem(system)
+	server := httptest.NewServer(emulator)
+	t.Cleanup(server.Close)
+	return emulator, server
+}
+
+func newTestProvisioner(t *testing.T, bmcServer *httptest.Server, password string) (*redfishProvisioner, *[]string) {
+	events := []string{}
+	hostData := provisioner.HostData{
+		ObjectMeta:     metav1.ObjectMeta{Name: "myhost", Namespace: "myns"},
+		BMCAddress:     "redfish+" + bmcServer.URL,
+		BMCCredentials: bmc.Credentials{Username: "admin", Password: password},
+		BootMACAddress: "52:54:00:aa:bb:cc",
+	}
+	prov, err := NewProvisionerFactory().NewProvisioner(hostData, func(reason, message string) {
+		events = append(events, reason)
+	})
+	if err != nil {
+		t.Fatalf("could not create provisioner: %s", err)
+	}
+	return prov.(*redfishProvisioner), &events
+}
+
+func TestValidateManagementAccess(t *testing.T) {
+	emulator, bmcServer := newTestBMC(t)
+
+	prov, _ := newTestProvisioner(t, bmcServer, "password")
+	result, provID, err := prov.ValidateManagementAccess(provisioner.ManagementAccessData{}, false, false)
+	assert.NoError(t, err)
+	assert.Empty(t, result.ErrorMessage)
+	assert.Equal(t, "/redfish/v1/Systems/1", provID)
+
+	prov, _ = newTestProvisioner(t, bmcServer, "wrong")
+	result, _, err = prov.ValidateManagementAccess(provisioner.ManagementAccessData{}, false, false)
+	assert.NoError(t, err)
+	assert.Contains(t, result.ErrorMessage, "rejected the credentials")
+
+	emulator.InjectFault(redfishemulator.Fault{StatusCode: http.StatusServiceUnavailable, Count: 1})
+	prov, _ = newTestProvisioner(t, bmcServer, "password")
+	_, _, err = prov.ValidateManagementAccess(provisioner.ManagementAccessData{}, false, false)
+	assert.Error(t, err)
+}
+
+func TestValidateManagementAccessUnsupported(t *testing.T) {
+	prov, err := NewProvisionerFactory().NewProvisioner(provisioner.HostData{
+		BMCAddress: "ipmi://192.168.122.1",
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, _, err := prov.ValidateManagementAccess(provisioner.ManagementAccessData{}, false, false)
+	assert.NoError(t, err)
+	assert.Contains(t, result.ErrorMessage, "not supported")
+}
+
+func TestInspectHardware(t *testing.T) {
+	_, bmcServer := newTestBMC(t)
+
+	prov, _ := newTestProvisioner(t, bmcServer, "password")
+	result, _, details, err := prov.InspectHardware(provisioner.InspectData{}, false, false)
+	assert.NoError(t, err)
+	assert.False(t, result.Dirty)
+	if details == nil {
+		t.Fatal("expected hardware details")
+	}
+
+	assert.Equal(t, metal3v1alpha1.HardwareSystemVendor{
+		Manufacturer: "Contoso", ProductName: "Edge 1000", SerialNumber: "ABC123",
+	}, details.SystemVendor)
+	assert.Equal(t, "2.1.0", details.Firmware.BIOS.Version)
+	assert.Equal(t, 16*1024, details.RAMMebibytes)
+	assert.Equal(t, metal3v1alpha1.CPU{
+		Arch: "x86_64", Model: "Contoso CPU", ClockMegahertz: 3000, Count: 8,
+	}, details.CPU)
+	assert.Equal(t, []metal3v1alpha1.Storage{{
+		Name:         "Drive 1",
+		Type:         metal3v1alpha1.NVME,
+		SizeBytes:    960197124096,
+		Vendor:       "Contoso",
+		Model:        "Contoso NVMe",
+		SerialNumber: "D1",
+		WWN:          "0x5000c500a0b1c2d3",
+	}}, details.Storage)
+	assert.Equal(t, []metal3v1alpha1.NIC{{
+		Name:      "NIC1",
+		MAC:       "52:54:00:aa:bb:cc",
+		IP:        "192.168.111.20",
+		SpeedGbps: 10,
+		PXE:       true,
+	}}, details.NIC)
+}
+
+func TestPower(t *testing.T) {
+	emulator, bmcServer := newTestBMC(t)
+
+	prov, events := newTestProvisioner(t, bmcServer, "password")
+
+	result, err := prov.PowerOn(false)
+	assert.NoError(t, err)
+	assert.True(t, result.Dirty)
+	result, err = prov.PowerOn(false)
+	assert.NoError(t, err)
+	assert.False(t, result.Dirty)
+
+	hwState, err := prov.UpdateHardwareState()
+	assert.NoError(t, err)
+	assert.True(t, *hwState.PoweredOn)
+
+	result, err = prov.PowerOff(metal3v1alpha1.RebootModeSoft, false)
+	assert.NoError(t, err)
+	assert.True(t, result.Dirty)
+	result, err = prov.PowerOff(metal3v1alpha1.RebootModeSoft, false)
+	assert.NoError(t, err)
+	assert.False(t, result.Dirty)
+
+	assert.Equal(t, []string{"PowerOn", "PowerOff"}, *events)
+	assert.Len(t, emulator.Requests(), 2)
+}
+
+func TestPrepareFirmware(t *testing.T) {
+	emulator, bmcServer := newTestBMC(t)
+
+	prov, _ := newTestProvisioner(t, bmcServer, "password")
+	enabled := true
+	result, started, err := prov.Prepare(provisioner.PrepareData{
+		FirmwareConfig: &metal3v1alpha1.FirmwareConfig{
+			VirtualizationEnabled:             &enabled,
+			SimultaneousMultithreadingEnabled: &enabled,
+		},
+	}, true)
+	assert.NoError(t, err)
+	assert.True(t, started)
+	assert.Empty(t, result.ErrorMessage)
+	system, _ := emulator.System("1")
+	assert.Equal(t, map[string]interface{}{"ProcVirtualization": "Enabled"}, system.PendingBiosAttributes)
+
+	result, _, err = prov.Prepare(provisioner.PrepareData{
+		FirmwareConfig: &metal3v1alpha1.FirmwareConfig{SriovEnabled: &enabled},
+	}, true)
+	assert.NoError(t, err)
+	assert.Contains(t, result.ErrorMessage, "sriovEnabled")
+}
+
+func TestProvisionLiveISO(t *testing.T) {
+	emulator, bmcServer := newTestBMC(t)
+
+	prov, events := newTestProvisioner(t, bmcServer, "password")
+	liveISO := "live-iso"
+	data := provisioner.ProvisionData{
+		Image: metal3v1alpha1.Image{URL: "http://example.test/live.iso", DiskFormat: &liveISO},
+	}
+
+	result, err := prov.Provision(data)
+	assert.NoError(t, err)
+	assert.True(t, result.Dirty)
+	system, _ := emulator.System("1")
+	assert.True(t, system.VirtualMedia.Inserted)
+	assert.Equal(t, "On", system.PowerState)
+	assert.Equal(t, "Cd", system.Boot.BootSourceOverrideTarget)
+
+	result, err = prov.Provision(data)
+	assert.NoError(t, err)
+	assert.False(t, result.Dirty)
+
+	result, err = prov.Deprovision(false)
+	assert.NoError(t, err)
+	assert.True(t, result.Dirty)
+	system, _ = emulator.System("1")
+	assert.False(t, system.VirtualMedia.Inserted)
+
+	result, err = prov.Deprovision(false)
+	assert.NoError(t, err)
+	assert.False(t, result.Dirty)
+
+	assert.Equal(t, []string{"ProvisioningStarted", "ProvisioningComplete",
+		"DeprovisionStarted", "DeprovisionComplete"}, *events)
+
+	result, err = prov.Provision(provisioner.ProvisionData{
+		Image: metal3v1alpha1.Image{URL: "http://example.test/disk.qcow2"},
+	})
+	assert.NoError(t, err)
+	assert.Contains(t, result.ErrorMessage, "live-iso")
+}
+
+func TestProvisionLiveISOPartialFailure(t *testing.T) {
+	emulator, bmcServer := newTestBMC(t)
+	system, _ := emulator.System("1")
+	system.PowerState = "On"
+	emulator.AddSystem(system)
+
+	prov, _ := newTestProvisioner(t, bmcServer, "password")
+	liveISO := "live-iso"
+	data := provisioner.ProvisionData{
+		Image: metal3v1alpha1.Image{URL: "http://example.test/live.iso", DiskFormat: &liveISO},
+	}
+
+	// The host is powered off before its boot device is changed
+	result, err := prov.Provision(data)
+	assert.NoError(t, err)
+	assert.True(t, result.Dirty)
+	system, _ = emulator.System("1")
+	assert.Equal(t, "Off", system.PowerState)
+	assert.False(t, system.VirtualMedia.Inserted)
+
+	// The media is inserted but the host can not be powered on
+	emulator.InjectFault(redfishemulator.Fault{
+		Method:     http.MethodPost,
+		PathPrefix: "/redfish/v1/Systems/1/Actions/ComputerSystem.Reset",
+		StatusCode: http.StatusInternalServerError,
+		Count:      1,
+	})
+	_, err = prov.Provision(data)
+	assert.Error(t, err)
+	system, _ = emulator.System("1")
+	assert.True(t, system.VirtualMedia.Inserted)
+	assert.Equal(t, "Off", system.PowerState)
+
+	result, err = prov.Provision(data)
+	assert.NoError(t, err)
+	assert.True(t, result.Dirty, "provisioning completed without booting the image")
+	system, _ = emulator.System("1")
+	assert.Equal(t, "On", system.PowerState)
+
+	result, err = prov.Provision(data)
+	assert.NoError(t, err)
+	assert.False(t, result.Dirty)
+
+	// The media is ejected but the boot device can not be reset
+	emulator.InjectFault(redfishemulator.Fault{
+		Method:     http.MethodPatch,
+		PathPrefix: "/redfish/v1/Systems/1",
+		StatusCode: http.StatusInternalServerError,
+		Count:      1,
+	})
+	_, err = prov.Deprovision(false)
+	assert.Error(t, err)
+	system, _ = emulator.System("1")
+	assert.False(t, system.VirtualMedia.Inserted)
+
+	result, err = prov.Deprovision(false)
+	assert.NoError(t, err)
+	assert.True(t, result.Dirty, "deprovisioning completed without resetting the boot device")
+	system, _ = emulator.System("1")
+	assert.Equal(t, "Disabled", system.Boot.BootSourceOverrideEnabled)
+
+	result, err = prov.Deprovision(false)
+	assert.NoError(t, err)
+	assert.False(t, result.Dirty)
+}
@@ -0,0 +1,653 @@
+package redfishemulator
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+/*
+Package redfishemulator serves a small Redfish API from an in-memory
+model of one or more systems. It covers the resources used by the
+operator (Systems, Managers, VirtualMedia, Bios, Storage, power
+actions and the AccountService) and can be told to fail requests on
+demand, so that Redfish code paths can be tested without a BMC.
+*/
+
+const (
+	rootPath     = "/redfish/v1"
+	systemsPath  = rootPath + "/Systems"
+	managersPath = rootPath + "/Managers"
+	accountsPath = rootPath + "/AccountService/Accounts"
+)
+
+// resetTypes lists the supported ComputerSystem.Reset types.
+var resetTypes = []string{
+	"On", "ForceOff", "GracefulShutdown", "ForceRestart", "GracefulRestart", "PushPowerButton",
+}
+
+// Fault makes the emulator answer matching requests with an error.
+type Fault struct {
+	// Method to match, any method when empty.
+	Method string
+	// PathPrefix to match, any path when empty.
+	PathPrefix string
+	// StatusCode returned instead of handling the request.
+	StatusCode int
+	// Count is the number of requests to fail, zero fails all of
+	// them until the faults are cleared.
+	Count int
+}
+
+func (f *Fault) matches(r *http.Request) bool {
+	return (f.Method == "" || f.Method == r.Method) &&
+		strings.HasPrefix(r.URL.Path, f.PathPrefix)
+}
+
+// Request records a request handled by the emulator.
+type Request struct {
+	Method string
+	Path   string
+	Body   map[string]interface{}
+}
+
+// Emulator is an http.Handler serving the Redfish API of its
+// systems. It is safe for concurrent use.
+type Emulator struct {
+	mu sync.Mutex
+
+	username string
+	password string
+	// accountVersion is used as the ETag of the account, so
+	// concurrent password changes can be detected.
+	accountVersion int
+
+	systems  map[string]*System
+	faults   []*Fault
+	requests []Request
+}
+
+// New returns an Emulator accepting the given credentials, with no
+// systems.
+func New(username, password string) *Emulator {
+	return &Emulator{
+		username: username,
+		password: password,
+		systems:  map[string]*System{},
+	}
+}
+
+// AddSystem adds a system to the emulator, replacing any system with
+// the same ID.
+func (e *Emulator) AddSystem(system System) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	s := system.copy()
+	if s.PowerState == "" {
+		s.PowerState = "Off"
+	}
+	e.systems[s.ID] = &s
+}
+
+// System returns a copy of the current state of a system.
+func (e *Emulator) System(id string) (System, bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	s, ok := e.systems[id]
+	if !ok {
+		return System{}, false
+	}
+	return s.copy(), true
+}
+
+// Password returns the current password of the BMC account.
+func (e *Emulator) Password() string {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.password
+}
+
+// InjectFault adds a fault. Faults are checked in the order they were
+// added.
+func (e *Emulator) InjectFault(fault Fault) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	f := fault
+	e.faults = append(e.faults, &f)
+}
+
+// ClearFaults removes all the faults.
+func (e *Emulator) ClearFaults() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.faults = nil
+}
+
+// Requests returns the requests changing the state of the emulator,
+// in the order they were received.
+func (e *Emulator) Requests() []Request {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return append([]Request(nil), e.requests...)
+}
+
+func (e *Emulator) sortedSystemIDs() []string {
+	ids := make([]string, 0, len(e.systems))
+	for id := range e.systems {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+func (e *Emulator) injectedFault(r *http.Request) int {
+	for i, f := range e.faults {
+		if !f.matches(r) {
+			continue
+		}
+		if f.Count > 0 {
+			f.Count--
+			if f.Count == 0 {
+				e.faults = append(e.faults[:i], e.faults[i+1:]...)
+			}
+		}
+		return f.StatusCode
+	}
+	return 0
+}
+
+type resource map[string]interface{}
+
+func ref(path string) resource {
+	return resource{"@odata.id": path}
+}
+
+func collection(path string, members []string) resource {
+	refs := []resource{}
+	for _, member := range members {
+		refs = append(refs, ref(path+"/"+member))
+	}
+	return resource{
+		"@odata.id":           path,
+		"Members":             refs,
+		"Members@odata.count": len(refs),
+	}
+}
+
+// httpError is returned by the handlers to answer with an error status.
+type httpError struct {
+	status  int
+	message string
+}
+
+func (e *httpError) Error() string {
+	return e.message
+}
+
+func notFound(path string) error {
+	return &httpError{http.StatusNotFound, fmt.Sprintf("%s not found", path)}
+}
+
+func badRequest(format string, args ...interface{}) error {
+	return &httpError{http.StatusBadRequest, fmt.Sprintf(format, args...)}
+}
+
+func methodNotAllowed(method, path string) error {
+	return &httpError{http.StatusMethodNotAllowed, fmt.Sprintf("%s not allowed on %s", method, path)}
+}
+
+func (e *Emulator) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	path := strings.TrimRight(r.URL.Path, "/")
+
+	if status := e.injectedFault(r); status != 0 {
+		w.WriteHeader(status)
+		return
+	}
+
+	// As on real BMCs, the service root can be read without
+	// authenticating.
+	if path != rootPath {
+		if user, pass, ok := r.BasicAuth(); !ok || user != e.username || pass != e.password {
+			w.Header().Set("WWW-Authenticate", `Basic realm="redfish"`)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+	}
+
+	body := map[string]interface{}{}
+	if r.Method == http.MethodPatch || r.Method == http.MethodPost {
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			writeError(w, badRequest("invalid request body: %s", err))
+			return
+		}
+		e.requests = append(e.requests, Request{Method: r.Method, Path: path, Body: body})
+	}
+
+	result, err := e.handle(r.Method, path, body, r.Header.Get("If-Match"))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	if result == nil {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	if etag, ok := result["@odata.etag"].(string); ok {
+		w.Header().Set("ETag", etag)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+	if httpErr, ok := err.(*httpError); ok {
+		status = httpErr.status
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(resource{
+		"error": resource{
+			"code":    "Base.1.0.GeneralError",
+			"message": err.Error(),
+		},
+	})
+}
+
+func (e *Emulator) handle(method, path string, body map[string]interface{}, ifMatch string) (resource, error) {
+	switch {
+	case path == rootPath:
+		if method != http.MethodGet {
+			return nil, methodNotAllowed(method, path)
+		}
+		return resource{
+			"@odata.id":      rootPath,
+			"Id":             "RootService",
+			"RedfishVersion": "1.6.0",
+			"Systems":        ref(systemsPath),
+			"Managers":       ref(managersPath),
+			"AccountService": ref(rootPath + "/AccountService"),
+		}, nil
+
+	case path == systemsPath || path == managersPath:
+		if method != http.MethodGet {
+			return nil, methodNotAllowed(method, path)
+		}
+		return collection(path, e.sortedSystemIDs()), nil
+
+	case strings.HasPrefix(path, systemsPath+"/"):
+		parts := strings.Split(strings.TrimPrefix(path, systemsPath+"/"), "/")
+		system, ok := e.systems[parts[0]]
+		if !ok {
+			return nil, notFound(path)
+		}
+		return e.handleSystem(system, method, path, parts[1:], body)
+
+	case strings.HasPrefix(path, managersPath+"/"):
+		parts := strings.Split(strings.TrimPrefix(path, managersPath+"/"), "/")
+		system, ok := e.systems[parts[0]]
+		if !ok {
+			return nil, notFound(path)
+		}
+		return e.handleManager(system, method, path, parts[1:], body)
+
+	case path == rootPath+"/AccountService":
+		return resource{
+			"@odata.id": path,
+			"Accounts":  ref(accountsPath),
+		}, nil
+
+	case path == accountsPath:
+		return collection(accountsPath, []string{"1"}), nil
+
+	case path == accountsPath+"/1":
+		return e.handleAccount(method, path, body, ifMatch)
+	}
+
+	return nil, notFound(path)
+}
+
+func (e *Emulator) handleAccount(method, path string, body map[string]interface{}, ifMatch string) (resource, error) {
+	etag := fmt.Sprintf(`W/"%d"`, e.accountVersion)
+	switch method {
+	case http.MethodGet:
+		return resource{
+			"@odata.id":   path,
+			"@odata.etag": etag,
+			"Id":          "1",
+			"UserName":    e.username,
+			"RoleId":      "Administrator",
+			"Enabled":     true,
+		}, nil
+	case http.MethodPatch:
+		if ifMatch != "" && ifMatch != etag {
+			return nil, &httpError{http.StatusPreconditionFailed, "the account was changed"}
+		}
+		password, ok := body["Password"].(string)
+		if !ok || password == "" {
+			return nil, badRequest("Password is required")
+		}
+		e.password = password
+		e.accountVersion++
+		return nil, nil
+	}
+	return nil, methodNotAllowed(method, path)
+}
+
+func (e *Emulator) handleSystem(s *System, method, path string, parts []string, body map[string]interface{}) (resource, error) {
+	base := systemsPath + "/" + s.ID
+	sub := strings.Join(parts, "/")
+
+	if method == http.MethodPost {
+		if sub != "Actions/ComputerSystem.Reset" {
+			return nil, notFound(path)
+		}
+		resetType, _ := body["ResetType"].(string)
+		return nil, s.reset(resetType)
+	}
+
+	if method == http.MethodPatch {
+		switch sub {
+		case "":
+			return nil, s.patchBoot(body)
+		case "Bios/Settings":
+			attributes, ok := body["Attributes"].(map[string]interface{})
+			if !ok {
+				return nil, badRequest("Attributes are required")
+			}
+			for name, value := range attributes {
+				if _, known := s.BiosAttributes[name]; !known {
+					return nil, badRequest("unknown BIOS attribute %s", name)
+				}
+				if s.PendingBiosAttributes == nil {
+					s.PendingBiosAttributes = map[string]interface{}{}
+				}
+				s.PendingBiosAttributes[name] = value
+			}
+			return nil, nil
+		}
+		return nil, methodNotAllowed(method, path)
+	}
+
+	if method != http.MethodGet {
+		return nil, methodNotAllowed(method, path)
+	}
+
+	switch {
+	case sub == "":
+		return s.resource(base), nil
+
+	case sub == "Processors":
+		ids := []string{}
+		for i := 1; i <= s.CPU.Sockets; i++ {
+			ids = append(ids, fmt.Sprintf("CPU%d", i))
+		}
+		return collection(path, ids), nil
+	case strings.HasPrefix(sub, "Processors/CPU"):
+		socket, err := strconv.Atoi(strings.TrimPrefix(sub, "Processors/CPU"))
+		if err != nil || socket < 1 || socket > s.CPU.Sockets {
+			return nil, notFound(path)
+		}
+		return resource{
+			"@odata.id":             path,
+			"Id":                    fmt.Sprintf("CPU%d", socket),
+			"Socket":                fmt.Sprintf("CPU %d", socket),
+			"ProcessorType":         "CPU",
+			"ProcessorArchitecture": architecture(s.CPU.InstructionSet),
+			"InstructionSet":        s.CPU.InstructionSet,
+			"Model":                 s.CPU.Model,
+			"MaxSpeedMHz":           s.CPU.MaxSpeedMHz,
+			"TotalThreads":          s.CPU.ThreadsPerCPU,
+		}, nil
+
+	case sub == "Storage":
+		return collection(path, []string{"1"}), nil
+	case sub == "Storage/1":
+		drives := []resource{}
+		for _, d := range s.Drives {
+			drives = append(drives, ref(path+"/Drives/"+d.ID))
+		}
+		return resource{"@odata.id": path, "Id": "1", "Drives": drives}, nil
+	case strings.HasPrefix(sub, "Storage/1/Drives/"):
+		id := strings.TrimPrefix(sub, "Storage/1/Drives/")
+		for _, d := range s.Drives {
+			if d.ID == id {
+				return d.resource(path), nil
+			}
+		}
+		return nil, notFound(path)
+
+	case sub == "EthernetInterfaces":
+		ids := []string{}
+		for _, nic := range s.NICs {
+			ids = append(ids, nic.ID)
+		}
+		return collection(path, ids), nil
+	case strings.HasPrefix(sub, "EthernetInterfaces/"):
+		id := strings.TrimPrefix(sub, "EthernetInterfaces/")
+		for _, nic := range s.NICs {
+			if nic.ID == id {
+				return nic.resource(path), nil
+			}
+		}
+		return nil, notFound(path)
+
+	case sub == "Bios":
+		return resource{
+			"@odata.id":  path,
+			"Id":         "Bios",
+			"Attributes": copyAttributes(s.BiosAttributes),
+			"@Redfish.Settings": resource{
+				"SettingsObject": ref(path + "/Settings"),
+			},
+		}, nil
+	case sub == "Bios/Settings":
+		return resource{
+			"@odata.id":  path,
+			"Id":         "Settings",
+			"Attributes": copyAttributes(s.PendingBiosAttributes),
+		}, nil
+	}
+
+	return nil, notFound(path)
+}
+
+func (e *Emulator) handleManager(s *System, method, path string, parts []string, body map[string]interface{}) (resource, error) {
+	base := managersPath + "/" + s.ID
+	mediaPath := base + "/VirtualMedia/Cd"
+	sub := strings.Join(parts, "/")
+
+	switch {
+	case sub == "" && method == http.MethodGet:
+		return resource{
+			"@odata.id":    base,
+			"Id":           s.ID,
+			"ManagerType":  "BMC",
+			"VirtualMedia": ref(base + "/VirtualMedia"),
+			"Links": resource{
+				"ManagerForServers": []resource{ref(systemsPath + "/" + s.ID)},
+			},
+		}, nil
+
+	case sub == "VirtualMedia" && method == http.MethodGet:
+		return collection(path, []string{"Cd"}), nil
+
+	case sub == "VirtualMedia/Cd" && method == http.MethodGet:
+		return resource{
+			"@odata.id":      mediaPath,
+			"Id":             "Cd",
+			"MediaTypes":     []string{"CD", "DVD"},
+			"Image":          s.VirtualMedia.Image,
+			"Inserted":       s.VirtualMedia.Inserted,
+			"WriteProtected": true,
+			"Actions": resource{
+				"#VirtualMedia.InsertMedia": resource{"target": mediaPath + "/Actions/VirtualMedia.InsertMedia"},
+				"#VirtualMedia.EjectMedia":  resource{"target": mediaPath + "/Actions/VirtualMedia.EjectMedia"},
+			},
+		}, nil
+
+	case sub == "VirtualMedia/Cd/Actions/VirtualMedia.InsertMedia" && method == http.MethodPost:
+		image, _ := body["Image"].(string)
+		if image == "" {
+			return nil, badRequest("Image is required")
+		}
+		if s.VirtualMedia.Inserted {
+			return nil, &httpError{http.StatusConflict, "media is already inserted"}
+		}
+		s.VirtualMedia = VirtualMedia{Image: image, Inserted: true}
+		return nil, nil
+
+	case sub == "VirtualMedia/Cd/Actions/VirtualMedia.EjectMedia" && method == http.MethodPost:
+		s.VirtualMedia = VirtualMedia{}
+		return nil, nil
+	}
+
+	return nil, notFound(path)
+}
+
+func architecture(instructionSet string) string {
+	switch instructionSet {
+	case "ARM-A64":
+		return "ARM"
+	case "PowerISA":
+		return "Power"
+	}
+	return "x86"
+}
+
+func (s *System) resource(path string) resource {
+	return resource{
+		"@odata.id":    path,
+		"Id":           s.ID,
+		"Name":         s.Name,
+		"Manufacturer": s.Manufacturer,
+		"Model":        s.Model,
+		"SerialNumber": s.SerialNumber,
+		"HostName":     s.HostName,
+		"BiosVersion":  s.BiosVersion,
+		"PowerState":   s.PowerState,
+		"ProcessorSummary": resource{
+			"Count":                 s.CPU.Sockets,
+			"LogicalProcessorCount": s.CPU.Sockets * s.CPU.ThreadsPerCPU,
+			"Model":                 s.CPU.Model,
+		},
+		"MemorySummary": resource{
+			"TotalSystemMemoryGiB": s.MemoryGiB,
+		},
+		"Boot": resource{
+			"BootSourceOverrideTarget":  s.Boot.BootSourceOverrideTarget,
+			"BootSourceOverrideEnabled": s.Boot.BootSourceOverrideEnabled,
+			"BootSourceOverrideMode":    s.Boot.BootSourceOverrideMode,
+		},
+		"Processors":         ref(path + "/Processors"),
+		"Storage":            ref(path + "/Storage"),
+		"EthernetInterfaces": ref(path + "/EthernetInterfaces"),
+		"Bios":               ref(path + "/Bios"),
+		"Links": resource{
+			"ManagedBy": []resource{ref(managersPath + "/" + s.ID)},
+		},
+		"Actions": resource{
+			"#ComputerSystem.Reset": resource{
+				"target":                            path + "/Actions/ComputerSystem.Reset",
+				"ResetType@Redfish.AllowableValues": resetTypes,
+			},
+		},
+	}
+}
+
+func (d *Drive) resource(path string) resource {
+	r := resource{
+		"@odata.id":     path,
+		"Id":            d.ID,
+		"Name":          d.Name,
+		"Model":         d.Model,
+		"Manufacturer":  d.Manufacturer,
+		"SerialNumber":  d.SerialNumber,
+		"MediaType":     d.MediaType,
+		"Protocol":      d.Protocol,
+		"CapacityBytes": d.CapacityBytes,
+	}
+	if d.WWN != "" {
+		r["Identifiers"] = []resource{{"DurableNameFormat": "NAA", "DurableName": d.WWN}}
+	}
+	return r
+}
+
+func (n *NIC) resource(path string) resource {
+	r := resource{
+		"@odata.id":  path,
+		"Id":         n.ID,
+		"MACAddress": n.MACAddress,
+		"SpeedMbps":  n.SpeedMbps,
+	}
+	if n.IPv4Address != "" {
+		r["IPv4Addresses"] = []resource{{"Address": n.IPv4Address}}
+	}
+	return r
+}
+
+func (s *System) patchBoot(body map[string]interface{}) error {
+	boot, ok := body["Boot"].(map[string]interface{})
+	if !ok {
+		return badRequest("only Boot can be changed")
+	}
+	for name, value := range boot {
+		v, _ := value.(string)
+		switch name {
+		case "BootSourceOverrideTarget":
+			s.Boot.BootSourceOverrideTarget = v
+		case "BootSourceOverrideEnabled":
+			s.Boot.BootSourceOverrideEnabled = v
+		case "BootSourceOverrideMode":
+			s.Boot.BootSourceOverrideMode = v
+		default:
+			return badRequest("unknown Boot property %s", name)
+		}
+	}
+	return nil
+}
+
+// reset changes the power state of the system. Pending BIOS settings
+// are applied and one-time boot overrides consumed whenever the system
+// boots.
+func (s *System) reset(resetType string) error {
+	boot := false
+	switch resetType {
+	case "On":
+		if s.PowerState == "On" {
+			return &httpError{http.StatusConflict, "system is already on"}
+		}
+		boot = true
+	case "ForceOff", "GracefulShutdown":
+		s.PowerState = "Off"
+	case "ForceRestart", "GracefulRestart":
+		boot = true
+	case "PushPowerButton":
+		if s.PowerState == "On" {
+			s.PowerState = "Off"
+		} else {
+			boot = true
+		}
+	default:
+		return badRequest("unsupported ResetType %q", resetType)
+	}
+
+	if boot {
+		s.PowerState = "On"
+		for name, value := range s.PendingBiosAttributes {
+			s.BiosAttributes[name] = value
+		}
+		s.PendingBiosAttributes = nil
+		if s.Boot.BootSourceOverrideEnabled == "Once" {
+			s.Boot.BootSourceOverrideEnabled = "Disabled"
+		}
+	}
+	return nil
+}
@@ -0,0 +1,188 @@
+package redfishemulator
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type testClient struct {
+	t        *testing.T
+	server   *httptest.Server
+	password string
+}
+
+func (c *testClient) do(method, path string, body interface{}, out interface{}) int {
+	var content []byte
+	if body != nil {
+		content, _ = json.Marshal(body)
+	}
+	req, err := http.NewRequest(method, c.server.URL+path, bytes.NewReader(content))
+	if err != nil {
+		c.t.Fatal(err)
+	}
+	req.SetBasicAuth("admin", c.password)
+	resp, err := c.server.Client().Do(req)
+	if err != nil {
+		c.t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if out != nil && resp.StatusCode == http.StatusOK {
+		if err = json.NewDecoder(resp.Body).Decode(out); err != nil {
+			c.t.Fatal(err)
+		}
+	}
+	return resp.StatusCode
+}
+
+func newTestEmulator(t *testing.T) (*Emulator, *testClient) {
+	emulator := New("admin", "password")
+	emulator.AddSystem(DefaultSystem("1"))
+	server := httptest.NewServer(emulator)
+	t.Cleanup(server.Close)
+	return emulator, &testClient{t: t, server: server, password: "password"}
+}
+
+func TestAuthentication(t *testing.T) {
+	_, client := newTestEmulator(t)
+
+	if status := client.do(http.MethodGet, "/redfish/v1", nil, nil); status != http.StatusOK {
+		t.Errorf("expected the service root to be public, got %d", status)
+	}
+	client.password = "wrong"
+	if status := client.do(http.MethodGet, "/redfish/v1/Systems", nil, nil); status != http.StatusUnauthorized {
+		t.Errorf("expected %d, got %d", http.StatusUnauthorized, status)
+	}
+}
+
+func TestInventory(t *testing.T) {
+	_, client := newTestEmulator(t)
+
+	systems := struct {
+		Members []map[string]string
+	}{}
+	client.do(http.MethodGet, "/redfish/v1/Systems", nil, &systems)
+	if len(systems.Members) != 1 || systems.Members[0]["@odata.id"] != "/redfish/v1/Systems/1" {
+		t.Fatalf("unexpected systems %v", systems)
+	}
+
+	for _, path := range []string{
+		"/redfish/v1/Systems/1",
+		"/redfish/v1/Systems/1/Processors/CPU1",
+		"/redfish/v1/Systems/1/Storage/1/Drives/1",
+		"/redfish/v1/Systems/1/EthernetInterfaces/1",
+		"/redfish/v1/Systems/1/Bios",
+		"/redfish/v1/Managers/1/VirtualMedia/Cd",
+	} {
+		if status := client.do(http.MethodGet, path, nil, nil); status != http.StatusOK {
+			t.Errorf("GET %s returned %d", path, status)
+		}
+	}
+	if status := client.do(http.MethodGet, "/redfish/v1/Systems/2", nil, nil); status != http.StatusNotFound {
+		t.Errorf("expected %d, got %d", http.StatusNotFound, status)
+	}
+}
+
+func TestResetAppliesBiosSettings(t *testing.T) {
+	emulator, client := newTestEmulator(t)
+
+	status := client.do(http.MethodPatch, "/redfish/v1/Systems/1/Bios/Settings",
+		map[string]interface{}{"Attributes": map[string]string{"SriovGlobalEnable": "Enabled"}}, nil)
+	if status != http.StatusNoContent {
+		t.Fatalf("unexpected status %d", status)
+	}
+	status = client.do(http.MethodPatch, "/redfish/v1/Systems/1/Bios/Settings",
+		map[string]interface{}{"Attributes": map[string]string{"Unknown": "Enabled"}}, nil)
+	if status != http.StatusBadRequest {
+		t.Errorf("expected an unknown attribute to be rejected, got %d", status)
+	}
+
+	status = client.do(http.MethodPost, "/redfish/v1/Systems/1/Actions/ComputerSystem.Reset",
+		map[string]string{"ResetType": "On"}, nil)
+	if status != http.StatusNoContent {
+		t.Fatalf("unexpected status %d", status)
+	}
+
+	system, _ := emulator.System("1")
+	if system.PowerState != "On" {
+		t.Errorf("expected the system to be on, got %s", system.PowerState)
+	}
+	if system.BiosAttributes["SriovGlobalEnable"] != "Enabled" || len(system.PendingBiosAttributes) != 0 {
+		t.Errorf("expected the pending BIOS settings to be applied, got %v", system.BiosAttributes)
+	}
+}
+
+func TestVirtualMedia(t *testing.T) {
+	emulator, client := newTestEmulator(t)
+	insert := "/redfish/v1/Managers/1/VirtualMedia/Cd/Actions/VirtualMedia.InsertMedia"
+
+	body := map[string]interface{}{"Image": "http://example.test/live.iso"}
+	if status := client.do(http.MethodPost, insert, body, nil); status != http.StatusNoContent {
+		t.Fatalf("unexpected status %d", status)
+	}
+	if status := client.do(http.MethodPost, insert, body, nil); status != http.StatusConflict {
+		t.Errorf("expected inserting twice to fail, got %d", status)
+	}
+	system, _ := emulator.System("1")
+	if !system.VirtualMedia.Inserted || system.VirtualMedia.Image != "http://example.test/live.iso" {
+		t.Errorf("unexpected virtual media %v", system.VirtualMedia)
+	}
+
+	client.do(http.MethodPost, "/redfish/v1/Managers/1/VirtualMedia/Cd/Actions/VirtualMedia.EjectMedia",
+		map[string]interface{}{}, nil)
+	system, _ = emulator.System("1")
+	if system.VirtualMedia.Inserted {
+		t.Error("expected the media to be ejected")
+	}
+	if len(emulator.Requests()) != 3 {
+		t.Errorf("expected 3 requests, got %v", emulator.Requests())
+	}
+}
+
+func TestFaults(t *testing.T) {
+	emulator, client := newTestEmulator(t)
+	emulator.InjectFault(Fault{
+		Method:     http.MethodGet,
+		PathPrefix: "/redfish/v1/Systems/1",
+		StatusCode: http.StatusServiceUnavailable,
+		Count:      2,
+	})
+
+	for i, expected := range []int{http.StatusServiceUnavailable, http.StatusServiceUnavailable, http.StatusOK} {
+		if status := client.do(http.MethodGet, "/redfish/v1/Systems/1", nil, nil); status != expected {
+			t.Errorf("request %d: expected %d, got %d", i, expected, status)
+		}
+	}
+
+	emulator.InjectFault(Fault{StatusCode: http.StatusInternalServerError})
+	if status := client.do(http.MethodGet, "/redfish/v1", nil, nil); status != http.StatusInternalServerError {
+		t.Errorf("expected %d, got %d", http.StatusInternalServerError, status)
+	}
+	emulator.ClearFaults()
+	if status := client.do(http.MethodGet, "/redfish/v1", nil, nil); status != http.StatusOK {
+		t.Errorf("expected %d, got %d", http.StatusOK, status)
+	}
+}
+
+func TestChangePassword(t *testing.T) {
+	emulator, client := newTestEmulator(t)
+	path := "/redfish/v1/AccountService/Accounts/1"
+
+	account := map[string]interface{}{}
+	client.do(http.MethodGet, path, nil, &account)
+	if account["UserName"] != "admin" {
+		t.Fatalf("unexpected account %v", account)
+	}
+
+	if status := client.do(http.MethodPatch, path, map[string]string{"Password": "new-password"}, nil); status != http.StatusNoContent {
+		t.Fatalf("unexpected status %d", status)
+	}
+	if emulator.Password() != "new-password" {
+		t.Errorf("expected the password to change")
+	}
+	if status := client.do(http.MethodGet, path, nil, nil); status != http.StatusUnauthorized {
+		t.Errorf("expected the old password to be rejected, got %d", status)
+	}
+}
@@ -0,0 +1,140 @@
+package redfishemulator
+
+// System is the in-memory model of one ComputerSystem served by the
+// emulator, along with the Manager and virtual CD drive attached to
+// it.
+type System struct {
+	ID           string `json:"id"`
+	Name         string `json:"name,omitempty"`
+	Manufacturer string `json:"manufacturer,omitempty"`
+	Model        string `json:"model,omitempty"`
+	SerialNumber string `json:"serialNumber,omitempty"`
+	HostName     string `json:"hostName,omitempty"`
+	BiosVersion  string `json:"biosVersion,omitempty"`
+
+	// PowerState is either "On" or "Off".
+	PowerState string `json:"powerState,omitempty"`
+
+	CPU       CPU     `json:"cpu,omitempty"`
+	MemoryGiB float64 `json:"memoryGiB,omitempty"`
+	Drives    []Drive `json:"drives,omitempty"`
+	NICs      []NIC   `json:"nics,omitempty"`
+
+	// BiosAttributes are the current BIOS settings. Changes made
+	// through the Bios/Settings resource are kept in
+	// PendingBiosAttributes and applied when the system is reset.
+	BiosAttributes        map[string]interface{} `json:"biosAttributes,omitempty"`
+	PendingBiosAttributes map[string]interface{} `json:"pendingBiosAttributes,omitempty"`
+
+	Boot         Boot         `json:"boot,omitempty"`
+	VirtualMedia VirtualMedia `json:"virtualMedia,omitempty"`
+}
+
+// CPU describes the processors of a System. Each socket is served as
+// a separate Processor resource.
+type CPU struct {
+	Sockets        int    `json:"sockets,omitempty"`
+	ThreadsPerCPU  int    `json:"threadsPerCPU,omitempty"`
+	Model          string `json:"model,omitempty"`
+	MaxSpeedMHz    int    `json:"maxSpeedMHz,omitempty"`
+	InstructionSet string `json:"instructionSet,omitempty"`
+}
+
+// Drive is one disk of a System, served under its only Storage
+// controller.
+type Drive struct {
+	ID            string `json:"id"`
+	Name          string `json:"name,omitempty"`
+	Model         string `json:"model,omitempty"`
+	Manufacturer  string `json:"manufacturer,omitempty"`
+	SerialNumber  string `json:"serialNumber,omitempty"`
+	MediaType     string `json:"mediaType,omitempty"`
+	Protocol      string `json:"protocol,omitempty"`
+	CapacityBytes int64  `json:"capacityBytes,omitempty"`
+	WWN           string `json:"wwn,omitempty"`
+}
+
+// NIC is one EthernetInterface of a System.
+type NIC struct {
+	ID          string `json:"id"`
+	MACAddress  string `json:"macAddress"`
+	SpeedMbps   int    `json:"speedMbps,omitempty"`
+	IPv4Address string `json:"ipv4Address,omitempty"`
+}
+
+// Boot holds the boot source override of a System.
+type Boot struct {
+	BootSourceOverrideTarget  string `json:"bootSourceOverrideTarget,omitempty"`
+	BootSourceOverrideEnabled string `json:"bootSourceOverrideEnabled,omitempty"`
+	BootSourceOverrideMode    string `json:"bootSourceOverrideMode,omitempty"`
+}
+
+// VirtualMedia is the state of the virtual CD drive of a System.
+type VirtualMedia struct {
+	Image    string `json:"image,omitempty"`
+	Inserted bool   `json:"inserted,omitempty"`
+}
+
+// DefaultSystem returns a System with a plausible inventory, powered
+// off and with the given ID.
+func DefaultSystem(id string) System {
+	return System{
+		ID:           id,
+		Name:         "System " + id,
+		Manufacturer: "Metal3",
+		Model:        "Emulated",
+		SerialNumber: "EMU-" + id,
+		BiosVersion:  "1.0.0",
+		PowerState:   "Off",
+		CPU: CPU{
+			Sockets:        1,
+			ThreadsPerCPU:  4,
+			Model:          "Emulated CPU",
+			MaxSpeedMHz:    2400,
+			InstructionSet: "x86-64",
+		},
+		MemoryGiB: 8,
+		Drives: []Drive{
+			{
+				ID:            "1",
+				Name:          "Drive 1",
+				Model:         "Emulated Disk",
+				MediaType:     "SSD",
+				Protocol:      "SATA",
+				CapacityBytes: 100 * 1024 * 1024 * 1024,
+			},
+		},
+		NICs: []NIC{
+			{
+				ID:         "1",
+				MACAddress: "52:54:00:00:00:01",
+				SpeedMbps:  1000,
+			},
+		},
+		BiosAttributes: map[string]interface{}{
+			"ProcVirtualization": "Enabled",
+			"LogicalProc":        "Enabled",
+			"SriovGlobalEnable":  "Disabled",
+		},
+	}
+}
+
+func (s *System) copy() System {
+	out := *s
+	out.Drives = append([]Drive(nil), s.Drives...)
+	out.NICs = append([]NIC(nil), s.NICs...)
+	out.BiosAttributes = copyAttributes(s.BiosAttributes)
+	out.PendingBiosAttributes = copyAttributes(s.PendingBiosAttributes)
+	return out
+}
+
+func copyAttributes(in map[string]interface{}) map[string]interface{} {
+	if in == nil {
+		return nil
+	}
+	out := make(map[string]interface{}, len(in))
+	for k, v := range in {
+		out[k] = v
+	}
+	return out
+}